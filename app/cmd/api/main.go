@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"io/fs"
 	"log"
 	"net/http"
 	"os"
@@ -14,17 +13,21 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/lib/pq"
 
 	migfs "team5/task-manager/db/migrations"
 	"team5/task-manager/internal/config"
+	"team5/task-manager/internal/events"
+	"team5/task-manager/internal/health"
 	"team5/task-manager/internal/httpapi"
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/jobs"
+	"team5/task-manager/internal/jwks"
 	"team5/task-manager/internal/logger"
+	"team5/task-manager/internal/otel"
+	"team5/task-manager/internal/reminders"
+	"team5/task-manager/internal/store/postgres"
+	"team5/task-manager/internal/webhook"
 )
 
 func main() {
@@ -38,39 +41,31 @@ func main() {
 
 	logger.Logger.Info("starting task-manager", "service", cfg.ServiceName, "port", cfg.Port)
 
-	sub, err := fs.Sub(migfs.FS, ".")
-	if err != nil {
-		log.Fatalf("migrations fs: %v", err)
-	}
-
-	src, err := iofs.New(sub, ".")
-	if err != nil {
-		log.Fatalf("migrations source: %v", err)
-	}
-
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("sql open: %v", err)
 	}
 	defer db.Close()
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatalf("migrate driver: %v", err)
+	if err := migfs.Up(db); err != nil {
+		log.Fatal(err)
 	}
 
-	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	logger.Logger.Info("database migrations completed")
+
+	shutdownTracing, err := otel.Init(cfg.ServiceName, cfg.OTelEndpoint, cfg.OTelInsecure)
 	if err != nil {
-		log.Fatalf("migrate init: %v", err)
+		log.Fatalf("otel: %v", err)
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("migrate up: %v", err)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db config: %v", err)
 	}
+	poolCfg.ConnConfig.Tracer = otel.NewPgxTracer()
+	postgres.ConfigurePool(poolCfg)
 
-	logger.Logger.Info("database migrations completed")
-
-	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		log.Fatalf("db: %v", err)
 	}
@@ -79,13 +74,93 @@ func main() {
 	// Note: Go runtime metrics are automatically collected by Prometheus client library
 	// No need for manual collection goroutine
 
-	handler := httpapi.NewRouter(cfg, pool)
+	wantVersion, err := migfs.Latest()
+	if err != nil {
+		log.Fatalf("migrations version: %v", err)
+	}
+
+	probes := health.New()
+	probes.AddCheck("postgres", health.PostgresPing(pool))
+	probes.AddCheck("postgres_pool", health.PoolSaturation(pool))
+	probes.AddCheck("migrations", health.Migrations(pool, wantVersion))
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go probes.RunHeartbeat(bgCtx)
+
+	idempotency := postgres.NewIdempotencyStore(pool)
+	go jobs.Every(bgCtx, logger.Logger, "idempotency_sweeper", cfg.IdempotencySweepInterval, func(ctx context.Context) error {
+		n, err := idempotency.DeleteExpired(ctx, time.Now())
+		if n > 0 {
+			logger.Logger.Info("expired idempotency keys deleted", "count", n)
+		}
+		return err
+	})
+
+	if cfg.TrashRetentionDays > 0 {
+		tasks := postgres.NewTasksStore(pool, postgres.Options{SearchLanguage: cfg.SearchLanguage})
+		retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+		go jobs.Every(bgCtx, logger.Logger, "trash_purge", cfg.TrashPurgeInterval, func(ctx context.Context) error {
+			n, err := tasks.PurgeDeleted(ctx, time.Now().Add(-retention))
+			if n > 0 {
+				logger.Logger.Info("trashed tasks purged", "count", n)
+			}
+			return err
+		})
+	}
+
+	if cfg.ReminderInterval > 0 {
+		var notifier reminders.Notifier = reminders.LogNotifier{Log: logger.Logger}
+		if cfg.ReminderWebhookURL != "" {
+			notifier = reminders.NewWebhookNotifier(cfg.ReminderWebhookURL, cfg.ReminderWebhookSecret, 10*time.Second)
+		}
+		// Every replica ticks; the advisory lock lets one of them work at a time
+		scheduler := reminders.NewScheduler(
+			postgres.NewReminderStore(pool),
+			notifier,
+			postgres.NewAdvisoryLock(pool, reminders.LockKey),
+			logger.Logger,
+			reminders.Options{Window: cfg.ReminderWindow, MaxAttempts: cfg.ReminderMaxAttempts},
+		)
+		go jobs.Every(bgCtx, logger.Logger, "reminders", cfg.ReminderInterval, scheduler.Tick)
+	}
+
+	if cfg.WebhookDispatchInterval > 0 {
+		dispatcher := webhook.NewDispatcher(
+			postgres.NewWebhookStore(pool),
+			webhook.NewClient(cfg.WebhookTimeout),
+			postgres.NewAdvisoryLock(pool, webhook.LockKey),
+			logger.Logger,
+			webhook.DispatcherOptions{MaxAttempts: cfg.WebhookMaxAttempts, Timeout: cfg.WebhookTimeout},
+		)
+		go jobs.Every(bgCtx, logger.Logger, "webhooks", cfg.WebhookDispatchInterval, dispatcher.Tick)
+	}
+
+	// Token keys come from the issuer's JWKS; a rotation is picked up by the
+	// periodic reload, or sooner when a token names a kid not seen yet
+	var keys middleware.KeySource
+	if cfg.JWKSource != "" {
+		keySet := jwks.New(cfg.JWKSource, 10*time.Second)
+		if err := keySet.Refresh(context.Background()); err != nil {
+			log.Fatalf("jwks: %v", err)
+		}
+		go jobs.Every(bgCtx, logger.Logger, "jwks_refresh", cfg.JWKSRefreshInterval, keySet.Refresh)
+		keys = keySet
+	}
+
+	// Task changes committed on any replica reach the event streams of this one
+	changes := events.NewBroker(0)
+	go postgres.NewListener(pool, changes, logger.Logger).Run(bgCtx)
+
+	handler := httpapi.NewRouter(cfg, pool, probes, changes, keys)
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.Port),
 		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	// Event streams never end on their own: close them so Shutdown can finish
+	srv.RegisterOnShutdown(changes.DisconnectAll)
 
 	go func() {
 		log.Printf("listening on :%d", cfg.Port)
@@ -98,7 +173,18 @@ func main() {
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
+	// Fail readiness first and give the load balancer time to notice before
+	// refusing connections
+	probes.StartDraining()
+	logger.Logger.Info("draining", "delay", cfg.ShutdownDrainDelay.String())
+	time.Sleep(cfg.ShutdownDrainDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
+
+	// Flush spans still sitting in the batcher
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Logger.Error("otel shutdown", "error", err)
+	}
 }
@@ -0,0 +1,5 @@
+-- Rows created before ownership existed get an empty owner and are no longer
+-- reachable through the API.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner_id text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_id ON tasks(owner_id);
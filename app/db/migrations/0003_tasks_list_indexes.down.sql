@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_owner_updated_at;
+DROP INDEX IF EXISTS idx_tasks_owner_created_at;
@@ -0,0 +1,4 @@
+-- Keyset pagination on GET /tasks orders by (<sort column>, id) within an owner.
+-- due_date ordering is served by the existing idx_tasks_due_date.
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_created_at ON tasks(owner_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_updated_at ON tasks(owner_id, updated_at, id);
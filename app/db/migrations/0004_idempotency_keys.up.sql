@@ -0,0 +1,14 @@
+-- Responses of POST /tasks keyed by (owner, Idempotency-Key).
+-- status_code stays NULL while the first request is still being processed.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  owner_id text NOT NULL,
+  key text NOT NULL,
+  request_hash text NOT NULL,
+  status_code integer,
+  response_body bytea,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  expires_at timestamptz NOT NULL,
+  PRIMARY KEY (owner_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
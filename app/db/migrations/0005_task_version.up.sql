@@ -0,0 +1,2 @@
+-- Optimistic concurrency: exposed as the task ETag, checked against If-Match.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
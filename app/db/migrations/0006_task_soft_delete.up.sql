@@ -0,0 +1,4 @@
+-- DELETE /tasks/:id moves tasks to the trash; the purge job removes them later.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
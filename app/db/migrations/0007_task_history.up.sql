@@ -0,0 +1,27 @@
+-- Append-only audit log of task mutations, written in the mutation's transaction.
+-- No foreign key: the history outlives purged tasks.
+CREATE TABLE IF NOT EXISTS task_history (
+  id bigserial PRIMARY KEY,
+  task_id uuid NOT NULL,
+  owner_id text NOT NULL,
+  actor text NOT NULL,
+  action text NOT NULL,
+  correlation_id text NOT NULL DEFAULT '',
+  request_timestamp timestamptz,
+  changes jsonb NOT NULL DEFAULT '{}',
+  version bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_history_task_id ON task_history(task_id, id);
+
+CREATE OR REPLACE FUNCTION task_history_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'task_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_history_append_only ON task_history;
+CREATE TRIGGER task_history_append_only
+  BEFORE UPDATE OR DELETE ON task_history
+  FOR EACH ROW EXECUTE FUNCTION task_history_append_only();
@@ -0,0 +1,16 @@
+-- Tags are per owner; a task holds any number of them
+CREATE TABLE IF NOT EXISTS tags (
+  id bigserial PRIMARY KEY,
+  owner_id text NOT NULL,
+  name text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (owner_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_tags (
+  task_id uuid NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  tag_id bigint NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags(tag_id);
@@ -0,0 +1,11 @@
+-- Full-text search. Each row keeps the text search configuration it was
+-- indexed with (config.SearchLanguage at write time), the vector follows it.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_language regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector(search_language, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(search_language, coalesce(content, '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tasks_owner_status;
+
+ALTER TABLE tasks DROP COLUMN done;
+ALTER TABLE tasks ADD COLUMN done boolean NOT NULL DEFAULT false;
+UPDATE tasks SET done = (status = 'done');
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
+ALTER TABLE tasks DROP COLUMN IF EXISTS status;
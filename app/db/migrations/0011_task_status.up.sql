@@ -0,0 +1,12 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'todo'
+  CHECK (status IN ('todo', 'in_progress', 'blocked', 'done', 'cancelled'));
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority text NOT NULL DEFAULT 'medium'
+  CHECK (priority IN ('low', 'medium', 'high', 'urgent'));
+
+UPDATE tasks SET status = 'done' WHERE done;
+
+-- done is kept for existing clients, derived from status
+ALTER TABLE tasks DROP COLUMN done;
+ALTER TABLE tasks ADD COLUMN done boolean GENERATED ALWAYS AS (status = 'done') STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_status ON tasks (owner_id, status) WHERE deleted_at IS NULL;
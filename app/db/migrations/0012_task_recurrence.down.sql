@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence_start;
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence;
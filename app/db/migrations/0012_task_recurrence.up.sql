@@ -0,0 +1,4 @@
+-- RFC 5545 RRULE of the series the task is the open occurrence of, and the
+-- series' first due date (its DTSTART).
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence text;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence_start date;
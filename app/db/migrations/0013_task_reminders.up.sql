@@ -0,0 +1,17 @@
+-- Due-date reminders, one per task and due date. Rescheduling a task leaves
+-- the old reminder skipped and makes room for a new one.
+CREATE TABLE IF NOT EXISTS task_reminders (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  task_id uuid NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  owner_id text NOT NULL,
+  due_date date NOT NULL,
+  status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  sent_at timestamptz,
+  UNIQUE (task_id, due_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_reminders_pending ON task_reminders (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,58 @@
+-- Webhook subscriptions of an owner; an empty events array means every event
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id text NOT NULL,
+  url text NOT NULL,
+  secret text NOT NULL,
+  events text[] NOT NULL DEFAULT '{}',
+  active boolean NOT NULL DEFAULT true,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_owner_id ON webhook_subscriptions(owner_id, created_at);
+
+-- Transactional outbox: one row per task mutation, written in its transaction.
+-- No foreign key: events outlive purged tasks.
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+  id bigserial PRIMARY KEY,
+  event_id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  owner_id text NOT NULL,
+  event_type text NOT NULL,
+  task_id uuid NOT NULL,
+  payload jsonb NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  dispatched_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_outbox_undispatched ON webhook_outbox(id) WHERE dispatched_at IS NULL;
+
+-- One event for one subscription; dead rows are the dead letters
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  subscription_id uuid NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event_id bigint NOT NULL REFERENCES webhook_outbox(id) ON DELETE CASCADE,
+  status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  delivered_at timestamptz,
+  UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+  id bigserial PRIMARY KEY,
+  delivery_id uuid NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+  subscription_id uuid NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  attempt integer NOT NULL,
+  outcome text NOT NULL CHECK (outcome IN ('delivered', 'retry', 'dead')),
+  status_code integer,
+  error text,
+  duration_ms bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_subscription_id ON webhook_attempts(subscription_id, id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_history_notify ON task_history;
+DROP FUNCTION IF EXISTS task_history_notify();
+DROP INDEX IF EXISTS idx_task_history_owner_id;
@@ -0,0 +1,16 @@
+-- task_history doubles as the change log of GET /tasks/events: every entry is
+-- announced on the task_changes channel once its transaction commits, and
+-- reconnecting clients resume from their last entry id.
+CREATE INDEX IF NOT EXISTS idx_task_history_owner_id ON task_history(owner_id, id);
+
+CREATE OR REPLACE FUNCTION task_history_notify() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('task_changes', json_build_object('id', NEW.id, 'owner_id', NEW.owner_id)::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_history_notify ON task_history;
+CREATE TRIGGER task_history_notify
+  AFTER INSERT ON task_history
+  FOR EACH ROW EXECUTE FUNCTION task_history_notify();
@@ -0,0 +1,17 @@
+-- Projects group the tasks of an owner; archived ones are hidden with their tasks
+CREATE TABLE IF NOT EXISTS projects (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id text NOT NULL,
+  name text NOT NULL,
+  description text NOT NULL DEFAULT '',
+  archived_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_owner_id ON projects(owner_id, name);
+
+-- Only projects without live tasks are deleted: trashed ones just leave it
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id uuid REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id, created_at) WHERE project_id IS NOT NULL;
@@ -0,0 +1,23 @@
+-- Members act on a project and its tasks as its owner, within their role
+CREATE TABLE IF NOT EXISTS project_members (
+  project_id uuid NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  user_id text NOT NULL,
+  role text NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id);
+
+-- One pending invitation per invitee and project; accepting it consumes it
+CREATE TABLE IF NOT EXISTS project_invitations (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  project_id uuid NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  invitee text NOT NULL,
+  role text NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+  invited_by text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (project_id, invitee)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_invitations_invitee ON project_invitations(invitee, created_at);
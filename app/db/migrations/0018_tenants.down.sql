@@ -0,0 +1,22 @@
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (owner_id, key);
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_tenant_id_owner_id_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_owner_id_name_key UNIQUE (owner_id, name);
+
+DO $$
+DECLARE
+  t text;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'tasks', 'task_history', 'tags', 'task_tags', 'idempotency_keys', 'task_reminders',
+    'webhook_subscriptions', 'webhook_outbox', 'webhook_deliveries', 'webhook_attempts',
+    'projects', 'project_members', 'project_invitations'
+  ] LOOP
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS tenant_id', t);
+  END LOOP;
+END
+$$;
@@ -0,0 +1,36 @@
+-- Tenants share the database, isolated by row level security: every row
+-- carries its tenant, and only the rows of the tenant set in app.tenant_id
+-- are visible or writable. The API sets it for the session of each pooled
+-- connection it checks out (postgres.ConfigurePool). Background jobs working
+-- across tenants set app.all_tenants. FORCE makes the policies apply to the
+-- table owner too; superusers and BYPASSRLS roles still skip them.
+DO $$
+DECLARE
+  t text;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'tasks', 'task_history', 'tags', 'task_tags', 'idempotency_keys', 'task_reminders',
+    'webhook_subscriptions', 'webhook_outbox', 'webhook_deliveries', 'webhook_attempts',
+    'projects', 'project_members', 'project_invitations'
+  ] LOOP
+    -- Existing rows belong to the default tenant; new rows to the current one
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT %L', t, 'default');
+    EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting(%L, true), %L)', t, 'app.tenant_id', '');
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format($p$
+      CREATE POLICY tenant_isolation ON %I
+        USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '') OR current_setting('app.all_tenants', true) = 'on')
+        WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '') OR current_setting('app.all_tenants', true) = 'on')
+    $p$, t);
+  END LOOP;
+END
+$$;
+
+-- Owners are per tenant: the same subject in two tenants is two users
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_owner_id_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_tenant_id_owner_id_name_key UNIQUE (tenant_id, owner_id, name);
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, owner_id, key);
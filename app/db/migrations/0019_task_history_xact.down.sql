@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_task_history_owner_xact;
+ALTER TABLE task_history DROP COLUMN IF EXISTS xact_horizon;
+ALTER TABLE task_history DROP COLUMN IF EXISTS xact;
@@ -0,0 +1,12 @@
+-- Entry ids are taken before their transaction commits, so an entry may
+-- commit after a higher one was already streamed. Each entry records its
+-- transaction and the oldest transaction still running when it was written:
+-- the entries below a Last-Event-ID that may have committed after it are
+-- those of transactions not older than that horizon. Existing entries keep
+-- NULLs and are never replayed this way.
+ALTER TABLE task_history ADD COLUMN IF NOT EXISTS xact xid8;
+ALTER TABLE task_history ADD COLUMN IF NOT EXISTS xact_horizon xid8;
+ALTER TABLE task_history ALTER COLUMN xact SET DEFAULT pg_current_xact_id();
+ALTER TABLE task_history ALTER COLUMN xact_horizon SET DEFAULT pg_snapshot_xmin(pg_current_snapshot());
+
+CREATE INDEX IF NOT EXISTS idx_task_history_owner_xact ON task_history(owner_id, xact);
@@ -0,0 +1,6 @@
+CREATE OR REPLACE FUNCTION task_history_notify() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('task_changes', json_build_object('id', NEW.id, 'owner_id', NEW.owner_id)::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
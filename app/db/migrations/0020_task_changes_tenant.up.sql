@@ -0,0 +1,8 @@
+-- Notifications carry the tenant of the entry, so that a replica only wakes
+-- the streams of that tenant: owner ids are only unique within one.
+CREATE OR REPLACE FUNCTION task_history_notify() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('task_changes', json_build_object('id', NEW.id, 'tenant_id', NEW.tenant_id, 'owner_id', NEW.owner_id)::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"team5/task-manager/internal/service"
 )
 
 type Config struct {
@@ -11,6 +15,74 @@ type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	ServiceName string
+
+	// JWT claim naming the caller's tenant; tenants share the database but
+	// never see each other's rows
+	JWTTenantClaim string
+
+	// RS256 and ES256 tokens are verified with the keys of JWKSource, a JWKS
+	// URL or file (from JWKS_URL or JWKS_FILE) reloaded every
+	// JWKSRefreshInterval; HS256 tokens with JWTSecret. At least one of them
+	// is required. iss and aud must match JWTIssuer and JWTAudience when set,
+	// and exp, nbf and iat are checked with JWTClockSkew of tolerance
+	JWKSource           string
+	JWKSRefreshInterval time.Duration
+	JWTIssuer           string
+	JWTAudience         string
+	JWTClockSkew        time.Duration
+
+	// Scopes granted to tokens carrying no scope or scp claim, from the space
+	// separated JWT_DEFAULT_SCOPES; none by default (the Helm chart grants
+	// read, write and delete while issuers move to scoped tokens)
+	JWTDefaultScopes []string
+
+	// Tracing is disabled when OTelEndpoint is empty
+	OTelEndpoint string
+	OTelInsecure bool
+
+	// How long /readyz reports draining before the HTTP server stops on SIGTERM
+	ShutdownDrainDelay time.Duration
+
+	// Idempotency-Key records on POST /tasks live for IdempotencyTTL and are
+	// swept every IdempotencySweepInterval
+	IdempotencyTTL           time.Duration
+	IdempotencySweepInterval time.Duration
+
+	// Trashed tasks are purged TrashRetentionDays after deletion (0 keeps them
+	// forever), checked every TrashPurgeInterval
+	TrashRetentionDays int
+	TrashPurgeInterval time.Duration
+
+	// What DELETE /tasks/:id does to live subtasks when the request does not
+	// say: orphan, cascade or block
+	SubtaskDeleteMode string
+
+	// Postgres text search configuration (english, simple, german...) used to
+	// index task title and content and to parse GET /tasks/search queries
+	SearchLanguage string
+
+	// Allowed task status changes, from STATUS_TRANSITIONS written as
+	// "todo=in_progress,done;in_progress=done;done=todo" (see service.ParseWorkflow)
+	StatusWorkflow service.Workflow
+
+	// Open tasks due within ReminderWindow get a reminder, checked every
+	// ReminderInterval (0 disables reminders). Reminders are posted to
+	// ReminderWebhookURL, signed with ReminderWebhookSecret when set, or only
+	// logged without a URL; failed deliveries are retried up to
+	// ReminderMaxAttempts times with exponential backoff
+	ReminderInterval      time.Duration
+	ReminderWindow        time.Duration
+	ReminderWebhookURL    string
+	ReminderWebhookSecret string
+	ReminderMaxAttempts   int
+
+	// Task events are delivered to webhook subscriptions every
+	// WebhookDispatchInterval (0 disables deliveries, events stay in the
+	// outbox), each request bounded by WebhookTimeout, which must be shorter;
+	// a delivery failing WebhookMaxAttempts times is dead-lettered
+	WebhookDispatchInterval time.Duration
+	WebhookTimeout          time.Duration
+	WebhookMaxAttempts      int
 }
 
 func Load() (*Config, error) {
@@ -19,18 +91,89 @@ func Load() (*Config, error) {
 	cfg.Port = getEnvInt("PORT", 8080)
 	cfg.DatabaseURL = os.Getenv("DATABASE_URL")
 	cfg.JWTSecret = os.Getenv("JWT_HS256_SECRET")
+	cfg.JWTTenantClaim = getEnv("JWT_TENANT_CLAIM", "tenant_id")
+	cfg.JWKSRefreshInterval = getEnvDuration("JWKS_REFRESH_INTERVAL", 15*time.Minute)
+	cfg.JWTIssuer = os.Getenv("JWT_ISSUER")
+	cfg.JWTAudience = os.Getenv("JWT_AUDIENCE")
+	cfg.JWTClockSkew = getEnvDuration("JWT_CLOCK_SKEW", 30*time.Second)
+	cfg.JWTDefaultScopes = strings.Fields(os.Getenv("JWT_DEFAULT_SCOPES"))
 	cfg.ServiceName = getEnv("SERVICE_NAME", "task-manager")
+	cfg.OTelEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	cfg.OTelInsecure = getEnvBool("OTEL_EXPORTER_OTLP_INSECURE", false)
+	cfg.ShutdownDrainDelay = getEnvDuration("SHUTDOWN_DRAIN_DELAY", 10*time.Second)
+	cfg.IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	cfg.IdempotencySweepInterval = getEnvDuration("IDEMPOTENCY_SWEEP_INTERVAL", 10*time.Minute)
+	cfg.TrashRetentionDays = getEnvInt("TRASH_RETENTION_DAYS", 30)
+	cfg.TrashPurgeInterval = getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	cfg.SubtaskDeleteMode = getEnv("SUBTASK_DELETE_MODE", "orphan")
+	cfg.SearchLanguage = getEnv("SEARCH_LANGUAGE", "english")
+	cfg.ReminderInterval = getEnvDuration("REMINDER_INTERVAL", time.Minute)
+	cfg.ReminderWindow = getEnvDuration("REMINDER_WINDOW", 24*time.Hour)
+	cfg.ReminderWebhookURL = os.Getenv("REMINDER_WEBHOOK_URL")
+	cfg.ReminderWebhookSecret = os.Getenv("REMINDER_WEBHOOK_SECRET")
+	cfg.ReminderMaxAttempts = getEnvInt("REMINDER_MAX_ATTEMPTS", 8)
+	cfg.WebhookDispatchInterval = getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second)
+	cfg.WebhookTimeout = getEnvDuration("WEBHOOK_TIMEOUT", 4*time.Second)
+	cfg.WebhookMaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10)
 
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
-	if cfg.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_HS256_SECRET is required")
+	switch url, file := os.Getenv("JWKS_URL"), os.Getenv("JWKS_FILE"); {
+	case url != "" && file != "":
+		return nil, fmt.Errorf("JWKS_URL and JWKS_FILE are exclusive")
+	case url != "":
+		if err := service.CheckEndpointURL("JWKS_URL", url); err != nil {
+			return nil, err
+		}
+		cfg.JWKSource = url
+	default:
+		cfg.JWKSource = file
+	}
+	if cfg.JWTSecret == "" && cfg.JWKSource == "" {
+		return nil, fmt.Errorf("JWT_HS256_SECRET, JWKS_URL or JWKS_FILE is required")
+	}
+	if cfg.JWKSource != "" && cfg.JWKSRefreshInterval <= 0 {
+		return nil, fmt.Errorf("JWKS_REFRESH_INTERVAL must be positive")
+	}
+	switch cfg.SubtaskDeleteMode {
+	case "orphan", "cascade", "block":
+	default:
+		return nil, fmt.Errorf("SUBTASK_DELETE_MODE must be orphan, cascade or block")
+	}
+	if !isIdentifier(cfg.SearchLanguage) {
+		return nil, fmt.Errorf("SEARCH_LANGUAGE must be a text search configuration name")
+	}
+	workflow, err := service.ParseWorkflow(os.Getenv("STATUS_TRANSITIONS"))
+	if err != nil {
+		return nil, fmt.Errorf("STATUS_TRANSITIONS: %w", err)
+	}
+	cfg.StatusWorkflow = workflow
+	if cfg.WebhookDispatchInterval > 0 && (cfg.WebhookTimeout <= 0 || cfg.WebhookTimeout >= cfg.WebhookDispatchInterval) {
+		return nil, fmt.Errorf("WEBHOOK_TIMEOUT must be positive and shorter than WEBHOOK_DISPATCH_INTERVAL")
+	}
+	if cfg.ReminderWebhookURL != "" {
+		if err := service.CheckEndpointURL("REMINDER_WEBHOOK_URL", cfg.ReminderWebhookURL); err != nil {
+			return nil, err
+		}
 	}
 
 	return cfg, nil
 }
 
+// isIdentifier reports whether s is a plain lowercase SQL identifier.
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
 func getEnv(key, def string) string {
 	v := os.Getenv(key)
 	if v == "" {
@@ -51,3 +194,26 @@ func getEnvInt(key string, def int) int {
 	return i
 }
 
+func getEnvBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
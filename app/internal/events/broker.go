@@ -0,0 +1,97 @@
+// Package events fans the task change notifications of a replica out to the
+// event streams of its clients.
+package events
+
+import "sync"
+
+// DefaultBuffer is how many notifications a subscriber may fall behind by.
+const DefaultBuffer = 256
+
+// Broker is an in-process store.ChangeFeed. It never blocks publishers: a
+// subscriber that falls behind is disconnected and must catch up from the
+// change log.
+type Broker struct {
+	buffer int
+
+	mu     sync.Mutex
+	subs   map[owner]map[chan int64]struct{}
+	owners map[chan int64][]owner // of each subscription
+}
+
+// owner identifies an owner across tenants.
+type owner struct {
+	tenant, id string
+}
+
+func NewBroker(buffer int) *Broker {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Broker{buffer: buffer, subs: make(map[owner]map[chan int64]struct{}), owners: make(map[chan int64][]owner)}
+}
+
+// Subscribe returns the history entry IDs published for any of ownerIDs in
+// tenant from now on. The channel is closed by cancel, or early when the
+// subscriber lags or the feed is interrupted (see DisconnectAll).
+func (b *Broker) Subscribe(tenant string, ownerIDs ...string) (<-chan int64, func()) {
+	ch := make(chan int64, b.buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	owners := make([]owner, len(ownerIDs))
+	for i, id := range ownerIDs {
+		o := owner{tenant, id}
+		if b.subs[o] == nil {
+			b.subs[o] = make(map[chan int64]struct{})
+		}
+		b.subs[o][ch] = struct{}{}
+		owners[i] = o
+	}
+	b.owners[ch] = owners
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(ch)
+	}
+}
+
+// Publish announces a new history entry of ownerID in tenant.
+func (b *Broker) Publish(tenant, ownerID string, id int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[owner{tenant, ownerID}] {
+		select {
+		case ch <- id:
+		default:
+			b.drop(ch)
+		}
+	}
+}
+
+// DisconnectAll closes every subscription, for when notifications may have
+// been lost or the server is shutting down.
+func (b *Broker) DisconnectAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.owners {
+		b.drop(ch)
+	}
+}
+
+// drop closes a subscription once; the caller holds the lock.
+func (b *Broker) drop(ch chan int64) {
+	owners, ok := b.owners[ch]
+	if !ok {
+		return
+	}
+	delete(b.owners, ch)
+	for _, o := range owners {
+		subs := b.subs[o]
+		delete(subs, ch)
+		if len(subs) == 0 {
+			delete(b.subs, o)
+		}
+	}
+	close(ch)
+}
@@ -0,0 +1,100 @@
+package events
+
+import "testing"
+
+func TestBrokerRoutesByOwner(t *testing.T) {
+	b := NewBroker(4)
+	alice, cancel := b.Subscribe("t", "alice")
+	defer cancel()
+	bob, cancelBob := b.Subscribe("t", "bob")
+	defer cancelBob()
+
+	b.Publish("t", "alice", 1)
+	b.Publish("t", "carol", 2)
+	if id := <-alice; id != 1 {
+		t.Errorf("alice got %d", id)
+	}
+	select {
+	case id := <-bob:
+		t.Errorf("bob got %d", id)
+	default:
+	}
+}
+
+func TestBrokerRoutesByTenant(t *testing.T) {
+	b := NewBroker(4)
+	acme, cancel := b.Subscribe("acme", "alice")
+	defer cancel()
+	globex, cancelGlobex := b.Subscribe("globex", "alice")
+	defer cancelGlobex()
+
+	b.Publish("acme", "alice", 1)
+	if id := <-acme; id != 1 {
+		t.Errorf("acme got %d", id)
+	}
+	select {
+	case id := <-globex:
+		t.Errorf("the same owner in another tenant got %d", id)
+	default:
+	}
+}
+
+func TestBrokerSubscribesToSeveralOwners(t *testing.T) {
+	b := NewBroker(1)
+	both, cancel := b.Subscribe("t", "alice", "bob")
+	defer cancel()
+
+	b.Publish("t", "bob", 1)
+	if id := <-both; id != 1 {
+		t.Errorf("got %d", id)
+	}
+	// Lagging behind one owner ends the subscription to all of them
+	b.Publish("t", "alice", 2)
+	b.Publish("t", "bob", 3)
+	if id := <-both; id != 2 {
+		t.Errorf("got %d", id)
+	}
+	if _, ok := <-both; ok {
+		t.Error("still subscribed after lagging")
+	}
+	b.Publish("t", "alice", 4) // no subscribers left
+}
+
+func TestBrokerDisconnectsLaggingSubscriber(t *testing.T) {
+	b := NewBroker(2)
+	slow, cancel := b.Subscribe("t", "alice")
+	defer cancel()
+	for id := int64(1); id <= 3; id++ {
+		b.Publish("t", "alice", id)
+	}
+	var got []int64
+	for id := range slow {
+		got = append(got, id)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %v before the disconnect, want the 2 buffered", got)
+	}
+
+	// A new subscriber is unaffected
+	fresh, cancelFresh := b.Subscribe("t", "alice")
+	defer cancelFresh()
+	b.Publish("t", "alice", 4)
+	if id := <-fresh; id != 4 {
+		t.Errorf("fresh got %d", id)
+	}
+}
+
+func TestBrokerDisconnectAll(t *testing.T) {
+	b := NewBroker(0)
+	a, cancelA := b.Subscribe("t", "alice")
+	c, _ := b.Subscribe("t", "bob")
+	b.DisconnectAll()
+	cancelA() // after the disconnect, a no-op
+	if _, ok := <-a; ok {
+		t.Error("alice still subscribed")
+	}
+	if _, ok := <-c; ok {
+		t.Error("bob still subscribed")
+	}
+	b.Publish("t", "alice", 1) // no subscribers left
+}
@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses. A "warn" check is reported but does not make the pod unready.
+const (
+	StatusOK       = "ok"
+	StatusWarn     = "warn"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// CheckFunc probes one dependency. Details are surfaced as-is in the readiness report.
+type CheckFunc func(ctx context.Context) (status string, details map[string]any, err error)
+
+type CheckResult struct {
+	Status    string         `json:"status"`
+	LatencyMS float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// Probes backs /healthz and /readyz.
+// Readiness runs every registered check and flips to draining for good once
+// StartDraining is called. Liveness only looks at a heartbeat goroutine, so it
+// stays cheap but fails when the process stops scheduling work.
+type Probes struct {
+	checks       []namedCheck
+	checkTimeout time.Duration
+	stallAfter   time.Duration
+
+	draining  atomic.Bool
+	heartbeat atomic.Int64 // unix nanos of the last beat
+}
+
+func New() *Probes {
+	p := &Probes{
+		checkTimeout: time.Second,
+		stallAfter:   10 * time.Second,
+	}
+	p.heartbeat.Store(time.Now().UnixNano())
+	return p
+}
+
+func (p *Probes) AddCheck(name string, fn CheckFunc) {
+	p.checks = append(p.checks, namedCheck{name: name, fn: fn})
+}
+
+// StartDraining makes readiness report 503 from now on, so the load balancer
+// stops routing new requests before the HTTP server shuts down.
+func (p *Probes) StartDraining() {
+	p.draining.Store(true)
+}
+
+func (p *Probes) Draining() bool {
+	return p.draining.Load()
+}
+
+// RunHeartbeat beats once per second until ctx is done.
+func (p *Probes) RunHeartbeat(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			p.heartbeat.Store(now.UnixNano())
+		}
+	}
+}
+
+// Alive reports whether the heartbeat is recent, and its age.
+func (p *Probes) Alive() (bool, time.Duration) {
+	age := time.Since(time.Unix(0, p.heartbeat.Load()))
+	return age < p.stallAfter, age
+}
+
+// Ready runs all checks concurrently, each bounded by the check timeout.
+func (p *Probes) Ready(ctx context.Context) (bool, Report) {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(p.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range p.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			res := p.run(ctx, nc.fn)
+			mu.Lock()
+			report.Checks[nc.name] = res
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	if p.Draining() {
+		report.Status = StatusDraining
+	}
+	return report.Status == StatusOK, report
+}
+
+func (p *Probes) run(ctx context.Context, fn CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, p.checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	status, details, err := fn(ctx)
+	res := CheckResult{
+		Status:    status,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) (string, map[string]any, error) { return StatusOK, nil, nil }
+
+func TestReadyAggregatesChecks(t *testing.T) {
+	p := New()
+	p.AddCheck("a", ok)
+	p.AddCheck("b", func(context.Context) (string, map[string]any, error) {
+		return StatusWarn, map[string]any{"saturation": 0.95}, nil
+	})
+
+	ready, report := p.Ready(context.Background())
+	if !ready || report.Status != StatusOK {
+		t.Fatalf("ready = %v, status = %q; a warning must not fail readiness", ready, report.Status)
+	}
+	if report.Checks["b"].Status != StatusWarn {
+		t.Errorf("check b = %+v", report.Checks["b"])
+	}
+
+	p.AddCheck("c", func(context.Context) (string, map[string]any, error) {
+		return StatusOK, nil, errors.New("down")
+	})
+	ready, report = p.Ready(context.Background())
+	if ready || report.Status != StatusFail {
+		t.Fatalf("ready = %v, status = %q, want fail", ready, report.Status)
+	}
+	if got := report.Checks["c"]; got.Status != StatusFail || got.Error != "down" {
+		t.Errorf("check c = %+v", got)
+	}
+}
+
+func TestReadyTimesOutSlowChecks(t *testing.T) {
+	p := New()
+	p.checkTimeout = 10 * time.Millisecond
+	p.AddCheck("slow", func(ctx context.Context) (string, map[string]any, error) {
+		<-ctx.Done()
+		return StatusFail, nil, ctx.Err()
+	})
+
+	if ready, _ := p.Ready(context.Background()); ready {
+		t.Fatal("slow check should fail readiness")
+	}
+}
+
+func TestDrainingIsNotReady(t *testing.T) {
+	p := New()
+	p.AddCheck("a", ok)
+	p.StartDraining()
+
+	ready, report := p.Ready(context.Background())
+	if ready || report.Status != StatusDraining {
+		t.Fatalf("ready = %v, status = %q, want draining", ready, report.Status)
+	}
+}
+
+func TestAliveDetectsStalledHeartbeat(t *testing.T) {
+	p := New()
+	if alive, _ := p.Alive(); !alive {
+		t.Fatal("fresh probes should be alive")
+	}
+
+	p.heartbeat.Store(time.Now().Add(-time.Minute).UnixNano())
+	if alive, age := p.Alive(); alive {
+		t.Fatalf("heartbeat %s old should not be alive", age)
+	}
+}
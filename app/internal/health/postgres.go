@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// saturationWarn is the share of acquired connections above which the pool check warns.
+const saturationWarn = 0.9
+
+// PostgresPing fails when a connection cannot be acquired and pinged in time.
+func PostgresPing(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) (string, map[string]any, error) {
+		if err := pool.Ping(ctx); err != nil {
+			return StatusFail, nil, err
+		}
+		return StatusOK, nil, nil
+	}
+}
+
+// PoolSaturation reports pgxpool usage. A saturated pool only warns: failing
+// readiness under load would shift traffic to replicas that are just as busy.
+func PoolSaturation(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) (string, map[string]any, error) {
+		st := pool.Stat()
+		saturation := 0.0
+		if st.MaxConns() > 0 {
+			saturation = float64(st.AcquiredConns()) / float64(st.MaxConns())
+		}
+		details := map[string]any{
+			"acquired":            st.AcquiredConns(),
+			"idle":                st.IdleConns(),
+			"total":               st.TotalConns(),
+			"max":                 st.MaxConns(),
+			"saturation":          saturation,
+			"empty_acquire_count": st.EmptyAcquireCount(),
+		}
+		if saturation >= saturationWarn {
+			return StatusWarn, details, nil
+		}
+		return StatusOK, details, nil
+	}
+}
+
+// Migrations fails while the schema is dirty or older than the migrations
+// embedded in this binary (e.g. another replica is still migrating).
+func Migrations(pool *pgxpool.Pool, want uint) CheckFunc {
+	return func(ctx context.Context) (string, map[string]any, error) {
+		var version int64
+		var dirty bool
+		err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return StatusFail, nil, fmt.Errorf("no migration applied")
+			}
+			return StatusFail, nil, err
+		}
+		details := map[string]any{"version": version, "want": want, "dirty": dirty}
+		if dirty {
+			return StatusFail, details, fmt.Errorf("schema is dirty at version %d", version)
+		}
+		if version < int64(want) {
+			return StatusFail, details, fmt.Errorf("schema at version %d, want %d", version, want)
+		}
+		return StatusOK, details, nil
+	}
+}
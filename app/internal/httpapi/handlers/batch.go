@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+const (
+	MaxBatchOperations = 500
+	// A batch is one transaction: it gets a larger budget than single-task calls
+	batchTimeout = 5 * time.Second
+)
+
+type BatchResponse struct {
+	Mode    string            `json:"mode"`
+	Applied bool              `json:"applied"` // false when an atomic batch was rolled back
+	Results []BatchItemResult `json:"results"`
+}
+
+// BatchItemResult reports one operation, in request order.
+type BatchItemResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	Status int              `json:"status"`
+	Task   *model.Task      `json:"task,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+// Batch applies a list of create/update/delete operations with the rules of
+// the single-task endpoints. In atomic mode (the default) nothing is applied
+// unless every operation succeeds, and the response carries the status of the
+// failing operation; in best_effort mode the response is 200 and each result
+// has its own status.
+func (h *TasksHandler) Batch(c *gin.Context) {
+	var req model.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = model.BatchModeAtomic
+	case model.BatchModeAtomic, model.BatchModeBestEffort:
+	default:
+		writeError(c, &service.ValidationError{Field: "mode", Message: "invalid mode (atomic or best_effort required)"})
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > MaxBatchOperations {
+		writeError(c, &service.ValidationError{Field: "operations", Message: fmt.Sprintf("operations must hold between 1 and %d items", MaxBatchOperations)})
+		return
+	}
+	// The route takes tasks:write; deleting through it takes tasks:delete too
+	for _, raw := range req.Operations {
+		if raw.Op == model.BatchOpDelete && !middleware.HasScope(c, middleware.ScopeTasksDelete) {
+			middleware.AbortMissingScope(c, middleware.ScopeTasksDelete)
+			return
+		}
+	}
+	atomic := req.Mode == model.BatchModeAtomic
+
+	ctx, cancel := contextWithTimeout(c, batchTimeout)
+	defer cancel()
+
+	// Invalid or forbidden operations never reach the store
+	var owner string
+	ops := make([]store.BatchOp, 0, len(req.Operations))
+	index := make([]int, 0, len(req.Operations)) // ops[i] is req.Operations[index[i]]
+	results := make([]store.BatchResult, len(req.Operations))
+	for i, raw := range req.Operations {
+		op, err := h.parseBatchOp(raw)
+		if err == nil {
+			err = h.authorizeBatchOp(ctx, c, &owner, &op)
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		ops = append(ops, op)
+		index = append(index, i)
+	}
+
+	failed := atomic && hasError(results)
+	if !failed && len(ops) > 0 {
+		applied, err := h.repo.Batch(ctx, owner, ops, atomic)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		for i, r := range applied {
+			results[index[i]] = r
+		}
+		failed = atomic && hasError(results)
+	}
+	if failed {
+		store.MarkRolledBack(results)
+	}
+
+	resp := BatchResponse{Mode: req.Mode, Applied: !failed, Results: make([]BatchItemResult, len(results))}
+	status := http.StatusOK
+	for i, r := range results {
+		item := BatchItemResult{Index: i, Op: req.Operations[i].Op, Status: http.StatusOK}
+		switch {
+		case r.Err != nil:
+			var p problem.Problem
+			item.Status, p = batchProblem(c, r.Err)
+			item.Error = &p
+			if failed && !errors.Is(r.Err, store.ErrRolledBack) {
+				status = item.Status
+			}
+		case item.Op == model.BatchOpCreate:
+			item.Status = http.StatusCreated
+			item.Task = &r.Task
+		case item.Op == model.BatchOpUpdate:
+			item.Task = &r.Task
+		}
+		resp.Results[i] = item
+	}
+	c.JSON(status, resp)
+}
+
+// parseBatchOp validates an operation exactly like the matching single-task request.
+func (h *TasksHandler) parseBatchOp(raw model.BatchOperation) (store.BatchOp, error) {
+	op := store.BatchOp{Kind: raw.Op, ID: raw.ID}
+
+	switch raw.Op {
+	case model.BatchOpCreate:
+		req := model.CreateTaskRequest{
+			Title:            deref(raw.Title),
+			Content:          deref(raw.Content),
+			DueDate:          deref(raw.DueDate),
+			RequestTimestamp: raw.RequestTimestamp,
+			ParentID:         deref(raw.ParentID),
+			ProjectID:        deref(raw.ProjectID),
+			Status:           deref(raw.Status),
+			Priority:         deref(raw.Priority),
+			Recurrence:       deref(raw.Recurrence),
+		}
+		if raw.Tags != nil {
+			req.Tags = *raw.Tags
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			return op, err
+		}
+		in, err := newTask(req)
+		op.New = in
+		return op, err
+
+	case model.BatchOpUpdate, model.BatchOpDelete:
+		if _, err := uuid.Parse(raw.ID); err != nil {
+			return op, &service.ValidationError{Field: "id", Message: "id must be a task id"}
+		}
+		cond, err := batchPrecondition(raw)
+		if err != nil {
+			return op, err
+		}
+		cond.Transition = h.opts.Workflow.Allows
+		op.Cond = cond
+		if raw.Op == model.BatchOpDelete {
+			op.Children, err = h.childrenMode(raw.Children)
+			return op, err
+		}
+
+		op.Patch = model.UpdateTaskRequest{Title: raw.Title, Content: raw.Content, DueDate: raw.DueDate, Done: raw.Done, Status: raw.Status, Priority: raw.Priority, Recurrence: raw.Recurrence, ParentID: raw.ParentID, ProjectID: raw.ProjectID, Tags: raw.Tags, RequestTimestamp: raw.RequestTimestamp}
+		if err := checkPatch(&op.Patch); err != nil {
+			return op, err
+		}
+		if raw.DueDate != nil {
+			d, err := service.ParseDateYYYYMMDD(*raw.DueDate)
+			if err != nil {
+				return op, err
+			}
+			op.PatchDueDate = &d
+		}
+		return op, nil
+	}
+	return op, &service.ValidationError{Field: "op", Message: "invalid op (create, update or delete required)"}
+}
+
+// authorizeBatchOp applies the policy of the matching single-task request.
+// A batch acts as a single owner, the one of its first authorized operation:
+// operations on the tasks of someone else fail.
+func (h *TasksHandler) authorizeBatchOp(ctx context.Context, c *gin.Context, owner *string, op *store.BatchOp) error {
+	var a model.Access
+	var err error
+	if op.Kind == model.BatchOpCreate {
+		a, err = h.policy.create(ctx, c, op.New)
+	} else if a, err = h.policy.task(ctx, c, op.ID, model.RoleEditor); err == nil && op.Kind == model.BatchOpUpdate {
+		err = h.policy.patch(ctx, c, a, op.Patch)
+	}
+	if err != nil {
+		return err
+	}
+	if op.Kind == model.BatchOpDelete {
+		h.policy.deletion(a, &op.Cond)
+	}
+	if *owner == "" {
+		*owner = a.OwnerID
+	} else if a.OwnerID != *owner {
+		return &service.ValidationError{Field: "operations", Message: "a batch only changes the tasks of one owner"}
+	}
+	return nil
+}
+
+// batchPrecondition is precondition with the version field in place of If-Match.
+func batchPrecondition(raw model.BatchOperation) (store.Precondition, error) {
+	cond := store.Precondition{Version: raw.Version}
+	if raw.RequestTimestamp != "" {
+		ts, err := service.ParseRFC3339(raw.RequestTimestamp)
+		if err != nil {
+			return cond, err
+		}
+		cond.RequestTimestamp = &ts
+	} else if raw.Version == nil {
+		return cond, &service.ValidationError{Field: "request_timestamp", Message: "request_timestamp is required unless version is sent"}
+	}
+	return cond, nil
+}
+
+func batchProblem(c *gin.Context, err error) (int, problem.Problem) {
+	status, code, field, detail := describeError(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c).Error("batch operation failed", "error", err)
+	}
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		field, detail = "version", "version does not match the current task version"
+	}
+	return status, problem.New(c, status, code, field, detail)
+}
+
+func hasError(results []store.BatchResult) bool {
+	for _, r := range results {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+func init() {
+	// Report binding failures with the JSON field name rather than the Go one
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// writeError maps any error returned while serving a request to a problem body.
+func writeError(c *gin.Context, err error) {
+	status, code, field, detail := describeError(err)
+	if status == http.StatusInternalServerError {
+		requestLogger(c).Error("request failed", "error", err)
+	}
+	problem.Abort(c, status, code, field, detail)
+}
+
+// describeError returns the problem status, code, field and detail of err.
+func describeError(err error) (status int, code, field, detail string) {
+	var (
+		verr      *service.ValidationError
+		fieldErrs validator.ValidationErrors
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		transErr  *store.TransitionError
+		forbidden *service.ForbiddenError
+	)
+
+	switch {
+	case errors.As(err, &verr):
+		return http.StatusBadRequest, problem.CodeValidationFailed, verr.Field, verr.Message
+	case errors.As(err, &fieldErrs):
+		fe := fieldErrs[0]
+		detail := fe.Field() + " is invalid"
+		if fe.Tag() == "required" {
+			detail = fe.Field() + " is required"
+		}
+		return http.StatusBadRequest, problem.CodeValidationFailed, fe.Field(), detail
+	case errors.As(err, &typeErr):
+		return http.StatusBadRequest, problem.CodeInvalidRequest, typeErr.Field, typeErr.Field + " must be a " + typeErr.Type.String()
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest, problem.CodeInvalidRequest, "", "request body must be a JSON object"
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound, problem.CodeNotFound, "", "task not found"
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict, problem.CodeConflict, "request_timestamp", "request_timestamp is not newer than the task's last_request_timestamp"
+	case errors.Is(err, store.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, problem.CodePreconditionFailed, "If-Match", "If-Match does not match the current task version"
+	case errors.Is(err, store.ErrInvalidParent):
+		return http.StatusBadRequest, problem.CodeValidationFailed, "parent_id", "parent_id must be one of your live tasks"
+	case errors.Is(err, store.ErrParentCycle):
+		return http.StatusConflict, problem.CodeParentCycle, "parent_id", "a task cannot be moved under itself or one of its subtasks"
+	case errors.Is(err, store.ErrHasChildren):
+		return http.StatusConflict, problem.CodeHasChildren, "children", "the task has subtasks, delete them first or use children=orphan|cascade"
+	case errors.Is(err, store.ErrInvalidProject):
+		return http.StatusBadRequest, problem.CodeValidationFailed, "project_id", "project_id must be one of your projects"
+	case errors.Is(err, store.ErrProjectNotEmpty):
+		return http.StatusConflict, problem.CodeProjectNotEmpty, "", "the project has tasks, move or delete them first"
+	case errors.Is(err, store.ErrAlreadyMember):
+		return http.StatusConflict, problem.CodeAlreadyMember, "invitee", "the invitee already has a role in the project"
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden, problem.CodeForbidden, "", forbidden.Error()
+	case errors.As(err, &transErr):
+		return http.StatusConflict, problem.CodeInvalidTransition, "status", "status cannot change from " + transErr.From + " to " + transErr.To
+	case errors.Is(err, store.ErrRolledBack):
+		return http.StatusFailedDependency, problem.CodeRolledBack, "", "not applied because another operation of the batch failed"
+	case isOverload(err):
+		return http.StatusTooManyRequests, problem.CodeOverloaded, "", "request timed out, retry later"
+	default:
+		return http.StatusInternalServerError, problem.CodeInternal, "", "internal error"
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"team5/task-manager/internal/httpapi/problem"
+)
+
+func TestErrorsAreProblemDocuments(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "t")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		code   string
+		field  string
+	}{
+		{
+			name: "missing title", method: http.MethodPost, path: "/tasks",
+			body:   map[string]string{"content": "c", "due_date": "2025-01-01", "request_timestamp": "2025-01-01T00:00:00Z"},
+			status: http.StatusBadRequest, code: problem.CodeValidationFailed, field: "title",
+		},
+		{
+			name: "invalid due_date", method: http.MethodPost, path: "/tasks",
+			body:   map[string]string{"title": "t", "content": "c", "due_date": "tomorrow", "request_timestamp": "2025-01-01T00:00:00Z"},
+			status: http.StatusBadRequest, code: problem.CodeValidationFailed, field: "due_date",
+		},
+		{
+			name: "invalid request_timestamp", method: http.MethodPut, path: "/tasks/" + task.ID,
+			body:   map[string]string{"request_timestamp": "yesterday"},
+			status: http.StatusBadRequest, code: problem.CodeValidationFailed, field: "request_timestamp",
+		},
+		{
+			name: "wrong type", method: http.MethodPut, path: "/tasks/" + task.ID,
+			body:   map[string]any{"done": "yes", "request_timestamp": "2025-01-02T00:00:00Z"},
+			status: http.StatusBadRequest, code: problem.CodeInvalidRequest, field: "done",
+		},
+		{
+			name: "stale request_timestamp", method: http.MethodPut, path: "/tasks/" + task.ID,
+			body:   map[string]any{"done": true, "request_timestamp": "2025-01-01T00:00:00Z"},
+			status: http.StatusConflict, code: problem.CodeConflict, field: "request_timestamp",
+		},
+		{
+			name: "unknown task", method: http.MethodGet, path: "/tasks/00000000-0000-0000-0000-000000000000",
+			status: http.StatusNotFound, code: problem.CodeNotFound,
+		},
+		{
+			name: "bad list param", method: http.MethodGet, path: "/tasks?order=sideways",
+			status: http.StatusBadRequest, code: problem.CodeValidationFailed, field: "order",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(t, r, tt.method, tt.path, "alice", tt.body)
+			assertProblem(t, w, tt.status, tt.code, tt.field)
+		})
+	}
+}
+
+func TestMalformedBodyIsProblem(t *testing.T) {
+	r := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader("{not json"))
+	req.Header.Set("Authorization", "Bearer "+token(t, "alice"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertProblem(t, w, http.StatusBadRequest, problem.CodeInvalidRequest, "")
+}
+
+func TestUnauthorizedIsProblem(t *testing.T) {
+	r := newTestRouter(t)
+	assertProblem(t, do(t, r, http.MethodGet, "/tasks", "", nil), http.StatusUnauthorized, problem.CodeUnauthorized, "")
+}
+
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code, field string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d (body %s)", w.Code, status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+	}
+	p := decode[problem.Problem](t, w)
+	if p.Status != status || p.Code != code || p.Field != field || p.Detail == "" {
+		t.Errorf("problem = %+v, want status %d, code %q, field %q", p, status, code, field)
+	}
+}
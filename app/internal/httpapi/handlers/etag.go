@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// etag is the strong entity tag of a task: its quoted version.
+func etag(t model.Task) string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+// ifNoneMatch reports whether an If-None-Match header matches tag (weak comparison).
+func ifNoneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// precondition builds the store guard of PUT and DELETE from If-Match and the
+// legacy request_timestamp. At least one of them is required; when both are
+// sent both must hold.
+func precondition(c *gin.Context, requestTimestamp string) (store.Precondition, error) {
+	var cond store.Precondition
+
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch != "" && ifMatch != "*" {
+		// If-Match uses strong comparison: weak or malformed tags never match
+		v, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+		if err != nil || !strings.HasPrefix(ifMatch, `"`) {
+			return cond, store.ErrPreconditionFailed
+		}
+		cond.Version = &v
+	}
+
+	if requestTimestamp != "" {
+		ts, err := service.ParseRFC3339(requestTimestamp)
+		if err != nil {
+			return cond, err
+		}
+		cond.RequestTimestamp = &ts
+	} else if ifMatch == "" {
+		return cond, &service.ValidationError{Field: "request_timestamp", Message: "request_timestamp is required unless If-Match is sent"}
+	}
+	return cond, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+)
+
+func doWithHeader(t *testing.T, r http.Handler, method, path string, body any, header, value string) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Authorization", "Bearer "+token(t, "alice"))
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestETagConditionalGet(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "t")
+
+	w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "alice", nil)
+	tag := w.Header().Get("ETag")
+	if tag != `"1"` {
+		t.Fatalf("ETag = %q, want \"1\"", tag)
+	}
+
+	if w := doWithHeader(t, r, http.MethodGet, "/tasks/"+task.ID, nil, "If-None-Match", tag); w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match current: status %d, want 304", w.Code)
+	}
+	if w := doWithHeader(t, r, http.MethodGet, "/tasks/"+task.ID, nil, "If-None-Match", `W/"1"`); w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match weak: status %d, want 304", w.Code)
+	}
+	if w := doWithHeader(t, r, http.MethodGet, "/tasks/"+task.ID, nil, "If-None-Match", `"0"`); w.Code != http.StatusOK {
+		t.Fatalf("If-None-Match stale: status %d, want 200", w.Code)
+	}
+}
+
+func TestIfMatchUpdateAndDelete(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "t")
+
+	// No request_timestamp needed with If-Match
+	w := doWithHeader(t, r, http.MethodPut, "/tasks/"+task.ID, map[string]any{"done": true}, "If-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: status %d, body %s", w.Code, w.Body)
+	}
+	if got := decode[model.Task](t, w); got.Version != 2 || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("update: version %d, ETag %q", got.Version, w.Header().Get("ETag"))
+	}
+
+	w = doWithHeader(t, r, http.MethodPut, "/tasks/"+task.ID, map[string]any{"done": false}, "If-Match", `"1"`)
+	assertProblem(t, w, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "If-Match")
+
+	w = doWithHeader(t, r, http.MethodDelete, "/tasks/"+task.ID, nil, "If-Match", `W/"2"`)
+	assertProblem(t, w, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "If-Match")
+
+	if w := doWithHeader(t, r, http.MethodDelete, "/tasks/"+task.ID, nil, "If-Match", `"2"`); w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestPreconditionRequired(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "t")
+
+	w := do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"done": true})
+	assertProblem(t, w, http.StatusBadRequest, problem.CodeValidationFailed, "request_timestamp")
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// replayBatch is how many change log entries a resuming stream reads at a time.
+const replayBatch = 500
+
+// EventsHandler streams the changes to the caller's tasks as Server-Sent
+// Events.
+type EventsHandler struct {
+	log    store.ChangeLog
+	feed   store.ChangeFeed
+	policy policy
+	opts   EventsOptions
+}
+
+type EventsOptions struct {
+	// Heartbeat is the interval of the comments keeping idle streams open
+	// through proxies (15s if zero)
+	Heartbeat time.Duration
+}
+
+// NewEventsHandler streams the changes of log announced by feed; members
+// grants access to the tasks of shared projects.
+func NewEventsHandler(log store.ChangeLog, feed store.ChangeFeed, members store.MemberRepository, opts EventsOptions) *EventsHandler {
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+	return &EventsHandler{log: log, feed: feed, policy: policy{members: members}, opts: opts}
+}
+
+// Stream serves GET /tasks/events, the changes to the caller's own tasks and
+// to those of the projects shared with them when the stream opened. Each
+// event is a task history entry: its id is the
+// entry ID, its name the webhook event type (task.created, task.updated,
+// task.deleted). A client reconnecting with Last-Event-ID (or
+// ?last_event_id=) first gets the entries it missed from the change log. IDs
+// are not in commit order, so those start with the entries below
+// Last-Event-ID that may have committed after it: clients drop the ones they
+// already have by id. The server ends the stream when it cannot keep up;
+// clients just reconnect.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	lastID, resume, err := lastEventID(c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	owner := middleware.Subject(c)
+	access, err := h.policy.shared(ctx, c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	shared := projectIDs(access)
+
+	// Subscribe before reading the log so nothing falls in between; the
+	// owners of the shared projects announce their other tasks too, which
+	// the log leaves out
+	owners := []string{owner}
+	for _, a := range access {
+		if !slices.Contains(owners, a.OwnerID) {
+			owners = append(owners, a.OwnerID)
+		}
+	}
+	ids, cancel := h.feed.Subscribe(store.TenantFrom(ctx), owners...)
+	defer cancel()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	replayed := replay{last: lastID}
+	if resume {
+		entries, err := h.log.Overlapping(ctx, owner, shared, lastID)
+		if err != nil {
+			streamFailed(c, err)
+			return
+		}
+		replayed.add(entries)
+		if !send(c, entries) {
+			return
+		}
+		for {
+			entries, err := h.log.Changes(ctx, owner, shared, lastID, replayBatch)
+			if err != nil {
+				streamFailed(c, err)
+				return
+			}
+			replayed.add(entries)
+			if !send(c, entries) {
+				return
+			}
+			if len(entries) < replayBatch {
+				break
+			}
+			lastID = entries[len(entries)-1].ID
+		}
+	}
+
+	heartbeat := time.NewTicker(h.opts.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case id, ok := <-ids:
+			if !ok {
+				return
+			}
+			batch := drain(ids, id, &replayed)
+			if len(batch) == 0 {
+				continue
+			}
+			entries, err := h.log.ChangesByID(ctx, owner, shared, batch)
+			if err != nil {
+				streamFailed(c, err)
+				return
+			}
+			if !send(c, entries) {
+				return
+			}
+		}
+	}
+}
+
+// send writes the entries and flushes; false when the client is gone.
+func send(c *gin.Context, entries []model.TaskHistoryEntry) bool {
+	for _, e := range entries {
+		event := sse.Event{Id: strconv.FormatInt(e.ID, 10), Event: store.EventType(e.Action), Data: e}
+		if err := sse.Encode(c.Writer, event); err != nil {
+			return false
+		}
+	}
+	c.Writer.Flush()
+	return c.Request.Context().Err() == nil
+}
+
+// streamFailed logs the error ending a response whose headers are already sent.
+func streamFailed(c *gin.Context, err error) {
+	if c.Request.Context().Err() == nil {
+		requestLogger(c).Error("response stream failed", "error", err)
+	}
+}
+
+// replay remembers the entries replayed from the change log, which the feed
+// may announce as well. They committed before the log was read, so the feed
+// announces them before any entry above the last one read: the first of
+// those ends the need to remember them.
+type replay struct {
+	seen map[int64]bool
+	last int64 // the highest ID replayed, or the resume point
+}
+
+func (r *replay) add(entries []model.TaskHistoryEntry) {
+	if r.seen == nil && len(entries) > 0 {
+		r.seen = make(map[int64]bool)
+	}
+	for _, e := range entries {
+		r.seen[e.ID] = true
+		r.last = max(r.last, e.ID)
+	}
+}
+
+// fresh reports whether an announced ID was not replayed. Each ID is
+// announced once, so a replayed one is forgotten once seen.
+func (r *replay) fresh(id int64) bool {
+	if id > r.last {
+		r.seen = nil
+		return true
+	}
+	if r.seen[id] {
+		delete(r.seen, id)
+		return false
+	}
+	return true
+}
+
+// drain returns first and the IDs already queued behind it, but the
+// replayed ones.
+func drain(ids <-chan int64, first int64, replayed *replay) []int64 {
+	var batch []int64
+	for id, ok := first, true; ok; {
+		if replayed.fresh(id) {
+			batch = append(batch, id)
+		}
+		select {
+		case id, ok = <-ids:
+		default:
+			ok = false
+		}
+	}
+	return batch
+}
+
+// lastEventID reads the entry to resume after; resume is false for a fresh stream.
+func lastEventID(c *gin.Context) (id int64, resume bool, err error) {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	if v == "" {
+		return 0, false, nil
+	}
+	id, err = strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, &service.ValidationError{Field: "Last-Event-ID", Message: "Last-Event-ID must be an event id"}
+	}
+	return id, true, nil
+}
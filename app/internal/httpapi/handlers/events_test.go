@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/store/memory"
+)
+
+type sseEvent struct {
+	id, name string
+	entry    model.TaskHistoryEntry
+}
+
+// openStream connects to GET /tasks/events; the handler has subscribed by
+// the time it returns.
+func openStream(t *testing.T, srv *httptest.Server, sub, lastEventID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tasks/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token(t, sub))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("status %d, content type %q", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(res.Body)
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && ev.id != "":
+			return ev
+		case strings.HasPrefix(line, "id:"):
+			ev.id = strings.TrimPrefix(line, "id:")
+		case strings.HasPrefix(line, "event:"):
+			ev.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &ev.entry); err != nil {
+				t.Fatalf("data %q: %v", line, err)
+			}
+		}
+	}
+}
+
+func TestEventsStreamLive(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(t))
+	t.Cleanup(srv.Close) // after the streams are closed
+	stream := openStream(t, srv, "alice", "")
+
+	createTask(t, srv.Config.Handler, "bob", "not mine")
+	task := createTask(t, srv.Config.Handler, "alice", "mine")
+	w := do(t, srv.Config.Handler, http.MethodDelete, "/tasks/"+task.ID, "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+
+	ev := readEvent(t, stream)
+	if ev.name != model.EventTaskCreated || ev.entry.TaskID != task.ID || ev.id != strconv.FormatInt(ev.entry.ID, 10) {
+		t.Fatalf("first event %+v", ev)
+	}
+	if ev := readEvent(t, stream); ev.name != model.EventTaskDeleted || ev.entry.TaskID != task.ID {
+		t.Fatalf("second event %+v", ev)
+	}
+}
+
+func TestEventsStreamSharedProjects(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(t))
+	t.Cleanup(srv.Close) // after the streams are closed
+	r := srv.Config.Handler
+	project := decode[model.Project](t, do(t, r, http.MethodPost, "/projects", "alice", map[string]any{"name": "shared"}))
+	inv := decode[model.Invitation](t, do(t, r, http.MethodPost, "/projects/"+project.ID+"/invitations", "alice", map[string]any{"invitee": "bob", "role": "viewer"}))
+	if w := do(t, r, http.MethodPost, "/invitations/"+inv.ID+"/accept", "bob", nil); w.Code != http.StatusOK {
+		t.Fatalf("accept: status %d, body %s", w.Code, w.Body)
+	}
+	before := createTaskIn(t, r, "alice", project.ID, "", "before")
+
+	// A resumed stream replays the shared tasks, then follows them live
+	stream := openStream(t, srv, "bob", "0")
+	if ev := readEvent(t, stream); ev.entry.TaskID != before.ID {
+		t.Fatalf("replayed %+v, want %s", ev, before.ID)
+	}
+	createTask(t, r, "alice", "private")
+	inside := createTaskIn(t, r, "alice", project.ID, "", "inside")
+	if ev := readEvent(t, stream); ev.entry.TaskID != inside.ID {
+		t.Fatalf("live %+v, want %s and not alice's private task", ev, inside.ID)
+	}
+	mine := createTask(t, r, "bob", "mine")
+	if ev := readEvent(t, stream); ev.entry.TaskID != mine.ID {
+		t.Fatalf("live %+v, want %s", ev, mine.ID)
+	}
+}
+
+func TestEventsStreamResume(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(t))
+	t.Cleanup(srv.Close) // after the streams are closed
+	first := createTask(t, srv.Config.Handler, "alice", "first")
+	createTask(t, srv.Config.Handler, "bob", "not mine")
+	second := createTask(t, srv.Config.Handler, "alice", "second")
+
+	stream := openStream(t, srv, "alice", "0")
+	ev := readEvent(t, stream)
+	if ev.entry.TaskID != first.ID {
+		t.Fatalf("replayed %+v, want %s first", ev, first.ID)
+	}
+	if ev := readEvent(t, stream); ev.entry.TaskID != second.ID {
+		t.Fatalf("replayed %+v, want %s second", ev, second.ID)
+	}
+
+	// Resuming after the first entry replays the second only, then goes live
+	stream = openStream(t, srv, "alice", ev.id)
+	if ev := readEvent(t, stream); ev.entry.TaskID != second.ID {
+		t.Fatalf("resumed with %+v, want %s", ev, second.ID)
+	}
+	third := createTask(t, srv.Config.Handler, "alice", "third")
+	if ev := readEvent(t, stream); ev.entry.TaskID != third.ID || ev.name != model.EventTaskCreated {
+		t.Fatalf("live after replay %+v, want %s", ev, third.ID)
+	}
+}
+
+// lateLog is a change log where entry late committed after entry after.
+type lateLog struct {
+	*memory.TasksStore
+	late, after int64
+}
+
+func (l lateLog) Overlapping(ctx context.Context, ownerID string, shared []string, id int64) ([]model.TaskHistoryEntry, error) {
+	if id != l.after {
+		return nil, nil
+	}
+	return l.ChangesByID(ctx, ownerID, shared, []int64{l.late})
+}
+
+func TestEventsStreamResumeReplaysLateCommits(t *testing.T) {
+	repo := memory.NewTasksStore()
+	ctx := context.Background()
+	for _, title := range []string{"late", "early"} {
+		if _, err := repo.Create(ctx, "alice", store.NewTask{Title: title, Content: "c", RequestTimestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := repo.Changes(ctx, "alice", nil, 0, 10)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Changes = %+v, %v", entries, err)
+	}
+	log := lateLog{TasksStore: repo, late: entries[0].ID, after: entries[1].ID}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.AuthJWT(testSecret, middleware.AuthOptions{}))
+	r.GET("/tasks/events", NewEventsHandler(log, repo, memory.NewProjectStore(repo), EventsOptions{}).Stream)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	stream := openStream(t, srv, "alice", strconv.FormatInt(entries[1].ID, 10))
+	if ev := readEvent(t, stream); ev.entry.ID != entries[0].ID {
+		t.Fatalf("resumed with %+v, want the late entry %d", ev, entries[0].ID)
+	}
+	next, err := repo.Create(ctx, "alice", store.NewTask{Title: "next", Content: "c", RequestTimestamp: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := readEvent(t, stream); ev.entry.TaskID != next.ID {
+		t.Fatalf("live after replay %+v, want %s", ev, next.ID)
+	}
+}
+
+func TestEventsStreamBadLastEventID(t *testing.T) {
+	r := newTestRouter(t)
+	for _, path := range []string{"/tasks/events?last_event_id=abc", "/tasks/events?last_event_id=-1"} {
+		if w := do(t, r, http.MethodGet, path, "alice", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestReplayForgetsPastTheLastReplayed(t *testing.T) {
+	r := replay{last: 4}
+	r.add([]model.TaskHistoryEntry{{ID: 3}, {ID: 5}, {ID: 7}})
+
+	ids := make(chan int64, 4)
+	for _, id := range []int64{5, 6, 8, 3} {
+		ids <- id
+	}
+	first := <-ids
+	if got := drain(ids, first, &r); fmt.Sprint(got) != "[6 8 3]" {
+		t.Errorf("drain = %v, want 6, 8 and 3 (announced after 8)", got)
+	}
+	if r.seen != nil {
+		t.Errorf("still remembers %v after passing %d", r.seen, r.last)
+	}
+}
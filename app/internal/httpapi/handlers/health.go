@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/health"
+)
+
+type HealthHandler struct {
+	probes *health.Probes
+}
+
+func NewHealthHandler(probes *health.Probes) *HealthHandler {
+	return &HealthHandler{probes: probes}
+}
+
+// Liveness must stay cheap: no dependency is checked here, a database outage
+// should make the pod unready, not restart it.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	ok, age := h.probes.Alive()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusFail, "heartbeat_age_ms": age.Milliseconds()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ok, report := h.probes.Ready(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// Members lists who the project is shared with, its owner first. Every
+// member sees the list.
+func (h *ProjectsHandler) Members(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	members, err := h.members.ListMembers(ctx, a.OwnerID, id)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.MemberList{Items: members})
+}
+
+// UpdateMember changes the role of a member; admins only.
+func (h *ProjectsHandler) UpdateMember(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	var req model.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := service.CheckMemberRole("role", req.Role); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	user := c.Param("user")
+	if user == a.OwnerID {
+		writeError(c, &service.ValidationError{Field: "user", Message: "the owner's role cannot change"})
+		return
+	}
+	m, err := h.members.SetMemberRole(ctx, a.OwnerID, id, user, req.Role)
+	if err != nil {
+		writeMemberError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// RemoveMember takes a member out of the project: admins remove anyone,
+// members remove themselves to leave it.
+func (h *ProjectsHandler) RemoveMember(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	user := c.Param("user")
+	need := model.RoleAdmin
+	if user == middleware.Subject(c) {
+		need = model.RoleViewer
+	}
+	a, err := h.policy.project(ctx, c, id, need)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	if user == a.OwnerID {
+		writeError(c, &service.ValidationError{Field: "user", Message: "the owner cannot leave the project"})
+		return
+	}
+	if err := h.members.RemoveMember(ctx, a.OwnerID, id, user); err != nil {
+		writeMemberError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Invite offers a role in the project to a user; admins only. Inviting a
+// user again replaces their pending invitation.
+func (h *ProjectsHandler) Invite(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	var req model.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	invitee, err := service.NormalizeInvitee(req.Invitee)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := service.CheckMemberRole("role", req.Role); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	inv, err := h.members.CreateInvitation(ctx, a.OwnerID, id, model.Invitation{Invitee: invitee, Role: req.Role, InvitedBy: middleware.Subject(c)})
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+// Invitations lists the pending invitations of the project; admins only.
+func (h *ProjectsHandler) Invitations(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	items, err := h.members.ListInvitations(ctx, a.OwnerID, id)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.InvitationList{Items: items})
+}
+
+// RevokeInvitation withdraws a pending invitation; admins only.
+func (h *ProjectsHandler) RevokeInvitation(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	invitation, ok := invitationID(c, "invitation")
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	if err := h.members.DeleteInvitation(ctx, a.OwnerID, id, invitation); err != nil {
+		writeInvitationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MyInvitations lists the invitations sent to the caller, oldest first.
+func (h *ProjectsHandler) MyInvitations(c *gin.Context) {
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	items, err := h.members.PendingInvitations(ctx, middleware.Subject(c))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.InvitationList{Items: items})
+}
+
+// AcceptInvitation makes the caller a member with the invited role. Only
+// the invitee sees an invitation.
+func (h *ProjectsHandler) AcceptInvitation(c *gin.Context) {
+	id, ok := invitationID(c, "id")
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	m, err := h.members.AcceptInvitation(ctx, middleware.Subject(c), id)
+	if err != nil {
+		writeInvitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+func (h *ProjectsHandler) DeclineInvitation(c *gin.Context) {
+	id, ok := invitationID(c, "id")
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	if err := h.members.DeclineInvitation(ctx, middleware.Subject(c), id); err != nil {
+		writeInvitationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// invitationID reads an invitation id parameter; an id that cannot exist is a 404.
+func invitationID(c *gin.Context, param string) (string, bool) {
+	id := c.Param(param)
+	if _, err := uuid.Parse(id); err != nil {
+		writeInvitationError(c, store.ErrNotFound)
+		return "", false
+	}
+	return id, true
+}
+
+// writeMemberError is writeError with the 404 naming the member.
+func writeMemberError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeNotFound, "", "member not found")
+		return
+	}
+	writeError(c, err)
+}
+
+// writeInvitationError is writeError with the 404 naming the invitation.
+func writeInvitationError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeNotFound, "", "invitation not found")
+		return
+	}
+	writeError(c, err)
+}
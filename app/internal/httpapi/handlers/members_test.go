@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+)
+
+func TestProjectSharing(t *testing.T) {
+	r := newTestRouter(t)
+	project := decode[model.Project](t, do(t, r, http.MethodPost, "/projects", "alice", map[string]any{"name": "shared"}))
+	w := do(t, r, http.MethodPost, "/tasks", "alice", map[string]any{
+		"title": "inside", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": project.ID, "tags": []string{"shared"},
+	})
+	inside := decode[model.Task](t, w)
+	private := createTask(t, r, "alice", "private")
+
+	// Nothing is visible before sharing, not even its existence
+	for _, path := range []string{"/tasks/" + inside.ID, "/projects/" + project.ID, "/projects/" + project.ID + "/members"} {
+		if w := do(t, r, http.MethodGet, path, "bob", nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s before sharing: status %d, want 404", path, w.Code)
+		}
+	}
+
+	w = do(t, r, http.MethodPost, "/projects/"+project.ID+"/invitations", "alice", map[string]any{"invitee": "bob", "role": "viewer"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("invite: status %d, body %s", w.Code, w.Body)
+	}
+	inv := decode[model.Invitation](t, w)
+	if w := do(t, r, http.MethodPost, "/projects/"+project.ID+"/invitations", "alice", map[string]any{"invitee": "carol", "role": "owner"}); w.Code != http.StatusBadRequest {
+		t.Errorf("invite as owner: status %d, want 400", w.Code)
+	}
+	if list := decode[model.InvitationList](t, do(t, r, http.MethodGet, "/invitations", "bob", nil)); len(list.Items) != 1 || list.Items[0].ProjectName != "shared" {
+		t.Errorf("bob's invitations = %+v", list.Items)
+	}
+	if w := do(t, r, http.MethodPost, "/invitations/"+inv.ID+"/accept", "carol", nil); w.Code != http.StatusNotFound {
+		t.Errorf("accept by another user: status %d, want 404", w.Code)
+	}
+	if w := do(t, r, http.MethodPost, "/invitations/"+inv.ID+"/accept", "bob", nil); w.Code != http.StatusOK {
+		t.Fatalf("accept: status %d, body %s", w.Code, w.Body)
+	}
+
+	// Viewers read, and are told what they cannot change
+	if w := do(t, r, http.MethodGet, "/tasks/"+inside.ID, "bob", nil); w.Code != http.StatusOK {
+		t.Errorf("viewer get: status %d", w.Code)
+	}
+	w = do(t, r, http.MethodPut, "/tasks/"+inside.ID, "bob", map[string]any{"title": "mine", "request_timestamp": "2025-01-01T00:00:01Z"})
+	if p := decode[problem.Problem](t, w); w.Code != http.StatusForbidden || p.Code != problem.CodeForbidden {
+		t.Errorf("viewer update: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+private.ID, "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get a task outside the project: status %d, want 404", w.Code)
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/projects/"+project.ID+"/tasks", "bob", nil)); len(page.Items) != 1 {
+		t.Errorf("viewer project tasks = %+v", page.Items)
+	}
+	// The endpoints over all the caller's tasks cover the shared ones, not
+	// the owner's others
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "bob", nil)); len(page.Items) != 1 || page.Items[0].ID != inside.ID {
+		t.Errorf("GET /tasks = %+v, want the shared task", page.Items)
+	}
+	if page := decode[model.SearchPage](t, do(t, r, http.MethodGet, "/tasks/search?q=inside", "bob", nil)); len(page.Items) != 1 || page.Items[0].ID != inside.ID {
+		t.Errorf("search = %+v, want the shared task", page.Items)
+	}
+	if page := decode[model.SearchPage](t, do(t, r, http.MethodGet, "/tasks/search?q=private", "bob", nil)); len(page.Items) != 0 {
+		t.Errorf("search finds a task outside the project: %+v", page.Items)
+	}
+	if tags := decode[model.TagList](t, do(t, r, http.MethodGet, "/tags", "bob", nil)); len(tags.Items) != 1 || tags.Items[0].Name != "shared" {
+		t.Errorf("tags = %+v, want those of the shared task", tags.Items)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/export?format=ndjson", "bob", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), inside.ID) || strings.Contains(w.Body.String(), private.ID) {
+		t.Errorf("export: status %d, body %s", w.Code, w.Body)
+	}
+	if got := decode[model.Project](t, do(t, r, http.MethodGet, "/projects/"+project.ID, "bob", nil)); got.Role != model.RoleViewer {
+		t.Errorf("project role = %q, want viewer", got.Role)
+	}
+	if w := do(t, r, http.MethodGet, "/projects/"+project.ID+"/invitations", "bob", nil); w.Code != http.StatusForbidden {
+		t.Errorf("viewer lists invitations: status %d, want 403", w.Code)
+	}
+
+	// Editors change the tasks, as the project owner
+	w = do(t, r, http.MethodPut, "/projects/"+project.ID+"/members/bob", "alice", map[string]any{"role": "editor"})
+	if m := decode[model.Member](t, w); w.Code != http.StatusOK || m.Role != model.RoleEditor {
+		t.Fatalf("promote: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodPut, "/tasks/"+inside.ID, "bob", map[string]any{"title": "edited", "request_timestamp": "2025-01-01T00:00:01Z"}); w.Code != http.StatusOK {
+		t.Errorf("editor update: status %d, body %s", w.Code, w.Body)
+	}
+	w = do(t, r, http.MethodPut, "/tasks/"+inside.ID, "bob", map[string]any{"project_id": "", "request_timestamp": "2025-01-01T00:00:02Z"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("editor takes the task out of the project: status %d, want 403", w.Code)
+	}
+	w = do(t, r, http.MethodPost, "/tasks", "bob", map[string]any{
+		"title": "added", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": project.ID,
+	})
+	if task := decode[model.Task](t, w); w.Code != http.StatusCreated || task.OwnerID != "alice" {
+		t.Errorf("editor create: status %d, body %s", w.Code, w.Body)
+	}
+	w = do(t, r, http.MethodPost, "/tasks", "bob", map[string]any{
+		"title": "sub", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": project.ID, "parent_id": private.ID,
+	})
+	if w.Code != http.StatusBadRequest || decode[problem.Problem](t, w).Field != "parent_id" {
+		t.Errorf("editor create under a private task: status %d, body %s", w.Code, w.Body)
+	}
+	mine := createTask(t, r, "bob", "mine")
+	w = do(t, r, http.MethodPost, "/tasks:batch", "bob", map[string]any{"operations": []map[string]any{
+		{"op": "update", "id": mine.ID, "title": "x", "request_timestamp": "2025-01-02T00:00:00Z"},
+		{"op": "update", "id": inside.ID, "title": "y", "request_timestamp": "2025-01-02T00:00:00Z"},
+	}})
+	if resp := decode[BatchResponse](t, w); w.Code != http.StatusBadRequest || resp.Applied {
+		t.Errorf("batch over two owners: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodDelete, "/projects/"+project.ID, "bob", nil); w.Code != http.StatusForbidden {
+		t.Errorf("editor deletes the project: status %d, want 403", w.Code)
+	}
+
+	// An editor's cascade stops at the project
+	elsewhere := decode[model.Project](t, do(t, r, http.MethodPost, "/projects", "alice", map[string]any{"name": "elsewhere"}))
+	parent := createTaskIn(t, r, "alice", project.ID, "", "parent")
+	child := createTaskIn(t, r, "alice", project.ID, parent.ID, "child")
+	away := createTaskIn(t, r, "alice", elsewhere.ID, parent.ID, "away")
+	if w := do(t, r, http.MethodDelete, "/tasks/"+parent.ID+"?children=cascade", "bob", map[string]any{"request_timestamp": "2025-01-02T00:00:00Z"}); w.Code != http.StatusOK {
+		t.Fatalf("editor cascade: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+child.ID, "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("subtask in the project: status %d, want 404", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+away.ID, "alice", nil); w.Code != http.StatusOK {
+		t.Errorf("subtask in another project: status %d, want it left live", w.Code)
+	}
+	parent = createTaskIn(t, r, "alice", project.ID, "", "batch parent")
+	away = createTaskIn(t, r, "alice", elsewhere.ID, parent.ID, "batch away")
+	w = do(t, r, http.MethodPost, "/tasks:batch", "bob", map[string]any{"operations": []map[string]any{
+		{"op": "delete", "id": parent.ID, "children": "cascade", "request_timestamp": "2025-01-02T00:00:00Z"},
+	}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("editor batch cascade: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+away.ID, "alice", nil); w.Code != http.StatusOK {
+		t.Errorf("subtask in another project after a batch: status %d, want it left live", w.Code)
+	}
+
+	// Leaving the project hides it again
+	if list := decode[model.MemberList](t, do(t, r, http.MethodGet, "/projects/"+project.ID+"/members", "bob", nil)); len(list.Items) != 2 || list.Items[0].Role != model.RoleOwner {
+		t.Errorf("members = %+v", list.Items)
+	}
+	if w := do(t, r, http.MethodDelete, "/projects/"+project.ID+"/members/alice", "bob", nil); w.Code != http.StatusForbidden {
+		t.Errorf("editor removes the owner: status %d, want 403", w.Code)
+	}
+	if w := do(t, r, http.MethodDelete, "/projects/"+project.ID+"/members/bob", "bob", nil); w.Code != http.StatusNoContent {
+		t.Errorf("leave: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+inside.ID, "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get after leaving: status %d, want 404", w.Code)
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "bob", nil)); len(page.Items) != 1 || page.Items[0].ID != mine.ID {
+		t.Errorf("GET /tasks after leaving = %+v, want bob's own", page.Items)
+	}
+}
+
+// createTaskIn creates a task of sub in a project, under parentID unless "".
+func createTaskIn(t *testing.T, r http.Handler, sub, projectID, parentID, title string) model.Task {
+	t.Helper()
+	body := map[string]any{"title": title, "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": projectID}
+	if parentID != "" {
+		body["parent_id"] = parentID
+	}
+	w := do(t, r, http.MethodPost, "/tasks", sub, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create %s: status %d, body %s", title, w.Code, w.Body)
+	}
+	return decode[model.Task](t, w)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// policy decides whose data a request acts on and whether the caller may.
+// The stores only know owners: a member of a shared project acts on it and
+// its tasks as the project owner, within the rights of their role. What the
+// caller cannot see is a 404 whether it exists or not; what they can see but
+// not change is a 403.
+type policy struct {
+	members store.MemberRepository
+}
+
+// own is the access to the caller's own tasks.
+func (p policy) own(c *gin.Context) model.Access {
+	return model.Access{OwnerID: middleware.Subject(c), Role: model.RoleOwner}
+}
+
+// shared returns the access of the caller to the projects of other owners
+// they are a member of. The endpoints working on all the caller's tasks
+// (lists, search, tags, export and the event stream) cover the tasks of
+// these projects with their own: every role may read them.
+func (p policy) shared(ctx context.Context, c *gin.Context) ([]model.Access, error) {
+	return p.members.SharedProjects(ctx, middleware.Subject(c))
+}
+
+// deletion keeps what a member deletes within the project they are
+// authorized on: a cascade does not follow subtasks into other projects.
+func (p policy) deletion(a model.Access, cond *store.Precondition) {
+	if a.Role != model.RoleOwner {
+		cond.CascadeProject = &a.ProjectID
+	}
+}
+
+// projectIDs returns the projects of shared.
+func projectIDs(shared []model.Access) []string {
+	ids := make([]string, len(shared))
+	for i, a := range shared {
+		ids[i] = a.ProjectID
+	}
+	return ids
+}
+
+// task authorizes need on a task. A task not shared with the caller is
+// looked up among their own: the store answers 404 for someone else's, and
+// the history of the caller's purged tasks stays readable.
+func (p policy) task(ctx context.Context, c *gin.Context, id, need string) (model.Access, error) {
+	a, err := p.members.TaskAccess(ctx, middleware.Subject(c), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return p.own(c), nil
+	}
+	if err != nil {
+		return a, err
+	}
+	return a, service.Authorize(a.Role, need)
+}
+
+// project authorizes need on a project; ErrNotFound when the caller cannot see it.
+func (p policy) project(ctx context.Context, c *gin.Context, id, need string) (model.Access, error) {
+	a, err := p.members.ProjectAccess(ctx, middleware.Subject(c), id)
+	if err != nil {
+		return a, err
+	}
+	return a, service.Authorize(a.Role, need)
+}
+
+// create authorizes a new task: in a project, editors create it for the
+// project owner.
+func (p policy) create(ctx context.Context, c *gin.Context, in store.NewTask) (model.Access, error) {
+	a := p.own(c)
+	if in.ProjectID != "" {
+		var err error
+		if a, err = p.project(ctx, c, in.ProjectID, model.RoleEditor); err != nil {
+			return a, projectError(err)
+		}
+	}
+	return a, p.parent(ctx, c, a, in.ParentID)
+}
+
+// patch authorizes the project and parent changes of an update of a task
+// the caller accesses with a. Only the owner takes a task out of its
+// project; members may move it to another project of the owner where they
+// are editors.
+func (p policy) patch(ctx context.Context, c *gin.Context, a model.Access, req model.UpdateTaskRequest) error {
+	if a.Role == model.RoleOwner {
+		return nil
+	}
+	if req.ProjectID != nil && *req.ProjectID != a.ProjectID {
+		if *req.ProjectID == "" {
+			return &service.ForbiddenError{Need: model.RoleOwner}
+		}
+		to, err := p.project(ctx, c, *req.ProjectID, model.RoleEditor)
+		if err != nil {
+			return projectError(err)
+		}
+		if to.OwnerID != a.OwnerID {
+			return store.ErrInvalidProject
+		}
+	}
+	if req.ParentID != nil {
+		return p.parent(ctx, c, a, *req.ParentID)
+	}
+	return nil
+}
+
+// parent checks that a member sees parentID as a task of the owner they act
+// for; the store checks the rest.
+func (p policy) parent(ctx context.Context, c *gin.Context, a model.Access, parentID string) error {
+	if parentID == "" || a.Role == model.RoleOwner {
+		return nil
+	}
+	pa, err := p.task(ctx, c, parentID, model.RoleViewer)
+	if err != nil {
+		return err
+	}
+	if pa.OwnerID != a.OwnerID {
+		return store.ErrInvalidParent
+	}
+	return nil
+}
+
+// projectError reports a project the caller cannot see like a missing one,
+// as a bad project_id.
+func projectError(err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return store.ErrInvalidProject
+	}
+	return err
+}
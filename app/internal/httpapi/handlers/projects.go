@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// ProjectsHandler serves the projects of the caller and those shared with
+// them, with the tasks in them, their members and invitations.
+type ProjectsHandler struct {
+	repo    store.ProjectRepository
+	members store.MemberRepository
+	tasks   store.TaskRepository
+	policy  policy
+}
+
+func NewProjectsHandler(repo store.ProjectRepository, members store.MemberRepository, tasks store.TaskRepository) *ProjectsHandler {
+	return &ProjectsHandler{repo: repo, members: members, tasks: tasks, policy: policy{members: members}}
+}
+
+func (h *ProjectsHandler) Create(c *gin.Context) {
+	var req model.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	name, err := service.NormalizeProjectName(req.Name)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := service.CheckProjectDescription(req.Description); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	p, err := h.repo.CreateProject(ctx, middleware.Subject(c), model.Project{Name: name, Description: req.Description})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// List returns the unarchived projects by name, all of them with
+// include_archived=true, each with the caller's role.
+func (h *ProjectsHandler) List(c *gin.Context) {
+	var includeArchived bool
+	if v := c.Query("include_archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(c, &service.ValidationError{Field: "include_archived", Message: "invalid include_archived (true or false required)"})
+			return
+		}
+		includeArchived = b
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	items, err := h.repo.ListProjects(ctx, middleware.Subject(c), includeArchived)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.ProjectList{Items: items})
+}
+
+func (h *ProjectsHandler) Get(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p, err := h.repo.GetProject(ctx, a.OwnerID, id)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p.Role = a.Role
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *ProjectsHandler) Update(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	var req model.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	if req.Name != nil {
+		name, err := service.NormalizeProjectName(*req.Name)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		req.Name = &name
+	}
+	if req.Description != nil {
+		if err := service.CheckProjectDescription(*req.Description); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p, err := h.repo.UpdateProject(ctx, a.OwnerID, id, req)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p.Role = a.Role
+	c.JSON(http.StatusOK, p)
+}
+
+// Delete refuses a project with live tasks (409); its trashed tasks leave
+// it. Only the owner deletes a project.
+func (h *ProjectsHandler) Delete(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleOwner)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	if err := h.repo.DeleteProject(ctx, a.OwnerID, id); err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Archive hides the project from List and its tasks from GET /tasks, until
+// unarchived. They stay readable and writable.
+func (h *ProjectsHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+func (h *ProjectsHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *ProjectsHandler) setArchived(c *gin.Context, archived bool) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleAdmin)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p, err := h.repo.SetArchived(ctx, a.OwnerID, id, archived)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	p.Role = a.Role
+	c.JSON(http.StatusOK, p)
+}
+
+// Tasks lists the live tasks of the project, archived or not, with the
+// parameters of GET /tasks.
+func (h *ProjectsHandler) Tasks(c *gin.Context) {
+	id, ok := projectID(c)
+	if !ok {
+		return
+	}
+	params, err := service.ParseListTasksParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	params.ProjectID = &id
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.project(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeProjectError(c, err)
+		return
+	}
+	listTasks(c, h.tasks, a.OwnerID, params)
+}
+
+// projectID reads the :id parameter; an id that cannot exist is a 404.
+func projectID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		writeProjectError(c, store.ErrNotFound)
+		return "", false
+	}
+	return id, true
+}
+
+// writeProjectError is writeError with the 404 naming the project.
+func writeProjectError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeNotFound, "", "project not found")
+		return
+	}
+	writeError(c, err)
+}
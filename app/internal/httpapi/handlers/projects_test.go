@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+)
+
+func TestProjectsCRUD(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := do(t, r, http.MethodPost, "/projects", "alice", map[string]any{"name": "  work ", "description": "day job"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	project := decode[model.Project](t, w)
+	if project.Name != "work" || project.Archived {
+		t.Fatalf("created %+v", project)
+	}
+	if w := do(t, r, http.MethodPost, "/projects", "alice", map[string]any{"name": "  "}); w.Code != http.StatusBadRequest || decode[problem.Problem](t, w).Field != "name" {
+		t.Errorf("blank name: status %d, body %s", w.Code, w.Body)
+	}
+
+	if w := do(t, r, http.MethodGet, "/projects/"+project.ID, "bob", nil); w.Code != http.StatusNotFound || decode[problem.Problem](t, w).Detail != "project not found" {
+		t.Errorf("get by another caller: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/projects/nope", "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get malformed id: status %d", w.Code)
+	}
+	w = do(t, r, http.MethodPut, "/projects/"+project.ID, "alice", map[string]any{"name": "office"})
+	if got := decode[model.Project](t, w); w.Code != http.StatusOK || got.Name != "office" || got.Description != "day job" {
+		t.Errorf("update: status %d, body %s", w.Code, w.Body)
+	}
+
+	// Tasks join the project
+	w = do(t, r, http.MethodPost, "/tasks", "alice", map[string]any{
+		"title": "report", "content": "c", "due_date": "2000-01-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": project.ID,
+	})
+	if task := decode[model.Task](t, w); w.Code != http.StatusCreated || task.ProjectID == nil || *task.ProjectID != project.ID {
+		t.Fatalf("create task in project: status %d, body %s", w.Code, w.Body)
+	}
+	loose := createTask(t, r, "alice", "loose")
+	w = do(t, r, http.MethodPost, "/tasks", "bob", map[string]any{
+		"title": "x", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z", "project_id": project.ID,
+	})
+	if w.Code != http.StatusBadRequest || decode[problem.Problem](t, w).Field != "project_id" {
+		t.Errorf("create task in another caller's project: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodPut, "/tasks/"+loose.ID, "alice", map[string]any{"project_id": "nope", "request_timestamp": "2025-01-01T00:00:01Z"}); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed project_id: status %d, body %s", w.Code, w.Body)
+	}
+
+	w = do(t, r, http.MethodGet, "/projects/"+project.ID, "alice", nil)
+	if got := decode[model.Project](t, w); got.TaskCounts != (model.ProjectCounts{Open: 1, Overdue: 1}) {
+		t.Errorf("task_counts = %+v", got.TaskCounts)
+	}
+	w = do(t, r, http.MethodGet, "/projects/"+project.ID+"/tasks", "alice", nil)
+	if page := decode[model.TaskPage](t, w); w.Code != http.StatusOK || len(page.Items) != 1 || page.Items[0].Title != "report" {
+		t.Errorf("project tasks: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/projects/"+project.ID+"/tasks", "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("project tasks by another caller: status %d", w.Code)
+	}
+
+	// Archiving hides the project and its tasks
+	w = do(t, r, http.MethodPost, "/projects/"+project.ID+"/archive", "alice", nil)
+	if got := decode[model.Project](t, w); w.Code != http.StatusOK || !got.Archived || got.ArchivedAt == nil {
+		t.Fatalf("archive: status %d, body %s", w.Code, w.Body)
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "alice", nil)); len(page.Items) != 1 || page.Items[0].ID != loose.ID {
+		t.Errorf("tasks with the project archived = %+v", page.Items)
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks?include_archived=true", "alice", nil)); len(page.Items) != 2 {
+		t.Errorf("tasks including archived = %+v", page.Items)
+	}
+	if list := decode[model.ProjectList](t, do(t, r, http.MethodGet, "/projects", "alice", nil)); len(list.Items) != 0 {
+		t.Errorf("projects without archived = %+v", list.Items)
+	}
+	if list := decode[model.ProjectList](t, do(t, r, http.MethodGet, "/projects?include_archived=true", "alice", nil)); len(list.Items) != 1 {
+		t.Errorf("projects with archived = %+v", list.Items)
+	}
+	w = do(t, r, http.MethodPost, "/projects/"+project.ID+"/unarchive", "alice", nil)
+	if got := decode[model.Project](t, w); w.Code != http.StatusOK || got.Archived {
+		t.Errorf("unarchive: status %d, body %s", w.Code, w.Body)
+	}
+
+	if w := do(t, r, http.MethodDelete, "/projects/"+project.ID, "alice", nil); w.Code != http.StatusConflict || decode[problem.Problem](t, w).Code != problem.CodeProjectNotEmpty {
+		t.Errorf("delete with tasks: status %d, body %s", w.Code, w.Body)
+	}
+	w = do(t, r, http.MethodPut, "/tasks/"+loose.ID, "alice", map[string]any{"project_id": project.ID, "request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("move into project: status %d, body %s", w.Code, w.Body)
+	}
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/projects/"+project.ID+"/tasks", "alice", nil))
+	for _, task := range page.Items {
+		if w := do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"project_id": "", "request_timestamp": "2025-01-01T00:00:02Z"}); w.Code != http.StatusOK {
+			t.Fatalf("move out: status %d, body %s", w.Code, w.Body)
+		}
+	}
+	if w := do(t, r, http.MethodDelete, "/projects/"+project.ID, "alice", nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete once empty: status %d, body %s", w.Code, w.Body)
+	}
+}
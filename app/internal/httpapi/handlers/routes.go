@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/middleware"
+)
+
+// Handlers are the handlers of the API routes.
+type Handlers struct {
+	Tasks    *TasksHandler
+	Projects *ProjectsHandler
+	Webhooks *WebhooksHandler
+	Events   *EventsHandler
+	// Idempotency replays the answers of retried creations
+	Idempotency gin.HandlerFunc
+}
+
+// RegisterRoutes declares the API routes on rg, which authenticates the
+// requests. Every route declares the scope its token needs. Sharing and
+// webhooks hand data to others, so they take tasks:admin.
+func RegisterRoutes(rg *gin.RouterGroup, h Handlers) {
+	read := middleware.RequireScope(middleware.ScopeTasksRead)
+	write := middleware.RequireScope(middleware.ScopeTasksWrite)
+	remove := middleware.RequireScope(middleware.ScopeTasksDelete)
+	admin := middleware.RequireScope(middleware.ScopeTasksAdmin)
+
+	tasks := h.Tasks
+	rg.POST("/tasks", write, h.Idempotency, tasks.Create)
+	rg.POST("/tasks:batch", middleware.CustomMethod("batch"), write, h.Idempotency, tasks.Batch)
+	rg.GET("/tasks", read, tasks.List)
+	rg.GET("/tasks/trash", read, tasks.Trash)
+	rg.GET("/tasks/search", read, tasks.Search)
+	rg.GET("/tasks/export", read, tasks.Export)
+	rg.POST("/tasks/import", write, tasks.Import)
+	rg.GET("/tasks/events", read, h.Events.Stream)
+	rg.GET("/tasks/:id", read, tasks.Get)
+	rg.PUT("/tasks/:id", write, tasks.Update)
+	rg.DELETE("/tasks/:id", remove, tasks.Delete)
+	rg.POST("/tasks/:id/restore", write, tasks.Restore)
+	rg.GET("/tasks/:id/history", read, tasks.History)
+	rg.GET("/tasks/:id/children", read, tasks.Children)
+	rg.GET("/tasks/:id/tree", read, tasks.Tree)
+	rg.GET("/tasks/:id/occurrences", read, tasks.Occurrences)
+	rg.DELETE("/tasks/:id/recurrence", write, tasks.StopRecurrence)
+	rg.GET("/tags", read, tasks.Tags)
+
+	projects := h.Projects
+	rg.POST("/projects", write, projects.Create)
+	rg.GET("/projects", read, projects.List)
+	rg.GET("/projects/:id", read, projects.Get)
+	rg.PUT("/projects/:id", write, projects.Update)
+	rg.DELETE("/projects/:id", remove, projects.Delete)
+	rg.POST("/projects/:id/archive", write, projects.Archive)
+	rg.POST("/projects/:id/unarchive", write, projects.Unarchive)
+	rg.GET("/projects/:id/tasks", read, projects.Tasks)
+	rg.GET("/projects/:id/members", read, projects.Members)
+	rg.PUT("/projects/:id/members/:user", admin, projects.UpdateMember)
+	rg.DELETE("/projects/:id/members/:user", admin, projects.RemoveMember)
+	rg.POST("/projects/:id/invitations", admin, projects.Invite)
+	rg.GET("/projects/:id/invitations", admin, projects.Invitations)
+	rg.DELETE("/projects/:id/invitations/:invitation", admin, projects.RevokeInvitation)
+	rg.GET("/invitations", read, projects.MyInvitations)
+	rg.POST("/invitations/:id/accept", write, projects.AcceptInvitation)
+	rg.POST("/invitations/:id/decline", write, projects.DeclineInvitation)
+
+	webhooks := h.Webhooks
+	rg.POST("/webhooks", admin, webhooks.Create)
+	rg.GET("/webhooks", admin, webhooks.List)
+	rg.GET("/webhooks/:id", admin, webhooks.Get)
+	rg.PUT("/webhooks/:id", admin, webhooks.Update)
+	rg.DELETE("/webhooks/:id", admin, webhooks.Delete)
+	rg.GET("/webhooks/:id/attempts", admin, webhooks.Attempts)
+}
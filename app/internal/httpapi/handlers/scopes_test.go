@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"team5/task-manager/internal/httpapi/problem"
+)
+
+func TestRouteScopes(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "scoped")
+	reader := scopedToken(t, "alice", "tasks:read")
+	writer := scopedToken(t, "alice", "tasks:read tasks:write")
+
+	if w := doWithToken(t, r, http.MethodGet, "/tasks/"+task.ID, reader, nil); w.Code != http.StatusOK {
+		t.Errorf("read with tasks:read: status %d", w.Code)
+	}
+	w := doWithToken(t, r, http.MethodPut, "/tasks/"+task.ID, reader, map[string]any{"title": "x", "request_timestamp": "2025-01-01T00:00:01Z"})
+	if p := decode[problem.Problem](t, w); w.Code != http.StatusForbidden || p.Code != problem.CodeInsufficientScope || p.Scope != "tasks:write" {
+		t.Errorf("update with tasks:read: status %d, body %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer error="insufficient_scope", scope="tasks:write"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+	if w := doWithToken(t, r, http.MethodPut, "/tasks/"+task.ID, writer, map[string]any{"title": "x", "request_timestamp": "2025-01-01T00:00:01Z"}); w.Code != http.StatusOK {
+		t.Errorf("update with tasks:write: status %d, body %s", w.Code, w.Body)
+	}
+	if w := doWithToken(t, r, http.MethodDelete, "/tasks/"+task.ID, writer, nil); w.Code != http.StatusForbidden || decode[problem.Problem](t, w).Scope != "tasks:delete" {
+		t.Errorf("delete with tasks:write: status %d, body %s", w.Code, w.Body)
+	}
+	w = doWithToken(t, r, http.MethodPost, "/tasks:batch", writer, map[string]any{"operations": []map[string]any{
+		{"op": "delete", "id": task.ID, "request_timestamp": "2025-01-02T00:00:00Z"},
+	}})
+	if w.Code != http.StatusForbidden || decode[problem.Problem](t, w).Scope != "tasks:delete" {
+		t.Errorf("batch delete with tasks:write: status %d, body %s", w.Code, w.Body)
+	}
+	if w := doWithToken(t, r, http.MethodGet, "/webhooks", writer, nil); w.Code != http.StatusForbidden || decode[problem.Problem](t, w).Scope != "tasks:admin" {
+		t.Errorf("webhooks with tasks:write: status %d, body %s", w.Code, w.Body)
+	}
+
+	// scp lists the scopes as an array; tokens without scopes get none
+	claims := jwt.MapClaims{"sub": "alice", "scp": []string{"tasks:read", "tasks:delete"}, "exp": time.Now().Add(time.Hour).Unix()}
+	scp, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := doWithToken(t, r, http.MethodDelete, "/tasks/"+task.ID, scp, map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"}); w.Code != http.StatusOK {
+		t.Errorf("delete with scp tasks:delete: status %d, body %s", w.Code, w.Body)
+	}
+	if w := doWithToken(t, r, http.MethodGet, "/tasks", scopedToken(t, "alice", ""), nil); w.Code != http.StatusForbidden {
+		t.Errorf("list without scopes: status %d", w.Code)
+	}
+}
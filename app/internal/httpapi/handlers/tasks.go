@@ -2,107 +2,320 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"team5/task-manager/internal/model"
 	"team5/task-manager/internal/service"
-	"team5/task-manager/internal/store/postgres"
+	"team5/task-manager/internal/store"
 )
 
+// maxOccurrences caps the preview of GET /tasks/:id/occurrences.
+const maxOccurrences = 100
+
 type TasksHandler struct {
-	store *postgres.TasksStore
+	repo   store.TaskRepository
+	policy policy
+	opts   TasksOptions
+}
+
+// TasksOptions are the deployment defaults of the task endpoints.
+type TasksOptions struct {
+	// DeleteChildren applies when DELETE /tasks/:id has no children parameter (orphan if empty)
+	DeleteChildren store.ChildrenMode
+	// Workflow lists the allowed status changes (service.DefaultWorkflow if nil)
+	Workflow service.Workflow
 }
 
-func NewTasksHandler(store *postgres.TasksStore) *TasksHandler {
-	return &TasksHandler{store: store}
+// NewTasksHandler serves the tasks of repo; members grants access to the
+// tasks of shared projects.
+func NewTasksHandler(repo store.TaskRepository, members store.MemberRepository, opts TasksOptions) *TasksHandler {
+	if opts.DeleteChildren == "" {
+		opts.DeleteChildren = store.ChildrenOrphan
+	}
+	if opts.Workflow == nil {
+		opts.Workflow = service.DefaultWorkflow()
+	}
+	return &TasksHandler{repo: repo, policy: policy{members: members}, opts: opts}
 }
 
 func (h *TasksHandler) Create(c *gin.Context) {
 	var req model.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Status(http.StatusBadRequest)
+		writeError(c, err)
 		return
 	}
 
-	reqTS, err := service.ParseRFC3339(req.RequestTimestamp)
+	in, err := newTask(req)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		writeError(c, err)
 		return
 	}
 
+	// Timeout court pour détecter surcharge → 429 (pragmatique)
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.create(ctx, c, in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	t, err := h.repo.Create(ctx, a.OwnerID, in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("ETag", etag(t))
+	c.JSON(http.StatusCreated, t)
+}
+
+// newTask validates the fields of a bound CreateTaskRequest.
+func newTask(req model.CreateTaskRequest) (store.NewTask, error) {
+	reqTS, err := service.ParseRFC3339(req.RequestTimestamp)
+	if err != nil {
+		return store.NewTask{}, err
+	}
 	due, err := service.ParseDateYYYYMMDD(req.DueDate)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		return store.NewTask{}, err
+	}
+	if err := checkParentID(req.ParentID); err != nil {
+		return store.NewTask{}, err
+	}
+	if err := checkProjectID(req.ProjectID); err != nil {
+		return store.NewTask{}, err
+	}
+	tags, err := service.NormalizeTags(req.Tags)
+	if err != nil {
+		return store.NewTask{}, err
+	}
+	if req.Status != "" {
+		if err := service.CheckStatus("status", req.Status); err != nil {
+			return store.NewTask{}, err
+		}
+	}
+	if req.Priority != "" {
+		if err := service.CheckPriority("priority", req.Priority); err != nil {
+			return store.NewTask{}, err
+		}
+	}
+	in := store.NewTask{Title: req.Title, Content: req.Content, DueDate: due, RequestTimestamp: reqTS, ParentID: req.ParentID, ProjectID: req.ProjectID, Tags: tags, Status: req.Status, Priority: req.Priority}
+	if req.Recurrence != "" {
+		rule, err := service.ParseRRule(req.Recurrence)
+		if err != nil {
+			return store.NewTask{}, err
+		}
+		in.Recurrence = rule.String()
+	}
+	return in, nil
+}
+
+// checkPatch validates the parent, project, status and priority and normalises the
+// tags and recurrence of an update.
+func checkPatch(req *model.UpdateTaskRequest) error {
+	if req.Recurrence != nil && *req.Recurrence != "" {
+		rule, err := service.ParseRRule(*req.Recurrence)
+		if err != nil {
+			return err
+		}
+		canonical := rule.String()
+		req.Recurrence = &canonical
+	}
+	if req.Status != nil {
+		if err := service.CheckStatus("status", *req.Status); err != nil {
+			return err
+		}
+		if req.Done != nil && *req.Done != (*req.Status == model.StatusDone) {
+			return &service.ValidationError{Field: "done", Message: "done contradicts status"}
+		}
+	}
+	if req.Priority != nil {
+		if err := service.CheckPriority("priority", *req.Priority); err != nil {
+			return err
+		}
+	}
+	if req.ParentID != nil {
+		if err := checkParentID(*req.ParentID); err != nil {
+			return err
+		}
+	}
+	if req.ProjectID != nil {
+		if err := checkProjectID(*req.ProjectID); err != nil {
+			return err
+		}
+	}
+	if req.Tags != nil {
+		tags, err := service.NormalizeTags(*req.Tags)
+		if err != nil {
+			return err
+		}
+		req.Tags = &tags
+	}
+	return nil
+}
+
+// checkParentID rejects a parent_id that cannot be a task id; "" means no parent.
+func checkParentID(id string) error {
+	if id == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return &service.ValidationError{Field: "parent_id", Message: "parent_id must be a task id"}
+	}
+	return nil
+}
+
+// checkProjectID rejects a project_id that cannot be a project id; "" means no project.
+func checkProjectID(id string) error {
+	if id == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return &service.ValidationError{Field: "project_id", Message: "project_id must be a project id"}
+	}
+	return nil
+}
+
+// List pages through the caller's own tasks and those of the projects
+// shared with them.
+func (h *TasksHandler) List(c *gin.Context) {
+	h.list(c, false)
+}
+
+// Trash lists deleted tasks not purged yet, with the same parameters as List.
+func (h *TasksHandler) Trash(c *gin.Context) {
+	h.list(c, true)
+}
+
+func (h *TasksHandler) list(c *gin.Context, trashed bool) {
+	params, err := service.ParseListTasksParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	params.Trashed = trashed
+	if params.Shared, err = h.sharedIDs(c); err != nil {
+		writeError(c, err)
 		return
 	}
+	listTasks(c, h.repo, h.policy.own(c).OwnerID, params)
+}
 
-	// Timeout court pour détecter surcharge → 429 (pragmatique)
+// sharedIDs returns the projects shared with the caller.
+func (h *TasksHandler) sharedIDs(c *gin.Context) ([]string, error) {
 	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
 	defer cancel()
+	shared, err := h.policy.shared(ctx, c)
+	return projectIDs(shared), err
+}
 
-	t, err := h.store.Create(ctx, req.Title, req.Content, due, reqTS)
+// listTasks writes the page of the tasks of ownerID selected by params.
+func listTasks(c *gin.Context, repo store.TaskRepository, ownerID string, params model.ListTasksParams) {
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	tasks, next, err := repo.List(ctx, ownerID, params)
 	if err != nil {
-		if isOverload(err) {
-			c.Status(http.StatusTooManyRequests)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		writeError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, t)
+
+	page := model.TaskPage{Items: tasks}
+	if next != nil {
+		page.NextCursor = service.EncodeCursor(*next)
+	}
+	c.JSON(http.StatusOK, page)
 }
 
-func (h *TasksHandler) List(c *gin.Context) {
+// Search runs a full-text query over title and content: words must all
+// match, "quoted text" as a phrase, word* as a prefix. Results are ranked,
+// each with an HTML snippet where matches are wrapped in <mark>. The
+// caller's own tasks are searched with those of the projects shared with
+// them.
+func (h *TasksHandler) Search(c *gin.Context) {
+	params, err := service.ParseSearchParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
 	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
 	defer cancel()
 
-	tasks, err := h.store.List(ctx)
+	shared, err := h.policy.shared(ctx, c)
 	if err != nil {
-		if isOverload(err) {
-			c.Status(http.StatusTooManyRequests)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		writeError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, tasks)
+	params.Shared = projectIDs(shared)
+	hits, more, err := h.repo.Search(ctx, h.policy.own(c).OwnerID, params)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	page := model.SearchPage{Items: hits}
+	if more {
+		page.NextCursor = service.EncodeSearchCursor(params)
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *TasksHandler) Get(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
 	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
 	defer cancel()
 
-	t, err := h.store.Get(ctx, id)
+	a, err := h.policy.task(ctx, c, id, model.RoleViewer)
 	if err != nil {
-		if errors.Is(err, postgres.ErrNotFound) {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		if isOverload(err) {
-			c.Status(http.StatusTooManyRequests)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		writeError(c, err)
+		return
+	}
+	t, err := h.repo.Get(ctx, a.OwnerID, id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	tag := etag(t)
+	c.Header("ETag", tag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && ifNoneMatch(inm, tag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, t)
 }
 
 func (h *TasksHandler) Update(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
 	var req model.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Status(http.StatusBadRequest)
+		writeError(c, err)
 		return
 	}
 
-	reqTS, err := service.ParseRFC3339(req.RequestTimestamp)
+	cond, err := precondition(c, req.RequestTimestamp)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		writeError(c, err)
+		return
+	}
+	cond.Transition = h.opts.Workflow.Allows
+
+	if err := checkPatch(&req); err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -110,7 +323,7 @@ func (h *TasksHandler) Update(c *gin.Context) {
 	if req.DueDate != nil {
 		d, err := service.ParseDateYYYYMMDD(*req.DueDate)
 		if err != nil {
-			c.Status(http.StatusBadRequest)
+			writeError(c, err)
 			return
 		}
 		due = &d
@@ -119,59 +332,335 @@ func (h *TasksHandler) Update(c *gin.Context) {
 	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
 	defer cancel()
 
-	t, err := h.store.Update(ctx, id, req, due, reqTS)
+	a, err := h.policy.task(ctx, c, id, model.RoleEditor)
 	if err != nil {
-		if errors.Is(err, postgres.ErrNotFound) {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		if errors.Is(err, postgres.ErrConflict) {
-			c.Status(http.StatusConflict)
-			return
-		}
-		if isOverload(err) {
-			c.Status(http.StatusTooManyRequests)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		writeError(c, err)
+		return
+	}
+	if err := h.policy.patch(ctx, c, a, req); err != nil {
+		writeError(c, err)
+		return
+	}
+	t, err := h.repo.Update(ctx, a.OwnerID, id, req, due, cond)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
+	c.Header("ETag", etag(t))
 	c.JSON(http.StatusOK, t)
 }
 
 func (h *TasksHandler) Delete(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	// The body may be omitted when If-Match is used
 	var req model.DeleteTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Status(http.StatusBadRequest)
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, err)
 		return
 	}
 
-	reqTS, err := service.ParseRFC3339(req.RequestTimestamp)
+	cond, err := precondition(c, req.RequestTimestamp)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		writeError(c, err)
+		return
+	}
+
+	children, err := h.childrenMode(c.Query("children"))
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
 	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
 	defer cancel()
 
-	err = h.store.Delete(ctx, id, reqTS)
+	a, err := h.policy.task(ctx, c, id, model.RoleEditor)
 	if err != nil {
-		if errors.Is(err, postgres.ErrNotFound) {
-			c.Status(http.StatusNotFound)
-			return
+		writeError(c, err)
+		return
+	}
+	h.policy.deletion(a, &cond)
+	if err := h.repo.Delete(ctx, a.OwnerID, id, cond, children); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// childrenMode parses the children parameter of a delete, falling back to the configured default.
+func (h *TasksHandler) childrenMode(v string) (store.ChildrenMode, error) {
+	switch mode := store.ChildrenMode(v); mode {
+	case "":
+		return h.opts.DeleteChildren, nil
+	case store.ChildrenOrphan, store.ChildrenCascade, store.ChildrenBlock:
+		return mode, nil
+	}
+	return "", &service.ValidationError{Field: "children", Message: "invalid children (orphan, cascade or block required)"}
+}
+
+func (h *TasksHandler) Restore(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	// The body may be omitted when If-Match is used
+	var req model.RestoreTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, err)
+		return
+	}
+
+	cond, err := precondition(c, req.RequestTimestamp)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleEditor)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	t, err := h.repo.Restore(ctx, a.OwnerID, id, cond)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("ETag", etag(t))
+	c.JSON(http.StatusOK, t)
+}
+
+// Children lists the live direct subtasks of a task, with the parameters of
+// List. Members only see the subtasks in the task's project.
+func (h *TasksHandler) Children(c *gin.Context) {
+	params, err := service.ParseListTasksParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	params.ParentID = &id
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if a.Role != model.RoleOwner {
+		params.ProjectID = &a.ProjectID
+	}
+	// An unknown parent is a 404, not an empty page
+	if _, err := h.repo.Get(ctx, a.OwnerID, id); err != nil {
+		writeError(c, err)
+		return
+	}
+	tasks, next, err := h.repo.List(ctx, a.OwnerID, params)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	page := model.TaskPage{Items: tasks}
+	if next != nil {
+		page.NextCursor = service.EncodeCursor(*next)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Tree returns a task with all its live subtasks, nested. Members only see
+// the subtasks in the task's project.
+func (h *TasksHandler) Tree(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	node, err := h.repo.Tree(ctx, a.OwnerID, id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if a.Role != model.RoleOwner {
+		node = pruneTree(node, a.ProjectID)
+	}
+	c.JSON(http.StatusOK, node)
+}
+
+// pruneTree drops the subtrees rooted outside the project.
+func pruneTree(node model.TaskNode, projectID string) model.TaskNode {
+	children := make([]model.TaskNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		if child.ProjectID != nil && *child.ProjectID == projectID {
+			children = append(children, pruneTree(child, projectID))
 		}
-		if errors.Is(err, postgres.ErrConflict) {
-			c.Status(http.StatusConflict)
+	}
+	node.Children = children
+	return node
+}
+
+// Occurrences previews the next due dates of a recurring task (limit, 10 by
+// default); the list is empty for a one-off task.
+func (h *TasksHandler) Occurrences(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxOccurrences {
+			writeError(c, &service.ValidationError{Field: "limit", Message: fmt.Sprintf("invalid limit (1-%d required)", maxOccurrences)})
 			return
 		}
-		if isOverload(err) {
-			c.Status(http.StatusTooManyRequests)
+		limit = n
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	t, err := h.repo.Get(ctx, a.OwnerID, id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	list := model.OccurrenceList{Items: []string{}}
+	if t.Recurrence != "" {
+		rule, err := service.ParseRRule(t.Recurrence)
+		if err != nil {
+			writeError(c, err)
 			return
 		}
-		c.Status(http.StatusInternalServerError)
+		start, _ := service.ParseDateYYYYMMDD(t.RecurrenceStart)
+		due, _ := service.ParseDateYYYYMMDD(t.DueDate)
+		for _, d := range rule.After(start, due, limit) {
+			list.Items = append(list.Items, d.Format("2006-01-02"))
+		}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// StopRecurrence ends the series of a recurring task: completing it will no
+// longer create a next occurrence. Guarded like Update.
+func (h *TasksHandler) StopRecurrence(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
 		return
 	}
-	c.Status(http.StatusOK)
+	// The body may be omitted when If-Match is used
+	var req model.DeleteTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, err)
+		return
+	}
+
+	cond, err := precondition(c, req.RequestTimestamp)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleEditor)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	stop := ""
+	t, err := h.repo.Update(ctx, a.OwnerID, id, model.UpdateTaskRequest{Recurrence: &stop}, nil, cond)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("ETag", etag(t))
+	c.JSON(http.StatusOK, t)
+}
+
+// Tags lists the tags of the caller's own tasks and of those of the projects
+// shared with them, with the number of live tasks using each.
+func (h *TasksHandler) Tags(c *gin.Context) {
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	shared, err := h.policy.shared(ctx, c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	tags, err := h.repo.Tags(ctx, h.policy.own(c).OwnerID, projectIDs(shared))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.TagList{Items: tags})
+}
+
+// History returns the task's audit trail, oldest first. It remains available
+// for trashed and purged tasks.
+func (h *TasksHandler) History(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	params, err := service.ParseHistoryParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	a, err := h.policy.task(ctx, c, id, model.RoleViewer)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	entries, next, err := h.repo.History(ctx, a.OwnerID, id, params)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	page := model.TaskHistoryPage{Items: entries}
+	if next != 0 {
+		page.NextCursor = service.EncodeHistoryCursor(next)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// taskID reads the :id parameter, answering 404 when it is not a task id.
+func taskID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(c, store.ErrNotFound)
+		return "", false
+	}
+	return id, true
 }
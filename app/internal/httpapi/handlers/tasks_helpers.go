@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,3 +17,13 @@ func contextWithTimeout(c *gin.Context, d time.Duration) (context.Context, conte
 func isOverload(err error) bool {
 	return errors.Is(err, context.DeadlineExceeded)
 }
+
+// requestLogger returns the per-request logger set by middleware.CorrelationID
+func requestLogger(c *gin.Context) *slog.Logger {
+	if v, ok := c.Get("logger"); ok {
+		if log, ok := v.(*slog.Logger); ok {
+			return log
+		}
+	}
+	return slog.Default()
+}
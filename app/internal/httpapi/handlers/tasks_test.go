@@ -0,0 +1,629 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store/memory"
+)
+
+const testSecret = "test-secret"
+
+// newTestRouter serves the routes of the API over memory stores.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/")
+	api.Use(middleware.AuthJWT(testSecret, middleware.AuthOptions{}))
+
+	repo := memory.NewTasksStore()
+	projectStore := memory.NewProjectStore(repo)
+	RegisterRoutes(api, Handlers{
+		Tasks:       NewTasksHandler(repo, projectStore, TasksOptions{}),
+		Projects:    NewProjectsHandler(projectStore, projectStore, repo),
+		Webhooks:    NewWebhooksHandler(memory.NewWebhookStore(repo)),
+		Events:      NewEventsHandler(repo, repo, projectStore, EventsOptions{}),
+		Idempotency: middleware.Idempotency(memory.NewIdempotencyStore(), time.Hour),
+	})
+	return r
+}
+
+// token signs a token for sub with every scope.
+func token(t *testing.T, sub string) string {
+	t.Helper()
+	return scopedToken(t, sub, middleware.ScopeTasksAdmin)
+}
+
+func scopedToken(t *testing.T, sub, scope string) string {
+	t.Helper()
+	return signedToken(t, jwt.MapClaims{"sub": sub, "scope": scope})
+}
+
+// tenantToken signs a token for sub in tenant with every scope.
+func tenantToken(t *testing.T, sub, tenant string) string {
+	t.Helper()
+	return signedToken(t, jwt.MapClaims{"sub": sub, "scope": middleware.ScopeTasksAdmin, "tenant_id": tenant})
+}
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func do(t *testing.T, r http.Handler, method, path, sub string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	tok := ""
+	if sub != "" {
+		tok = token(t, sub)
+	}
+	return doWithToken(t, r, method, path, tok, body)
+}
+
+func doWithToken(t *testing.T, r http.Handler, method, path, tok string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func createTask(t *testing.T, r http.Handler, sub, title string) model.Task {
+	t.Helper()
+	w := do(t, r, http.MethodPost, "/tasks", sub, map[string]string{
+		"title":             title,
+		"content":           "c",
+		"due_date":          "2025-06-01",
+		"request_timestamp": "2025-01-01T00:00:00Z",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	return decode[model.Task](t, w)
+}
+
+func TestTasksRequireToken(t *testing.T) {
+	r := newTestRouter(t)
+	if w := do(t, r, http.MethodGet, "/tasks", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestTasksCRUD(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "first")
+
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "alice", nil); w.Code != http.StatusOK {
+		t.Fatalf("get: status %d", w.Code)
+	}
+
+	w := do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"done": true, "request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusOK || !decode[model.Task](t, w).Done {
+		t.Fatalf("update: status %d, body %s", w.Code, w.Body)
+	}
+
+	w = do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"done": false, "request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("stale update: status %d, want 409", w.Code)
+	}
+
+	w = do(t, r, http.MethodDelete, "/tasks/"+task.ID, "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "alice", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: status %d, want 404", w.Code)
+	}
+}
+
+func TestTasksValidation(t *testing.T) {
+	r := newTestRouter(t)
+	w := do(t, r, http.MethodPost, "/tasks", "alice", map[string]string{
+		"title": "t", "content": "c", "due_date": "01/06/2025", "request_timestamp": "2025-01-01T00:00:00Z",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("bad due_date: status %d, want 400", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks?limit=0", "alice", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad limit: status %d, want 400", w.Code)
+	}
+}
+
+func TestTasksAreScopedToCaller(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "private")
+
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get by bob: status %d, want 404", w.Code)
+	}
+	w := do(t, r, http.MethodDelete, "/tasks/"+task.ID, "bob", map[string]string{"request_timestamp": "2025-01-02T00:00:00Z"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("delete by bob: status %d, want 404", w.Code)
+	}
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "bob", nil))
+	if len(page.Items) != 0 {
+		t.Errorf("bob lists %d tasks", len(page.Items))
+	}
+}
+
+func TestTasksAreScopedToTenant(t *testing.T) {
+	r := newTestRouter(t)
+	acme, globex := tenantToken(t, "alice", "acme"), tenantToken(t, "alice", "globex")
+	w := doWithToken(t, r, http.MethodPost, "/tasks", acme, map[string]string{
+		"title": "private", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	task := decode[model.Task](t, w)
+
+	// The same subject in another tenant is another user
+	if w := doWithToken(t, r, http.MethodGet, "/tasks/"+task.ID, globex, nil); w.Code != http.StatusNotFound {
+		t.Errorf("get from globex: status %d, want 404", w.Code)
+	}
+	w = doWithToken(t, r, http.MethodPut, "/tasks/"+task.ID, globex, map[string]any{"title": "x", "request_timestamp": "2025-01-02T00:00:00Z"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("update from globex: status %d, want 404", w.Code)
+	}
+	if page := decode[model.TaskPage](t, doWithToken(t, r, http.MethodGet, "/tasks", globex, nil)); len(page.Items) != 0 {
+		t.Errorf("globex lists %d tasks", len(page.Items))
+	}
+	// Nor does the default tenant of tokens without the claim see it
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get from the default tenant: status %d, want 404", w.Code)
+	}
+
+	w = doWithToken(t, r, http.MethodGet, "/tasks/"+task.ID, acme, nil)
+	if w.Code != http.StatusOK || decode[model.Task](t, w).Title != "private" {
+		t.Errorf("get from acme: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestTasksUnknownID(t *testing.T) {
+	r := newTestRouter(t)
+	body := map[string]any{"title": "x", "request_timestamp": "2025-01-02T00:00:00Z"}
+	for _, tc := range []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/tasks/not-a-uuid"},
+		{http.MethodPut, "/tasks/not-a-uuid"},
+		{http.MethodDelete, "/tasks/not-a-uuid"},
+		{http.MethodPost, "/tasks/not-a-uuid/restore"},
+		{http.MethodGet, "/tasks/not-a-uuid/history"},
+		{http.MethodGet, "/tasks/not-a-uuid/children"},
+		{http.MethodGet, "/tasks/not-a-uuid/tree"},
+		{http.MethodGet, "/tasks/not-a-uuid/occurrences"},
+		{http.MethodDelete, "/tasks/not-a-uuid/recurrence"},
+	} {
+		w := do(t, r, tc.method, tc.path, "alice", body)
+		if p := decode[problem.Problem](t, w); w.Code != http.StatusNotFound || p.Code != problem.CodeNotFound {
+			t.Errorf("%s %s: status %d, body %s", tc.method, tc.path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestTasksListPages(t *testing.T) {
+	r := newTestRouter(t)
+	for _, title := range []string{"a", "b", "c"} {
+		createTask(t, r, "alice", title)
+	}
+
+	w := do(t, r, http.MethodGet, "/tasks?limit=2", "alice", nil)
+	first := decode[model.TaskPage](t, w)
+	if len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %s", w.Body)
+	}
+
+	w = do(t, r, http.MethodGet, "/tasks?limit=2&cursor="+first.NextCursor, "alice", nil)
+	second := decode[model.TaskPage](t, w)
+	if len(second.Items) != 1 || second.NextCursor != "" {
+		t.Fatalf("second page = %s", w.Body)
+	}
+
+	w = do(t, r, http.MethodGet, "/tasks?limit=2&sort=due_date&cursor="+first.NextCursor, "alice", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("cursor reused with another sort: status %d, want 400", w.Code)
+	}
+
+	// Tampered cursors are refused before they reach the store
+	for _, cur := range []string{
+		`{"s":"created_at","v":"2025-01-01T00:00:00Z","id":"not-a-uuid"}`,
+		`{"s":"created_at","v":"2025-01-01T00:00:00Z"}`,
+		`{"s":"created_at","v":"0001-01-01T00:00:00Z","id":"5f0c7a36-3c4e-4f5e-9d7a-2b1f0e6c8a91"}`,
+		`{"s":"due_date","v":"2025-01-01T10:30:00Z","id":"5f0c7a36-3c4e-4f5e-9d7a-2b1f0e6c8a91"}`,
+		`{"s":"title","v":"2025-01-01T00:00:00Z","id":"5f0c7a36-3c4e-4f5e-9d7a-2b1f0e6c8a91"}`,
+	} {
+		sort := "created_at"
+		if strings.Contains(cur, "due_date") {
+			sort = "due_date"
+		}
+		w := do(t, r, http.MethodGet, "/tasks?sort="+sort+"&cursor="+base64.RawURLEncoding.EncodeToString([]byte(cur)), "alice", nil)
+		if p := decode[problem.Problem](t, w); w.Code != http.StatusBadRequest || p.Field != "cursor" {
+			t.Errorf("cursor %s: status %d, body %s", cur, w.Code, w.Body)
+		}
+	}
+}
+
+func TestTasksTrashAndRestore(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "oops")
+
+	w := do(t, r, http.MethodDelete, "/tasks/"+task.ID, "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+
+	trash := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks/trash", "alice", nil))
+	if len(trash.Items) != 1 || trash.Items[0].ID != task.ID || trash.Items[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v", trash)
+	}
+
+	w = do(t, r, http.MethodPost, "/tasks/"+task.ID+"/restore", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("restore with stale timestamp: status %d, want 409", w.Code)
+	}
+	w = do(t, r, http.MethodPost, "/tasks/"+task.ID+"/restore", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"})
+	if w.Code != http.StatusOK || decode[model.Task](t, w).DeletedAt != nil {
+		t.Fatalf("restore: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID, "alice", nil); w.Code != http.StatusOK {
+		t.Fatalf("get after restore: status %d", w.Code)
+	}
+}
+
+func TestTasksHistory(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "audited")
+	w := do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"done": true, "request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: status %d", w.Code)
+	}
+
+	w = do(t, r, http.MethodGet, "/tasks/"+task.ID+"/history?limit=1", "alice", nil)
+	first := decode[model.TaskHistoryPage](t, w)
+	if w.Code != http.StatusOK || len(first.Items) != 1 || first.Items[0].Action != model.ActionCreated || first.NextCursor == "" {
+		t.Fatalf("first page: status %d, body %s", w.Code, w.Body)
+	}
+	if first.Items[0].Actor != "alice" {
+		t.Errorf("actor = %q, want alice", first.Items[0].Actor)
+	}
+
+	w = do(t, r, http.MethodGet, "/tasks/"+task.ID+"/history?limit=1&cursor="+first.NextCursor, "alice", nil)
+	second := decode[model.TaskHistoryPage](t, w)
+	if len(second.Items) != 1 || second.Items[0].Action != model.ActionUpdated || second.NextCursor != "" {
+		t.Fatalf("second page = %s", w.Body)
+	}
+	if c := second.Items[0].Changes["done"]; c.From != false || c.To != true {
+		t.Errorf("done change = %+v", c)
+	}
+
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID+"/history", "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("other caller: status %d, want 404", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID+"/history?cursor=!", "alice", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: status %d, want 400", w.Code)
+	}
+}
+
+func TestTasksBatch(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "existing")
+	create := map[string]any{"op": "create", "title": "new", "content": "c", "due_date": "2025-02-01", "request_timestamp": "2025-01-01T00:00:00Z"}
+
+	// Atomic: an invalid operation rejects the whole batch before touching the store
+	w := do(t, r, http.MethodPost, "/tasks:batch", "alice", map[string]any{"operations": []any{
+		create,
+		map[string]any{"op": "create", "title": "no due date", "content": "c", "request_timestamp": "2025-01-01T00:00:00Z"},
+	}})
+	resp := decode[BatchResponse](t, w)
+	if w.Code != http.StatusBadRequest || resp.Applied || resp.Mode != model.BatchModeAtomic {
+		t.Fatalf("atomic with invalid op: status %d, body %s", w.Code, w.Body)
+	}
+	if resp.Results[0].Status != http.StatusFailedDependency || resp.Results[1].Error == nil || resp.Results[1].Error.Field != "due_date" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+
+	// Atomic: a conflict rolls back the operations before it
+	w = do(t, r, http.MethodPost, "/tasks:batch", "alice", map[string]any{"operations": []any{
+		create,
+		map[string]any{"op": "delete", "id": task.ID, "request_timestamp": "2024-01-01T00:00:00Z"},
+	}})
+	if w.Code != http.StatusConflict || decode[BatchResponse](t, w).Applied {
+		t.Fatalf("atomic with conflict: status %d, body %s", w.Code, w.Body)
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "alice", nil)); len(page.Items) != 1 {
+		t.Fatalf("rolled back batch left %d tasks", len(page.Items))
+	}
+
+	// Best effort: failures are reported per item, the rest applies
+	w = do(t, r, http.MethodPost, "/tasks:batch", "alice", map[string]any{"mode": "best_effort", "operations": []any{
+		create,
+		map[string]any{"op": "update", "id": task.ID, "done": true, "version": 7},
+		map[string]any{"op": "update", "id": task.ID, "done": true, "version": 1},
+		map[string]any{"op": "archive"},
+	}})
+	resp = decode[BatchResponse](t, w)
+	if w.Code != http.StatusOK || !resp.Applied || len(resp.Results) != 4 {
+		t.Fatalf("best effort: status %d, body %s", w.Code, w.Body)
+	}
+	statuses := []int{resp.Results[0].Status, resp.Results[1].Status, resp.Results[2].Status, resp.Results[3].Status}
+	if fmt.Sprint(statuses) != "[201 412 200 400]" {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if resp.Results[2].Task == nil || !resp.Results[2].Task.Done || resp.Results[1].Error.Field != "version" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+
+	if w := do(t, r, http.MethodPost, "/tasks:batch", "alice", map[string]any{"operations": []any{}}); w.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: status %d, want 400", w.Code)
+	}
+	if w := do(t, r, http.MethodPost, "/tasks:other", "alice", map[string]any{}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown custom method: status %d, want 404", w.Code)
+	}
+	// The custom method route must not shadow the other POST routes
+	do(t, r, http.MethodDelete, "/tasks/"+task.ID, "alice", map[string]any{"request_timestamp": "2030-01-01T00:00:00Z"})
+	if w := do(t, r, http.MethodPost, "/tasks/"+task.ID+"/restore", "alice", map[string]any{"request_timestamp": "2030-01-02T00:00:00Z"}); w.Code != http.StatusOK {
+		t.Errorf("restore: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestTasksSubtasks(t *testing.T) {
+	r := newTestRouter(t)
+	parent := createTask(t, r, "alice", "parent")
+	subtask := func(title, parentID string) *httptest.ResponseRecorder {
+		return do(t, r, http.MethodPost, "/tasks", "alice", map[string]string{
+			"title": title, "content": "c", "due_date": "2025-06-01",
+			"request_timestamp": "2025-01-01T00:00:00Z", "parent_id": parentID,
+		})
+	}
+	w := subtask("child", parent.ID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create subtask: status %d, body %s", w.Code, w.Body)
+	}
+	child := decode[model.Task](t, w)
+
+	if w := subtask("x", "not-a-uuid"); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed parent_id: status %d, want 400", w.Code)
+	}
+	if w := subtask("x", createTask(t, r, "bob", "bob's").ID); w.Code != http.StatusBadRequest {
+		t.Errorf("someone else's parent: status %d, want 400", w.Code)
+	}
+
+	w = do(t, r, http.MethodPut, "/tasks/"+parent.ID, "alice", map[string]any{"parent_id": child.ID, "request_timestamp": "2025-01-01T00:00:01Z"})
+	if w.Code != http.StatusConflict || decode[problem.Problem](t, w).Code != problem.CodeParentCycle {
+		t.Errorf("cycle: status %d, body %s", w.Code, w.Body)
+	}
+
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks/"+parent.ID+"/children", "alice", nil))
+	if len(page.Items) != 1 || page.Items[0].ID != child.ID {
+		t.Errorf("children = %+v", page)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+parent.ID+"/children", "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("children of someone else's task: status %d, want 404", w.Code)
+	}
+
+	do(t, r, http.MethodPut, "/tasks/"+child.ID, "alice", map[string]any{"done": true, "request_timestamp": "2025-01-01T00:00:01Z"})
+	tree := decode[model.TaskNode](t, do(t, r, http.MethodGet, "/tasks/"+parent.ID+"/tree", "alice", nil))
+	if tree.Progress == nil || *tree.Progress != 100 || len(tree.Children) != 1 || tree.Children[0].ID != child.ID {
+		t.Errorf("tree = %+v", tree)
+	}
+
+	w = do(t, r, http.MethodDelete, "/tasks/"+parent.ID+"?children=block", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"})
+	if w.Code != http.StatusConflict || decode[problem.Problem](t, w).Code != problem.CodeHasChildren {
+		t.Errorf("blocked delete: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodDelete, "/tasks/"+parent.ID+"?children=drop", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown children mode: status %d, want 400", w.Code)
+	}
+	if w := do(t, r, http.MethodDelete, "/tasks/"+parent.ID+"?children=cascade", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"}); w.Code != http.StatusOK {
+		t.Fatalf("cascade delete: status %d", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+child.ID, "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("child after cascade delete: status %d, want 404", w.Code)
+	}
+}
+
+func TestTasksTags(t *testing.T) {
+	r := newTestRouter(t)
+	w := do(t, r, http.MethodPost, "/tasks", "alice", map[string]any{
+		"title": "tagged", "content": "c", "due_date": "2025-06-01",
+		"request_timestamp": "2025-01-01T00:00:00Z", "tags": []string{" Work", "work", "urgent"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	if task := decode[model.Task](t, w); fmt.Sprint(task.Tags) != "[urgent work]" {
+		t.Errorf("tags = %v, want normalised [urgent work]", task.Tags)
+	}
+	if task := createTask(t, r, "alice", "untagged"); task.Tags == nil {
+		t.Errorf("untagged task has null tags")
+	}
+
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks?tag=WORK&tag=home", "alice", nil))
+	if len(page.Items) != 1 {
+		t.Errorf("any: %d tasks, want 1", len(page.Items))
+	}
+	page = decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks?tag=work&tag=home&tag_match=all", "alice", nil))
+	if len(page.Items) != 0 {
+		t.Errorf("all: %d tasks, want 0", len(page.Items))
+	}
+	if w := do(t, r, http.MethodGet, "/tasks?tag=a&tag_match=some", "alice", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad tag_match: status %d, want 400", w.Code)
+	}
+	if w := do(t, r, http.MethodPost, "/tasks", "alice", map[string]any{
+		"title": "x", "content": "c", "due_date": "2025-06-01",
+		"request_timestamp": "2025-01-01T00:00:00Z", "tags": []string{" "},
+	}); w.Code != http.StatusBadRequest {
+		t.Errorf("blank tag: status %d, want 400", w.Code)
+	}
+
+	tags := decode[model.TagList](t, do(t, r, http.MethodGet, "/tags", "alice", nil))
+	if fmt.Sprint(tags.Items) != "[{urgent 1} {work 1}]" {
+		t.Errorf("GET /tags = %v", tags.Items)
+	}
+	if tags := decode[model.TagList](t, do(t, r, http.MethodGet, "/tags", "bob", nil)); tags.Items == nil || len(tags.Items) != 0 {
+		t.Errorf("bob's tags = %v", tags.Items)
+	}
+}
+
+func TestTasksSearch(t *testing.T) {
+	r := newTestRouter(t)
+	createTask(t, r, "alice", "milk & <plan>")
+	createTask(t, r, "alice", "milk shake")
+	createTask(t, r, "alice", "bread")
+	createTask(t, r, "bob", "milk")
+
+	w := do(t, r, http.MethodGet, "/tasks/search?q=milk&limit=1", "alice", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search: status %d, body %s", w.Code, w.Body)
+	}
+	page := decode[model.SearchPage](t, w)
+	if len(page.Items) != 1 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	next := decode[model.SearchPage](t, do(t, r, http.MethodGet, "/tasks/search?q=milk&limit=1&cursor="+page.NextCursor, "alice", nil))
+	if len(next.Items) != 1 || next.NextCursor != "" || next.Items[0].ID == page.Items[0].ID {
+		t.Errorf("second page = %+v", next)
+	}
+
+	page = decode[model.SearchPage](t, do(t, r, http.MethodGet, `/tasks/search?q=%22milk+plan%22`, "alice", nil))
+	if len(page.Items) != 1 || page.Items[0].Snippet != "<mark>milk</mark> &amp; &lt;<mark>plan</mark>&gt;" {
+		t.Errorf("phrase = %+v", page.Items)
+	}
+	page = decode[model.SearchPage](t, do(t, r, http.MethodGet, "/tasks/search?q=sha*", "alice", nil))
+	if len(page.Items) != 1 || page.Items[0].Title != "milk shake" {
+		t.Errorf("prefix = %+v", page.Items)
+	}
+	if page := decode[model.SearchPage](t, do(t, r, http.MethodGet, "/tasks/search?q=zebra", "alice", nil)); page.Items == nil || len(page.Items) != 0 {
+		t.Errorf("no match = %+v", page.Items)
+	}
+
+	for _, path := range []string{
+		"/tasks/search",
+		"/tasks/search?q=%22milk",
+		"/tasks/search?q=bread&cursor=" + next.NextCursor + "x",
+	} {
+		if w := do(t, r, http.MethodGet, path, "alice", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestTasksStatusWorkflow(t *testing.T) {
+	r := newTestRouter(t)
+	task := createTask(t, r, "alice", "flow")
+	if task.Status != model.StatusTodo || task.Priority != model.PriorityMedium {
+		t.Fatalf("defaults = %s, %s", task.Status, task.Priority)
+	}
+
+	put := func(n int, body map[string]any) *httptest.ResponseRecorder {
+		body["request_timestamp"] = fmt.Sprintf("2025-01-01T00:00:%02dZ", n)
+		return do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", body)
+	}
+	if w := put(1, map[string]any{"status": "blocked", "priority": "urgent"}); w.Code != http.StatusOK {
+		t.Fatalf("to blocked: status %d, body %s", w.Code, w.Body)
+	}
+
+	// The default workflow requires unblocking first
+	w := put(2, map[string]any{"done": true})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("blocked to done: status %d, want 409", w.Code)
+	}
+	if p := decode[problem.Problem](t, w); p.Code != problem.CodeInvalidTransition || p.Field != "status" {
+		t.Errorf("problem = %+v", p)
+	}
+
+	for _, body := range []map[string]any{
+		{"status": "waiting"},
+		{"priority": "asap"},
+		{"status": "todo", "done": true},
+	} {
+		if w := put(3, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status %d, want 400", body, w.Code)
+		}
+	}
+
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks?status=blocked&priority=urgent", "alice", nil))
+	if len(page.Items) != 1 || page.Items[0].Done {
+		t.Errorf("filtered list = %+v", page.Items)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks?status=waiting", "alice", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad status filter: status %d, want 400", w.Code)
+	}
+}
+
+func TestTasksRecurrence(t *testing.T) {
+	r := newTestRouter(t)
+	w := do(t, r, http.MethodPost, "/tasks", "alice", map[string]any{
+		"title": "standup notes", "content": "c", "due_date": "2025-06-02",
+		"request_timestamp": "2025-01-01T00:00:00Z", "recurrence": "RRULE:freq=weekly;byday=mo,th",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	task := decode[model.Task](t, w)
+	if task.Recurrence != "FREQ=WEEKLY;BYDAY=MO,TH" {
+		t.Errorf("recurrence = %q, want the canonical rule", task.Recurrence)
+	}
+
+	list := decode[model.OccurrenceList](t, do(t, r, http.MethodGet, "/tasks/"+task.ID+"/occurrences?limit=3", "alice", nil))
+	if fmt.Sprint(list.Items) != "[2025-06-05 2025-06-09 2025-06-12]" {
+		t.Errorf("occurrences = %v", list.Items)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/"+task.ID+"/occurrences", "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("bob's preview: status %d, want 404", w.Code)
+	}
+
+	for _, rule := range []string{"FREQ=HOURLY", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;COUNT=2;UNTIL=20250101", "BYDAY=MO"} {
+		w := do(t, r, http.MethodPut, "/tasks/"+task.ID, "alice", map[string]any{"recurrence": rule, "request_timestamp": "2025-01-01T00:00:01Z"})
+		if w.Code != http.StatusBadRequest || decode[problem.Problem](t, w).Field != "recurrence" {
+			t.Errorf("%s: status %d, body %s", rule, w.Code, w.Body)
+		}
+	}
+
+	w = do(t, r, http.MethodDelete, "/tasks/"+task.ID+"/recurrence", "alice", map[string]string{"request_timestamp": "2025-01-01T00:00:02Z"})
+	if w.Code != http.StatusOK || decode[model.Task](t, w).Recurrence != "" {
+		t.Fatalf("stop: status %d, body %s", w.Code, w.Body)
+	}
+	list = decode[model.OccurrenceList](t, do(t, r, http.MethodGet, "/tasks/"+task.ID+"/occurrences", "alice", nil))
+	if list.Items == nil || len(list.Items) != 0 {
+		t.Errorf("stopped series occurrences = %v", list.Items)
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+const (
+	MaxImportLines = 10000
+	MaxImportBytes = 32 << 20
+	// maxImportLine caps an NDJSON line
+	maxImportLine = 1 << 20
+)
+
+// csvColumns are the columns of a CSV export, in order. An import only needs
+// the CreateTaskRequest ones, in any order, and ignores unknown columns.
+var csvColumns = []string{"id", "parent_id", "title", "content", "due_date", "status", "priority", "tags", "recurrence", "request_timestamp", "created_at", "updated_at"}
+
+// ImportReport sums up POST /tasks/import.
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Lines   int               `json:"lines"`   // tasks read, the CSV header excluded
+	Valid   int               `json:"valid"`   // tasks passing the POST /tasks validation
+	Created int               `json:"created"` // tasks created, 0 on a dry run
+	Errors  []ImportLineError `json:"errors"`
+}
+
+// ImportLineError is why the task of a line was not imported.
+type ImportLineError struct {
+	Line   int             `json:"line"` // in the file, from 1
+	Status int             `json:"status"`
+	Error  problem.Problem `json:"error"`
+}
+
+// Export streams the live tasks of GET /tasks, oldest first, filtered the
+// same way. Tasks are read a page at a time: a failure past the first page
+// truncates the file.
+func (h *TasksHandler) Export(c *gin.Context) {
+	format, params, err := service.ParseExportParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if params.Shared, err = h.sharedIDs(c); err != nil {
+		writeError(c, err)
+		return
+	}
+	owner := h.policy.own(c).OwnerID
+	page := func() ([]model.Task, *model.TaskCursor, error) {
+		ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+		defer cancel()
+		return h.repo.List(ctx, owner, params)
+	}
+
+	// The first page reports errors before anything is sent
+	tasks, next, err := page()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	var w exportWriter
+	switch format {
+	case model.FormatCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w = newCSVExport(c.Writer)
+	default:
+		c.Header("Content-Type", "application/x-ndjson")
+		w = ndjsonExport{json.NewEncoder(c.Writer)}
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+	c.Status(http.StatusOK)
+
+	for {
+		for _, t := range tasks {
+			if err := w.Write(model.NewTaskRecord(t)); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+		c.Writer.Flush()
+		if next == nil {
+			return
+		}
+		params.After = next
+		if tasks, next, err = page(); err != nil {
+			streamFailed(c, err)
+			return
+		}
+	}
+}
+
+// Import creates a task for each line of a CSV or NDJSON body, as exported,
+// validating it like POST /tasks. The lines are independent: the report lists
+// the ones that failed. With dry_run=true nothing is created. parent_id may
+// name a task of the file listed before its subtasks.
+func (h *TasksHandler) Import(c *gin.Context) {
+	format, dryRun, err := service.ParseImportParams(c.Request.URL.Query(), c.ContentType())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBytes)
+	var records importReader
+	switch format {
+	case model.FormatCSV:
+		records, err = newCSVImport(body)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+	default:
+		records = newNDJSONImport(body)
+	}
+
+	report := ImportReport{DryRun: dryRun, Errors: []ImportLineError{}}
+	created := make(map[string]string) // ids of the file to ids of the imported tasks
+	for c.Request.Context().Err() == nil {
+		line, rec, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var stop *importStop
+		if errors.As(err, &stop) {
+			report.addError(c, line, stop.err)
+			break
+		}
+		if report.Lines == MaxImportLines {
+			report.addError(c, line, &service.ValidationError{Field: "body", Message: fmt.Sprintf("an import holds at most %d tasks", MaxImportLines)})
+			break
+		}
+		report.Lines++
+		if err != nil {
+			report.addError(c, line, err)
+			continue
+		}
+
+		req := rec.CreateRequest()
+		if id, ok := created[req.ParentID]; ok {
+			req.ParentID = id
+		}
+		in, err := importTask(req)
+		if err != nil {
+			report.addError(c, line, err)
+			continue
+		}
+
+		ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+		a, err := h.policy.create(ctx, c, in)
+		if err != nil {
+			cancel()
+			report.addError(c, line, err)
+			continue
+		}
+		report.Valid++
+		if dryRun {
+			cancel()
+			continue
+		}
+		t, err := h.repo.Create(ctx, a.OwnerID, in)
+		cancel()
+		if err != nil {
+			report.addError(c, line, err)
+			continue
+		}
+		report.Created++
+		if rec.ID != "" {
+			created[rec.ID] = t.ID
+		}
+	}
+	if c.Request.Context().Err() != nil {
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// importTask validates a line like the body of POST /tasks.
+func importTask(req model.CreateTaskRequest) (store.NewTask, error) {
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return store.NewTask{}, err
+	}
+	return newTask(req)
+}
+
+func (r *ImportReport) addError(c *gin.Context, line int, err error) {
+	status, p := batchProblem(c, err)
+	r.Errors = append(r.Errors, ImportLineError{Line: line, Status: status, Error: p})
+}
+
+type exportWriter interface {
+	Write(model.TaskRecord) error
+	Flush() error
+}
+
+type csvExport struct {
+	w *csv.Writer
+}
+
+func newCSVExport(w io.Writer) csvExport {
+	e := csvExport{csv.NewWriter(w)}
+	_ = e.w.Write(csvColumns)
+	return e
+}
+
+func (e csvExport) Write(r model.TaskRecord) error {
+	return e.w.Write([]string{
+		r.ID, r.ParentID, r.Title, r.Content, r.DueDate, r.Status, r.Priority,
+		strings.Join(r.Tags, ","), r.Recurrence, r.RequestTimestamp,
+		r.CreatedAt.Format(time.RFC3339Nano), r.UpdatedAt.Format(time.RFC3339Nano),
+	})
+}
+
+func (e csvExport) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExport struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonExport) Write(r model.TaskRecord) error { return e.enc.Encode(r) }
+func (e ndjsonExport) Flush() error                   { return nil }
+
+// importReader reads the tasks of an import one line at a time. An error
+// other than io.EOF and *importStop is about that line only.
+type importReader interface {
+	Read() (line int, rec model.TaskRecord, err error)
+}
+
+// importStop is an error ending the import at a line.
+type importStop struct {
+	err error
+}
+
+func (e *importStop) Error() string { return e.err.Error() }
+
+// bodyError describes the failures to read the body itself.
+func bodyError(err error) error {
+	var (
+		tooLarge *http.MaxBytesError
+		perr     *csv.ParseError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return &service.ValidationError{Field: "body", Message: fmt.Sprintf("an import is at most %d MiB", MaxImportBytes>>20)}
+	case errors.Is(err, bufio.ErrTooLong):
+		return &service.ValidationError{Field: "body", Message: fmt.Sprintf("a line is at most %d MiB", maxImportLine>>20)}
+	case errors.As(err, &perr):
+		return &service.ValidationError{Field: "body", Message: perr.Err.Error()}
+	}
+	return err
+}
+
+type csvImport struct {
+	r       *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+// newCSVImport reads the header row, which must name the required columns.
+func newCSVImport(body io.Reader) (*csvImport, error) {
+	r := csv.NewReader(body)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &service.ValidationError{Field: "body", Message: "a CSV import starts with a header row"}
+	}
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	im := &csvImport{r: r, columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) // spreadsheet BOM
+		if _, dup := im.columns[name]; !dup {
+			im.columns[name] = i
+		}
+	}
+	for _, name := range []string{"title", "content", "due_date", "request_timestamp"} {
+		if _, ok := im.columns[name]; !ok {
+			return nil, &service.ValidationError{Field: "body", Message: "the CSV header has no " + name + " column"}
+		}
+	}
+	return im, nil
+}
+
+func (im *csvImport) Read() (int, model.TaskRecord, error) {
+	fields, err := im.r.Read()
+	var perr *csv.ParseError
+	switch {
+	case errors.Is(err, io.EOF):
+		return 0, model.TaskRecord{}, err
+	case errors.As(err, &perr):
+		// Reading goes on with the next row
+		im.line = perr.StartLine
+		return perr.StartLine, model.TaskRecord{}, bodyError(err)
+	case err != nil:
+		return im.line + 1, model.TaskRecord{}, &importStop{bodyError(err)}
+	}
+	im.line, _ = im.r.FieldPos(0)
+
+	get := func(name string) string {
+		if i, ok := im.columns[name]; ok {
+			return fields[i]
+		}
+		return ""
+	}
+	rec := model.TaskRecord{
+		ID:               get("id"),
+		ParentID:         get("parent_id"),
+		Title:            get("title"),
+		Content:          get("content"),
+		DueDate:          get("due_date"),
+		Status:           get("status"),
+		Priority:         get("priority"),
+		Recurrence:       get("recurrence"),
+		RequestTimestamp: get("request_timestamp"),
+	}
+	if tags := get("tags"); tags != "" {
+		rec.Tags = strings.Split(tags, ",")
+	}
+	return im.line, rec, nil
+}
+
+type ndjsonImport struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newNDJSONImport(body io.Reader) *ndjsonImport {
+	s := bufio.NewScanner(body)
+	s.Buffer(make([]byte, 0, 64<<10), maxImportLine)
+	return &ndjsonImport{s: s}
+}
+
+func (im *ndjsonImport) Read() (int, model.TaskRecord, error) {
+	for im.s.Scan() {
+		im.line++
+		b := bytes.TrimSpace(im.s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var rec model.TaskRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				err = &service.ValidationError{Field: "body", Message: "a line must be a JSON object"}
+			}
+			return im.line, rec, err
+		}
+		return im.line, rec, nil
+	}
+	if err := im.s.Err(); err != nil {
+		return im.line + 1, model.TaskRecord{}, &importStop{bodyError(err)}
+	}
+	return im.line, model.TaskRecord{}, io.EOF
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"team5/task-manager/internal/model"
+)
+
+func doRaw(t *testing.T, r http.Handler, method, path, sub, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token(t, sub))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// createFamily creates a tagged parent then its subtask for sub.
+func createFamily(t *testing.T, r http.Handler, sub string) (parent, child model.Task) {
+	t.Helper()
+	parent = createTask(t, r, sub, "parent")
+	w := do(t, r, http.MethodPost, "/tasks", sub, map[string]any{
+		"title":             "child, with \"quotes\"",
+		"content":           "line 1\nline 2",
+		"due_date":          "2025-06-02",
+		"request_timestamp": "2025-01-01T00:00:00Z",
+		"parent_id":         parent.ID,
+		"tags":              []string{"b", "a"},
+		"priority":          model.PriorityHigh,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create child: status %d, body %s", w.Code, w.Body)
+	}
+	return parent, decode[model.Task](t, w)
+}
+
+// assertImported checks that sub got a copy of parent and child, still linked.
+func assertImported(t *testing.T, r http.Handler, sub string, parent, child model.Task) {
+	t.Helper()
+	page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks?sort=created_at&order=asc", sub, nil))
+	if len(page.Items) != 2 {
+		t.Fatalf("%s has %d tasks, want 2", sub, len(page.Items))
+	}
+	p, c := page.Items[0], page.Items[1]
+	if p.ID == parent.ID || p.Title != parent.Title || p.DueDate != parent.DueDate {
+		t.Errorf("imported parent %+v from %+v", p, parent)
+	}
+	if c.Title != child.Title || c.Content != child.Content || c.Priority != child.Priority || !slices.Equal(c.Tags, child.Tags) {
+		t.Errorf("imported child %+v from %+v", c, child)
+	}
+	if c.ParentID == nil || *c.ParentID != p.ID {
+		t.Errorf("imported child parent_id = %v, want the imported parent %s", c.ParentID, p.ID)
+	}
+}
+
+func TestExportImportCSV(t *testing.T) {
+	r := newTestRouter(t)
+	parent, child := createFamily(t, r, "alice")
+
+	w := do(t, r, http.MethodGet, "/tasks/export?format=csv", "alice", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("export: status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	file := w.Body.String()
+	rows, err := csv.NewReader(strings.NewReader(file)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !slices.Equal(rows[0], csvColumns) || rows[1][0] != parent.ID || rows[2][1] != parent.ID || rows[2][7] != "a,b" {
+		t.Fatalf("exported %q", rows)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/export?format=csv", "bob", nil); strings.Count(w.Body.String(), "\n") != 1 {
+		t.Errorf("bob exports %q", w.Body)
+	}
+
+	w = doRaw(t, r, http.MethodPost, "/tasks/import", "bob", "text/csv", file)
+	if report := decode[ImportReport](t, w); w.Code != http.StatusOK || report.Lines != 2 || report.Created != 2 || len(report.Errors) != 0 {
+		t.Fatalf("import: status %d, body %s", w.Code, w.Body)
+	}
+	assertImported(t, r, "bob", parent, child)
+}
+
+func TestExportImportNDJSON(t *testing.T) {
+	r := newTestRouter(t)
+	parent, child := createFamily(t, r, "alice")
+
+	w := do(t, r, http.MethodGet, "/tasks/export?format=ndjson&tag=a", "alice", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("export: status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var rec model.TaskRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &rec); err != nil || rec.ID != child.ID || rec.ParentID != parent.ID {
+		t.Fatalf("filtered export %s: %v", w.Body, err)
+	}
+
+	file := do(t, r, http.MethodGet, "/tasks/export?format=ndjson", "alice", nil).Body.String()
+	w = doRaw(t, r, http.MethodPost, "/tasks/import?format=ndjson", "bob", "application/octet-stream", file)
+	if report := decode[ImportReport](t, w); w.Code != http.StatusOK || report.Created != 2 || len(report.Errors) != 0 {
+		t.Fatalf("import: status %d, body %s", w.Code, w.Body)
+	}
+	assertImported(t, r, "bob", parent, child)
+}
+
+func TestImportReportsLineErrors(t *testing.T) {
+	r := newTestRouter(t)
+	body := strings.Join([]string{
+		`{"title":"ok","content":"c","due_date":"2025-06-01","request_timestamp":"2025-01-01T00:00:00Z"}`,
+		``,
+		`not json`,
+		`{"content":"c","due_date":"2025-06-01","request_timestamp":"2025-01-01T00:00:00Z"}`,
+		`{"title":"t","content":"c","due_date":"06/01/2025","request_timestamp":"2025-01-01T00:00:00Z"}`,
+		`{"title":"t","content":"c","due_date":"2025-06-01","request_timestamp":"2025-01-01T00:00:00Z","status":"nope"}`,
+	}, "\n")
+
+	w := doRaw(t, r, http.MethodPost, "/tasks/import?dry_run=true", "alice", "application/x-ndjson", body)
+	report := decode[ImportReport](t, w)
+	if w.Code != http.StatusOK || !report.DryRun || report.Lines != 5 || report.Valid != 1 || report.Created != 0 {
+		t.Fatalf("dry run: status %d, body %s", w.Code, w.Body)
+	}
+	want := []struct {
+		line  int
+		field string
+	}{{3, "body"}, {4, "title"}, {5, "due_date"}, {6, "status"}}
+	if len(report.Errors) != len(want) {
+		t.Fatalf("errors %+v", report.Errors)
+	}
+	for i, e := range report.Errors {
+		if e.Line != want[i].line || e.Status != http.StatusBadRequest || e.Error.Field != want[i].field {
+			t.Errorf("error %d = %+v, want line %d on %s", i, e, want[i].line, want[i].field)
+		}
+	}
+	if page := decode[model.TaskPage](t, do(t, r, http.MethodGet, "/tasks", "alice", nil)); len(page.Items) != 0 {
+		t.Fatalf("dry run created %+v", page.Items)
+	}
+
+	w = doRaw(t, r, http.MethodPost, "/tasks/import", "alice", "application/x-ndjson", body)
+	if report := decode[ImportReport](t, w); report.Valid != 1 || report.Created != 1 || len(report.Errors) != 4 {
+		t.Fatalf("import: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	r := newTestRouter(t)
+	file := "title,content,due_date,request_timestamp,parent_id\n" +
+		"a,c,2025-06-01,2025-01-01T00:00:00Z,\n" +
+		"too,few\n" +
+		"b,c,2025-06-01,2025-01-01T00:00:00Z,00000000-0000-0000-0000-000000000000\n"
+	w := doRaw(t, r, http.MethodPost, "/tasks/import", "alice", "text/csv", file)
+	report := decode[ImportReport](t, w)
+	if w.Code != http.StatusOK || report.Lines != 3 || report.Created != 1 || len(report.Errors) != 2 {
+		t.Fatalf("import: status %d, body %s", w.Code, w.Body)
+	}
+	if e := report.Errors[0]; e.Line != 3 || e.Error.Field != "body" {
+		t.Errorf("short row error %+v", e)
+	}
+	if e := report.Errors[1]; e.Line != 4 || e.Error.Field != "parent_id" {
+		t.Errorf("unknown parent error %+v", e)
+	}
+
+	if w := doRaw(t, r, http.MethodPost, "/tasks/import", "alice", "text/csv", "title,content\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("missing columns: status %d", w.Code)
+	}
+	if w := doRaw(t, r, http.MethodPost, "/tasks/import", "alice", "text/plain", file); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/tasks/export", "alice", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("export without format: status %d", w.Code)
+	}
+}
+
+func TestExportStreamsAllPages(t *testing.T) {
+	r := newTestRouter(t)
+	const n = 450 // more than two export pages
+	var ops []map[string]any
+	for range n {
+		ops = append(ops, map[string]any{"op": "create", "title": "t", "content": "c", "due_date": "2025-06-01", "request_timestamp": "2025-01-01T00:00:00Z"})
+	}
+	if w := do(t, r, http.MethodPost, "/tasks:batch", "alice", map[string]any{"operations": ops}); w.Code != http.StatusOK {
+		t.Fatalf("batch: status %d", w.Code)
+	}
+
+	w := do(t, r, http.MethodGet, "/tasks/export?format=ndjson", "alice", nil)
+	seen := make(map[string]bool)
+	for s := bufio.NewScanner(w.Body); s.Scan(); {
+		var rec model.TaskRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		seen[rec.ID] = true
+	}
+	if len(seen) != n {
+		t.Errorf("exported %d distinct tasks, want %d", len(seen), n)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+	"team5/task-manager/internal/store"
+)
+
+// WebhooksHandler serves the caller's webhook subscriptions and their delivery log.
+type WebhooksHandler struct {
+	repo store.WebhookRepository
+}
+
+func NewWebhooksHandler(repo store.WebhookRepository) *WebhooksHandler {
+	return &WebhooksHandler{repo: repo}
+}
+
+// Create returns the subscription with its secret, the only time it is shown.
+func (h *WebhooksHandler) Create(c *gin.Context) {
+	var req model.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := service.CheckWebhookURL("url", req.URL); err != nil {
+		writeError(c, err)
+		return
+	}
+	events, err := service.NormalizeEvents(req.Events)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	secret := req.Secret
+	if secret == "" {
+		secret = newWebhookSecret()
+	} else if err := service.CheckWebhookSecret(secret); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	w, err := h.repo.CreateWebhook(ctx, middleware.Subject(c), model.WebhookSubscription{URL: req.URL, Events: events, Secret: secret})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+func (h *WebhooksHandler) List(c *gin.Context) {
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	items, err := h.repo.ListWebhooks(ctx, middleware.Subject(c))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.WebhookList{Items: items})
+}
+
+func (h *WebhooksHandler) Get(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	w, err := h.repo.GetWebhook(ctx, middleware.Subject(c), id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// Update changes the fields sent. "active": false pauses the subscription:
+// events meanwhile are not sent to it, pending deliveries resume once it is
+// active again.
+func (h *WebhooksHandler) Update(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var req model.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	if req.URL != nil {
+		if err := service.CheckWebhookURL("url", *req.URL); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+	if req.Events != nil {
+		events, err := service.NormalizeEvents(*req.Events)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		req.Events = &events
+	}
+	if req.Secret != nil {
+		if err := service.CheckWebhookSecret(*req.Secret); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	w, err := h.repo.UpdateWebhook(ctx, middleware.Subject(c), id, req)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+func (h *WebhooksHandler) Delete(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	if err := h.repo.DeleteWebhook(ctx, middleware.Subject(c), id); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Attempts pages through the delivery log of a subscription, oldest first.
+// Deliveries given up on end with a "dead" attempt.
+func (h *WebhooksHandler) Attempts(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	params, err := service.ParseHistoryParams(c.Request.URL.Query())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 800*time.Millisecond)
+	defer cancel()
+
+	items, next, err := h.repo.Attempts(ctx, middleware.Subject(c), id, params)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	page := model.WebhookAttemptPage{Items: items}
+	if next != 0 {
+		page.NextCursor = service.EncodeHistoryCursor(next)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// webhookID reads the :id parameter; an id that cannot exist is a 404.
+func webhookID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		writeWebhookError(c, store.ErrNotFound)
+		return "", false
+	}
+	return id, true
+}
+
+// writeWebhookError is writeError with the 404 naming the webhook.
+func writeWebhookError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeNotFound, "", "webhook not found")
+		return
+	}
+	writeError(c, err)
+}
+
+// newWebhookSecret returns a random 256-bit signing key.
+func newWebhookSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
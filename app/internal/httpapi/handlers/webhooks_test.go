@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/model"
+)
+
+func TestWebhooksCRUD(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := do(t, r, http.MethodPost, "/webhooks", "alice", map[string]any{"url": "https://example.com/hook", "events": []string{"task.deleted", "task.created", "task.deleted"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body)
+	}
+	hook := decode[model.WebhookSubscription](t, w)
+	if len(hook.Secret) != 64 || !hook.Active || len(hook.Events) != 2 || hook.Events[0] != model.EventTaskCreated {
+		t.Fatalf("created %+v", hook)
+	}
+
+	w = do(t, r, http.MethodGet, "/webhooks/"+hook.ID, "alice", nil)
+	if got := decode[model.WebhookSubscription](t, w); w.Code != http.StatusOK || got.Secret != "" || got.URL != hook.URL {
+		t.Errorf("get: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/webhooks/"+hook.ID, "bob", nil); w.Code != http.StatusNotFound || decode[problem.Problem](t, w).Detail != "webhook not found" {
+		t.Errorf("get by another caller: status %d, body %s", w.Code, w.Body)
+	}
+	if w := do(t, r, http.MethodGet, "/webhooks/nope", "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get malformed id: status %d", w.Code)
+	}
+	if list := decode[model.WebhookList](t, do(t, r, http.MethodGet, "/webhooks", "bob", nil)); len(list.Items) != 0 {
+		t.Errorf("bob sees %+v", list.Items)
+	}
+
+	w = do(t, r, http.MethodPut, "/webhooks/"+hook.ID, "alice", map[string]any{"active": false, "events": []string{}})
+	if got := decode[model.WebhookSubscription](t, w); w.Code != http.StatusOK || got.Active || len(got.Events) != 0 {
+		t.Errorf("update: status %d, body %s", w.Code, w.Body)
+	}
+
+	createTask(t, r, "alice", "first")
+	w = do(t, r, http.MethodGet, "/webhooks/"+hook.ID+"/attempts", "alice", nil)
+	if page := decode[model.WebhookAttemptPage](t, w); w.Code != http.StatusOK || page.Items == nil || len(page.Items) != 0 {
+		t.Errorf("attempts: status %d, body %s", w.Code, w.Body)
+	}
+
+	if w := do(t, r, http.MethodDelete, "/webhooks/"+hook.ID, "bob", nil); w.Code != http.StatusNotFound {
+		t.Errorf("delete by another caller: status %d", w.Code)
+	}
+	if w := do(t, r, http.MethodDelete, "/webhooks/"+hook.ID, "alice", nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete: status %d", w.Code)
+	}
+	if w := do(t, r, http.MethodGet, "/webhooks/"+hook.ID+"/attempts", "alice", nil); w.Code != http.StatusNotFound {
+		t.Errorf("attempts after delete: status %d", w.Code)
+	}
+}
+
+func TestWebhooksValidation(t *testing.T) {
+	r := newTestRouter(t)
+	for name, tc := range map[string]struct {
+		body  map[string]any
+		field string
+	}{
+		"missing url":  {map[string]any{}, "url"},
+		"relative url": {map[string]any{"url": "/hook"}, "url"},
+		"ftp url":      {map[string]any{"url": "ftp://example.com"}, "url"},
+		"http url":     {map[string]any{"url": "http://example.com/hook"}, "url"},
+		"bad event":    {map[string]any{"url": "https://example.com", "events": []string{"task.exploded"}}, "events"},
+		"short secret": {map[string]any{"url": "https://example.com", "secret": "short"}, "secret"},
+	} {
+		w := do(t, r, http.MethodPost, "/webhooks", "alice", tc.body)
+		if p := decode[problem.Problem](t, w); w.Code != http.StatusBadRequest || p.Field != tc.field {
+			t.Errorf("%s: status %d, body %s", name, w.Code, w.Body)
+		}
+	}
+
+	w := do(t, r, http.MethodPost, "/webhooks", "alice", map[string]any{"url": "https://example.com", "secret": "0123456789abcdef"})
+	if hook := decode[model.WebhookSubscription](t, w); w.Code != http.StatusCreated || hook.Secret != "0123456789abcdef" {
+		t.Errorf("create with secret: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -1,43 +1,139 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/store"
 )
 
-func AuthJWT(secret string) gin.HandlerFunc {
+// subjectKey is the gin context key holding the authenticated caller (JWT "sub").
+const subjectKey = "sub"
+
+// KeySource finds the public key verifying an RS256 or ES256 token by the
+// kid of its header (see jwks.KeySet).
+type KeySource interface {
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// AuthOptions configures AuthJWT.
+type AuthOptions struct {
+	// TenantClaim names the claim holding the caller's tenant. Default:
+	// tenant_id. Tokens without it belong to store.DefaultTenant.
+	TenantClaim string
+	// Keys verifies RS256 and ES256 tokens; without it only HS256 tokens
+	// signed with the secret are accepted.
+	Keys KeySource
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// ClockSkew is tolerated when checking exp, nbf and iat.
+	ClockSkew time.Duration
+	// DefaultScopes are granted to tokens without a scope or scp claim.
+	DefaultScopes []string
+}
+
+// AuthJWT authenticates the bearer token of the request: HS256 with secret
+// (unless empty), RS256 and ES256 with opts.Keys.
+func AuthJWT(secret string, opts AuthOptions) gin.HandlerFunc {
+	if opts.TenantClaim == "" {
+		opts.TenantClaim = "tenant_id"
+	}
+	methods := []string{}
+	if secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if opts.Keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	parserOpts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(opts.ClockSkew), jwt.WithIssuedAt()}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if !strings.HasPrefix(h, "Bearer ") {
-			c.AbortWithStatus(http.StatusUnauthorized)
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "missing bearer token")
 			return
 		}
 		tok := strings.TrimPrefix(h, "Bearer ")
 
 		parsed, err := jwt.Parse(tok, func(t *jwt.Token) (interface{}, error) {
-			if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-				return nil, jwt.ErrSignatureInvalid
+			// WithValidMethods has already refused the algorithms not configured
+			if t.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+				return []byte(secret), nil
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || parsed == nil || !parsed.Valid {
-			c.AbortWithStatus(http.StatusUnauthorized)
+			kid, _ := t.Header["kid"].(string)
+			return opts.Keys.Key(c.Request.Context(), kid, t.Method.Alg())
+		}, parserOpts...)
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "token expired")
+			return
+		case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "token not valid yet")
+			return
+		case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid token issuer")
+			return
+		case errors.Is(err, jwt.ErrTokenInvalidAudience):
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid token audience")
+			return
+		case err != nil || parsed == nil || !parsed.Valid:
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid token")
 			return
 		}
 
-		if claims, ok := parsed.Claims.(jwt.MapClaims); ok {
-			if exp, ok := claims["exp"].(float64); ok {
-				if time.Now().Unix() >= int64(exp) {
-					c.AbortWithStatus(http.StatusUnauthorized)
-					return
-				}
+		claims, ok := parsed.Claims.(jwt.MapClaims)
+		if !ok {
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid token")
+			return
+		}
+
+		// Tasks are owned by the token subject: no sub, no access
+		sub, err := claims.GetSubject()
+		if err != nil || sub == "" {
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "token has no sub claim")
+			return
+		}
+		tenant := store.DefaultTenant
+		if v, ok := claims[opts.TenantClaim]; ok {
+			if tenant, ok = v.(string); !ok || tenant == "" || len(tenant) > 255 {
+				problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid tenant claim")
+				return
 			}
 		}
+		scopes, ok := tokenScopes(claims, opts.DefaultScopes)
+		if !ok {
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "", "invalid scope claim")
+			return
+		}
+		c.Set(subjectKey, sub)
+		c.Set(scopesKey, scopes)
+		// The stores attribute history entries to the actor carried by the
+		// request context, and only see the rows of its tenant
+		ctx := store.WithActor(c.Request.Context(), store.Actor{
+			ID:            sub,
+			CorrelationID: c.GetString(correlationHeader),
+		})
+		c.Request = c.Request.WithContext(store.WithTenant(ctx, tenant))
 
 		c.Next()
 	}
 }
+
+// Subject returns the authenticated caller set by AuthJWT.
+func Subject(c *gin.Context) string {
+	return c.GetString(subjectKey)
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"team5/task-manager/internal/jwks"
+	"team5/task-manager/internal/store"
+)
+
+const authSecret = "auth-test-secret"
+
+// newAuthRouter serves GET /whoami, answering the subject and tenant the
+// request context carries.
+func newAuthRouter(secret string, opts AuthOptions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthJWT(secret, opts))
+	r.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"sub": Subject(c), "tenant": store.TenantFrom(c.Request.Context())})
+	})
+	return r
+}
+
+func signed(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(authSecret))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func whoami(r http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthJWTTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   AuthOptions
+		claims jwt.MapClaims
+		status int
+		want   string
+	}{
+		{"claim", AuthOptions{}, jwt.MapClaims{"sub": "alice", "tenant_id": "acme"}, http.StatusOK, `{"sub":"alice","tenant":"acme"}`},
+		{"no claim", AuthOptions{}, jwt.MapClaims{"sub": "alice"}, http.StatusOK, `{"sub":"alice","tenant":"default"}`},
+		{"configured claim", AuthOptions{TenantClaim: "org"}, jwt.MapClaims{"sub": "alice", "org": "acme", "tenant_id": "other"}, http.StatusOK, `{"sub":"alice","tenant":"acme"}`},
+		{"empty", AuthOptions{}, jwt.MapClaims{"sub": "alice", "tenant_id": ""}, http.StatusUnauthorized, ""},
+		{"not a string", AuthOptions{}, jwt.MapClaims{"sub": "alice", "tenant_id": 42}, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := whoami(newAuthRouter(authSecret, tt.opts), signed(t, tt.claims))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want != "" && w.Body.String() != tt.want {
+				t.Errorf("body %s, want %s", w.Body, tt.want)
+			}
+		})
+	}
+}
+
+// issuer signs tokens with its RSA and EC keys, published as a JWKS by an
+// httptest server.
+type issuer struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	keys *jwks.KeySet
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}))
+	t.Cleanup(srv.Close)
+
+	keys := jwks.New(srv.URL, time.Second)
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	return &issuer{rsa: rsaKey, ec: ecKey, keys: keys}
+}
+
+func (i *issuer) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	var key crypto.Signer = i.rsa
+	if method == jwt.SigningMethodES256 {
+		key = i.ec
+	}
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestAuthJWTKeySet(t *testing.T) {
+	iss := newIssuer(t)
+	r := newAuthRouter("", AuthOptions{Keys: iss.keys, Issuer: "https://issuer.test", Audience: "tasks", ClockSkew: time.Minute})
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "alice", "iss": "https://issuer.test", "aud": []string{"other", "tasks"}, "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(k string, v any) jwt.MapClaims {
+		c := valid()
+		c[k] = v
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"RS256", iss.sign(t, jwt.SigningMethodRS256, "rsa-1", valid()), http.StatusOK},
+		{"ES256", iss.sign(t, jwt.SigningMethodES256, "ec-1", valid()), http.StatusOK},
+		{"nbf within the skew", iss.sign(t, jwt.SigningMethodES256, "ec-1", with("nbf", now.Add(30*time.Second).Unix())), http.StatusOK},
+		{"expired within the skew", iss.sign(t, jwt.SigningMethodES256, "ec-1", with("exp", now.Add(-30*time.Second).Unix())), http.StatusOK},
+		{"nbf beyond the skew", iss.sign(t, jwt.SigningMethodES256, "ec-1", with("nbf", now.Add(2*time.Minute).Unix())), http.StatusUnauthorized},
+		{"expired beyond the skew", iss.sign(t, jwt.SigningMethodRS256, "rsa-1", with("exp", now.Add(-2*time.Minute).Unix())), http.StatusUnauthorized},
+		{"wrong issuer", iss.sign(t, jwt.SigningMethodRS256, "rsa-1", with("iss", "https://evil.test")), http.StatusUnauthorized},
+		{"wrong audience", iss.sign(t, jwt.SigningMethodRS256, "rsa-1", with("aud", "billing")), http.StatusUnauthorized},
+		{"unknown kid", iss.sign(t, jwt.SigningMethodRS256, "rsa-2", valid()), http.StatusUnauthorized},
+		{"kid of another algorithm", iss.sign(t, jwt.SigningMethodRS256, "ec-1", valid()), http.StatusUnauthorized},
+		{"HS256 without a secret", signed(t, valid()), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := whoami(r, tt.token); w.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+
+	// A token signed by another key with a known kid
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, valid())
+	forged.Header["kid"] = "rsa-1"
+	s, _ := forged.SignedString(other)
+	if w := whoami(r, s); w.Code != http.StatusUnauthorized {
+		t.Errorf("forged token: status %d", w.Code)
+	}
+}
+
+func TestAuthJWTScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthJWT(authSecret, AuthOptions{DefaultScopes: []string{ScopeTasksRead}}))
+	r.GET("/whoami", RequireScope(ScopeTasksWrite), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		status int
+	}{
+		{"scope", jwt.MapClaims{"sub": "alice", "scope": "tasks:read tasks:write"}, http.StatusNoContent},
+		{"scp list", jwt.MapClaims{"sub": "alice", "scp": []string{"tasks:write"}}, http.StatusNoContent},
+		{"scp string", jwt.MapClaims{"sub": "alice", "scp": "tasks:write"}, http.StatusNoContent},
+		{"admin", jwt.MapClaims{"sub": "alice", "scope": "tasks:admin"}, http.StatusNoContent},
+		{"scope wins over scp", jwt.MapClaims{"sub": "alice", "scope": "tasks:read", "scp": "tasks:write"}, http.StatusForbidden},
+		{"defaults", jwt.MapClaims{"sub": "alice"}, http.StatusForbidden},
+		{"malformed", jwt.MapClaims{"sub": "alice", "scp": []any{"tasks:write", 1}}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := whoami(r, signed(t, tt.claims)); w.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
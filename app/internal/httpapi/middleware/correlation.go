@@ -20,8 +20,8 @@ func CorrelationID() gin.HandlerFunc {
 		c.Set(correlationHeader, cid)
 		c.Writer.Header().Set(correlationHeader, cid)
 
-		// Create logger with correlation_id for this request
-		log := logger.WithCorrelationID(cid)
+		// Create logger with correlation_id (and trace ids, otelgin runs first) for this request
+		log := logger.WithTrace(c.Request.Context(), logger.WithCorrelationID(cid))
 		c.Set("logger", log)
 
 		// Log request start
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/problem"
+)
+
+// CustomMethod guards a route registered as "/collection:name" (custom method
+// style). Gin has no literal ':' in paths, so such a route is a parameter
+// matching any "/collection<suffix>"; anything but ":name" is a 404.
+func CustomMethod(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param(name) != ":"+name {
+			problem.Abort(c, http.StatusNotFound, problem.CodeNotFound, "", "route not found")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/store"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	maxIdempotencyKey = 255
+)
+
+// Idempotency makes a handler safe to retry: the first successful (2xx)
+// response for an Idempotency-Key is stored for ttl and replayed verbatim to
+// later requests with the same key and payload. Reusing a key with a
+// different payload is rejected with 422, and a replay racing the original
+// request gets 409. Requests without the header pass through untouched.
+// Must run after AuthJWT, keys are scoped to the caller.
+func Idempotency(repo store.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeValidationFailed, idempotencyHeader, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidRequest, "", "cannot read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		owner := Subject(c)
+		hash := requestHash(c.Request.Method, c.FullPath(), body)
+
+		rec, reserved, err := repo.Reserve(c.Request.Context(), owner, key, hash, time.Now().Add(ttl))
+		if errors.Is(err, store.ErrConflict) {
+			problem.Abort(c, http.StatusConflict, problem.CodeIdempotencyInProgress, idempotencyHeader, "Idempotency-Key is being processed, retry later")
+			return
+		}
+		if err != nil {
+			problem.Abort(c, http.StatusInternalServerError, problem.CodeInternal, "", "internal error")
+			return
+		}
+		if !reserved {
+			switch {
+			case rec.RequestHash != hash:
+				problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeIdempotencyMismatch, idempotencyHeader, "Idempotency-Key was already used with a different payload")
+			case rec.StatusCode == 0:
+				problem.Abort(c, http.StatusConflict, problem.CodeIdempotencyInProgress, idempotencyHeader, "Idempotency-Key is being processed, retry later")
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(rec.StatusCode, "application/json; charset=utf-8", rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// The request context may already be cancelled; the outcome must still be recorded
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 2*time.Second)
+		defer cancel()
+
+		status := w.Status()
+		if status >= 200 && status < 300 {
+			err = repo.Complete(ctx, owner, key, status, w.body.Bytes())
+		} else {
+			err = repo.Release(ctx, owner, key)
+		}
+		if err != nil {
+			if log, ok := c.Value("logger").(*slog.Logger); ok {
+				log.Error("idempotency: cannot record outcome", "error", err)
+			}
+		}
+	}
+}
+
+// requestHash fingerprints a request; JSON bodies are canonicalised so that
+// key order and whitespace do not count as a different payload.
+func requestHash(method, route string, body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canon, err := json.Marshal(v); err == nil {
+			body = canon
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte(method + " " + route + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// capturingWriter keeps a copy of the response body.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"team5/task-manager/internal/store/memory"
+)
+
+// newIdempotentRouter serves POST /things, answering status with a body
+// numbered by how many times the handler actually ran.
+func newIdempotentRouter(repo *memory.IdempotencyStore, status *int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(subjectKey, c.GetHeader("X-Sub")) })
+	r.POST("/things", Idempotency(repo, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(*status, gin.H{"call": calls})
+	})
+	return r, &calls
+}
+
+func post(r http.Handler, sub, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set("X-Sub", sub)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	status := http.StatusCreated
+	r, calls := newIdempotentRouter(memory.NewIdempotencyStore(), &status)
+
+	first := post(r, "alice", "k1", `{"title":"a","done":false}`)
+	// Same payload, different key order and spacing
+	replay := post(r, "alice", "k1", `{ "done": false, "title": "a" }`)
+
+	if *calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", *calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", replay.Code, replay.Body, first.Code, first.Body)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is not flagged")
+	}
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	status := http.StatusCreated
+	r, calls := newIdempotentRouter(memory.NewIdempotencyStore(), &status)
+
+	post(r, "alice", "k1", `{"title":"a"}`)
+	w := post(r, "alice", "k1", `{"title":"b"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+	if *calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", *calls)
+	}
+}
+
+func TestIdempotencyKeysAreScopedToCaller(t *testing.T) {
+	status := http.StatusCreated
+	r, calls := newIdempotentRouter(memory.NewIdempotencyStore(), &status)
+
+	post(r, "alice", "k1", `{"title":"a"}`)
+	if w := post(r, "bob", "k1", `{"title":"b"}`); w.Code != http.StatusCreated {
+		t.Fatalf("bob: status = %d, want 201", w.Code)
+	}
+	if *calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotencyFailedRequestCanBeRetried(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	r, calls := newIdempotentRouter(memory.NewIdempotencyStore(), &status)
+
+	post(r, "alice", "k1", `{}`)
+	status = http.StatusCreated
+	if w := post(r, "alice", "k1", `{}`); w.Code != http.StatusCreated {
+		t.Fatalf("retry: status = %d, want 201", w.Code)
+	}
+	if *calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotencyWithoutKeyPassesThrough(t *testing.T) {
+	status := http.StatusCreated
+	r, calls := newIdempotentRouter(memory.NewIdempotencyStore(), &status)
+
+	post(r, "alice", "", `{}`)
+	post(r, "alice", "", `{}`)
+	if *calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	repo := memory.NewIdempotencyStore()
+	status := http.StatusCreated
+	r, _ := newIdempotentRouter(repo, &status)
+
+	hash := requestHash(http.MethodPost, "/things", []byte(`{}`))
+	if _, ok, _ := repo.Reserve(context.Background(), "alice", "k1", hash, time.Now().Add(time.Hour)); !ok {
+		t.Fatal("reserve failed")
+	}
+	if w := post(r, "alice", "k1", `{}`); w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", w.Code)
+	}
+}
+
+func TestIdempotencySweep(t *testing.T) {
+	repo := memory.NewIdempotencyStore()
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		ttl := time.Hour
+		if i == 0 {
+			ttl = -time.Second
+		}
+		if _, _, err := repo.Reserve(ctx, "alice", fmt.Sprint(i), "h", time.Now().Add(ttl)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := repo.DeleteExpired(ctx, time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1", n, err)
+	}
+}
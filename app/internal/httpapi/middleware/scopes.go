@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"team5/task-manager/internal/httpapi/problem"
+)
+
+// OAuth scopes of the API, declared per route with RequireScope.
+// ScopeTasksAdmin grants every other one.
+const (
+	ScopeTasksRead   = "tasks:read"
+	ScopeTasksWrite  = "tasks:write"
+	ScopeTasksDelete = "tasks:delete"
+	ScopeTasksAdmin  = "tasks:admin"
+)
+
+// scopesKey is the gin context key holding the scopes granted by the token.
+const scopesKey = "scopes"
+
+// tokenScopes reads the space separated scope claim (RFC 8693), or else the
+// scp claim, as a list or a space separated string. A token with neither
+// gets defaults; ok is false for a claim of the wrong type.
+func tokenScopes(claims jwt.MapClaims, defaults []string) (scopes []string, ok bool) {
+	v, found := claims["scope"]
+	if !found {
+		v, found = claims["scp"]
+	}
+	if !found {
+		return defaults, true
+	}
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v), true
+	case []any:
+		for _, s := range v {
+			str, ok := s.(string)
+			if !ok {
+				return nil, false
+			}
+			scopes = append(scopes, str)
+		}
+		return scopes, true
+	}
+	return nil, false
+}
+
+// HasScope reports whether the token of the request grants scope.
+func HasScope(c *gin.Context, scope string) bool {
+	scopes := c.GetStringSlice(scopesKey)
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeTasksAdmin)
+}
+
+// RequireScope refuses the requests whose token does not grant scope. Must
+// run after AuthJWT.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, scope) {
+			AbortMissingScope(c, scope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortMissingScope answers 403 naming the scope the token lacks, in the
+// problem and in WWW-Authenticate (RFC 6750).
+func AbortMissingScope(c *gin.Context, scope string) {
+	p := problem.New(c, http.StatusForbidden, problem.CodeInsufficientScope, "", "the token lacks the "+scope+" scope")
+	p.Scope = scope
+	c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+	c.Header("Content-Type", problem.ContentType)
+	c.AbortWithStatusJSON(http.StatusForbidden, p)
+}
@@ -0,0 +1,70 @@
+// Package problem writes RFC 7807 application/problem+json error bodies.
+package problem
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ContentType = "application/problem+json"
+
+// Machine-readable error codes, stable across releases.
+const (
+	CodeInvalidRequest     = "invalid_request"   // body is not valid JSON or has the wrong shape
+	CodeValidationFailed   = "validation_failed" // a field is missing or malformed
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"          // the caller's role in the project does not allow it
+	CodeInsufficientScope  = "insufficient_scope" // the token lacks the scope of the route
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodeOverloaded         = "overloaded"
+	CodeInternal           = "internal"
+	CodeRolledBack         = "rolled_back" // batch operation undone because another one failed
+	CodeParentCycle        = "parent_cycle"
+	CodeHasChildren        = "has_children"
+	CodeInvalidTransition  = "invalid_transition" // the status workflow forbids the change
+	CodeProjectNotEmpty    = "project_not_empty"
+	CodeAlreadyMember      = "already_member"
+
+	CodeIdempotencyMismatch   = "idempotency_key_reused"      // same key, different payload
+	CodeIdempotencyInProgress = "idempotency_key_in_progress" // original request not finished yet
+)
+
+// correlationKey is the gin context key set by middleware.CorrelationID.
+const correlationKey = "correlation_id"
+
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	Code          string `json:"code"`
+	Field         string `json:"field,omitempty"`
+	Scope         string `json:"scope,omitempty"` // the missing scope of an insufficient_scope problem
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// New builds the problem for the current request.
+func New(c *gin.Context, status int, code, field, detail string) Problem {
+	return Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      c.Request.URL.Path,
+		Code:          code,
+		Field:         field,
+		CorrelationID: c.GetString(correlationKey),
+	}
+}
+
+// Abort writes the problem and stops the handler chain.
+func Abort(c *gin.Context, status int, code, field, detail string) {
+	p := New(c, status, code, field, detail)
+	// gin keeps an already set Content-Type when rendering JSON
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(status, p)
+}
@@ -6,38 +6,66 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"team5/task-manager/internal/config"
+	"team5/task-manager/internal/health"
 	"team5/task-manager/internal/httpapi/handlers"
 	"team5/task-manager/internal/httpapi/middleware"
+	"team5/task-manager/internal/httpapi/problem"
+	"team5/task-manager/internal/store"
 	"team5/task-manager/internal/store/postgres"
 )
 
-func NewRouter(cfg *config.Config, pool *pgxpool.Pool) http.Handler {
+// NewRouter serves the API; feed announces the task changes streamed by GET
+// /tasks/events, keys verifies RS256 and ES256 tokens (nil for HS256 only).
+func NewRouter(cfg *config.Config, pool *pgxpool.Pool, probes *health.Probes, feed store.ChangeFeed, keys middleware.KeySource) http.Handler {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 
-	r.Use(gin.Recovery())
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		problem.Abort(c, http.StatusInternalServerError, problem.CodeInternal, "", "internal error")
+	}))
+	r.Use(otelgin.Middleware(cfg.ServiceName, otelgin.WithFilter(tracedRequest)))
 	r.Use(middleware.CorrelationID())
 	r.Use(middleware.PrometheusMetrics())
 
-	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
-	r.GET("/readyz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	probe := handlers.NewHealthHandler(probes)
+	r.GET("/healthz", probe.Liveness)
+	r.GET("/readyz", probe.Readiness)
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	api := r.Group("/")
-	api.Use(middleware.AuthJWT(cfg.JWTSecret))
+	api.Use(middleware.AuthJWT(cfg.JWTSecret, middleware.AuthOptions{
+		TenantClaim:   cfg.JWTTenantClaim,
+		Keys:          keys,
+		Issuer:        cfg.JWTIssuer,
+		Audience:      cfg.JWTAudience,
+		ClockSkew:     cfg.JWTClockSkew,
+		DefaultScopes: cfg.JWTDefaultScopes,
+	}))
 
-	store := postgres.NewTasksStore(pool)
-	tasks := handlers.NewTasksHandler(store)
-
-	api.POST("/tasks", tasks.Create)
-	api.GET("/tasks", tasks.List)
-	api.GET("/tasks/:id", tasks.Get)
-	api.PUT("/tasks/:id", tasks.Update)
-	api.DELETE("/tasks/:id", tasks.Delete)
-
-	_ = pool
+	repo := postgres.NewTasksStore(pool, postgres.Options{SearchLanguage: cfg.SearchLanguage})
+	projectStore := postgres.NewProjectStore(pool)
+	handlers.RegisterRoutes(api, handlers.Handlers{
+		Tasks: handlers.NewTasksHandler(repo, projectStore, handlers.TasksOptions{
+			DeleteChildren: store.ChildrenMode(cfg.SubtaskDeleteMode),
+			Workflow:       cfg.StatusWorkflow,
+		}),
+		Projects:    handlers.NewProjectsHandler(projectStore, projectStore, repo),
+		Webhooks:    handlers.NewWebhooksHandler(postgres.NewWebhookStore(pool)),
+		Events:      handlers.NewEventsHandler(repo, feed, projectStore, handlers.EventsOptions{}),
+		Idempotency: middleware.Idempotency(postgres.NewIdempotencyStore(pool), cfg.IdempotencyTTL),
+	})
 
 	return r
 }
+
+// tracedRequest keeps probes and Prometheus scrapes out of the traces
+func tracedRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
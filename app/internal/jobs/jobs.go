@@ -0,0 +1,34 @@
+// Package jobs runs the periodic background work of the API process.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrOverrun is the cause (context.Cause) of the cancellation of a run that
+// outlived its interval, as opposed to the shutdown of the process.
+var ErrOverrun = errors.New("jobs: run outlived its interval")
+
+// Every calls fn each interval until ctx is done. Failures are logged and the
+// next tick retries; a run is cancelled with ErrOverrun if it outlives its
+// interval.
+func Every(ctx context.Context, log *slog.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	log = log.With("job", name)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			runCtx, cancel := context.WithTimeoutCause(ctx, interval, ErrOverrun)
+			if err := fn(runCtx); err != nil && ctx.Err() == nil {
+				log.Error("job failed", "error", err)
+			}
+			cancel()
+		}
+	}
+}
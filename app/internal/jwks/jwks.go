@@ -0,0 +1,220 @@
+// Package jwks keeps the public keys of a JSON Web Key Set (RFC 7517) that
+// verify RS256 and ES256 tokens, reloaded from a URL or a file.
+package jwks
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned for a kid and algorithm no key of the set matches.
+var ErrKeyNotFound = errors.New("jwks: no matching key")
+
+// maxBody bounds a fetched key set.
+const maxBody = 1 << 20
+
+// KeySet is the current keys of a JWKS source, safe for concurrent use.
+// Refresh reloads them, keeping the previous keys when the source fails; a
+// token signed with a kid not known yet triggers a reload, at most once per
+// MinRefreshInterval, so keys rotated in between are picked up.
+type KeySet struct {
+	// Source is an http(s) URL or the path of a local file.
+	Source string
+	Client *http.Client
+	// MinRefreshInterval spaces the reloads triggered by unknown kids.
+	MinRefreshInterval time.Duration
+
+	mu      sync.RWMutex
+	keys    []key
+	loaded  time.Time
+	refresh sync.Mutex // one reload at a time
+}
+
+type key struct {
+	kid string
+	alg string // RS256 or ES256
+	pub any    // *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+func New(source string, timeout time.Duration) *KeySet {
+	return &KeySet{Source: source, Client: &http.Client{Timeout: timeout}, MinRefreshInterval: time.Minute}
+}
+
+// Refresh reloads the keys from the source.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.refresh.Lock()
+	defer s.refresh.Unlock()
+	return s.reload(ctx)
+}
+
+func (s *KeySet) reload(ctx context.Context) error {
+	raw, err := s.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("jwks %s: %w", s.Source, err)
+	}
+	keys, err := parse(raw)
+	if err != nil {
+		return fmt.Errorf("jwks %s: %w", s.Source, err)
+	}
+	s.mu.Lock()
+	s.keys, s.loaded = keys, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.Source, "http://") && !strings.HasPrefix(s.Source, "https://") {
+		return os.ReadFile(s.Source)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBody))
+}
+
+// Key returns the public key verifying alg tokens signed with kid. An empty
+// kid selects the only key of the algorithm, if there is just one.
+func (s *KeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	if pub, err := s.lookup(kid, alg); err == nil || kid == "" {
+		return pub, err
+	}
+
+	s.refresh.Lock()
+	defer s.refresh.Unlock()
+	// Another caller may have reloaded while we waited
+	if pub, err := s.lookup(kid, alg); err == nil {
+		return pub, nil
+	}
+	s.mu.RLock()
+	recent := time.Since(s.loaded) < s.MinRefreshInterval
+	s.mu.RUnlock()
+	if recent {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.reload(ctx); err != nil {
+		return nil, err
+	}
+	return s.lookup(kid, alg)
+}
+
+func (s *KeySet) lookup(kid, alg string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found []any
+	for _, k := range s.keys {
+		if k.alg == alg && (kid == "" || k.kid == kid) {
+			found = append(found, k.pub)
+		}
+	}
+	if len(found) != 1 {
+		return nil, ErrKeyNotFound
+	}
+	return found[0], nil
+}
+
+// jwk is the subset of RFC 7517/7518 members read from a key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parse reads the RSA and P-256 signing keys of a JWKS document. Keys for
+// encryption, of other types or algorithms are skipped; a malformed key of a
+// supported type is an error.
+func parse(raw []byte) ([]key, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	var keys []key
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			pub any
+			alg string
+			err error
+		)
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+			alg = "RS256"
+			pub, err = rsaKey(k)
+		case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+			alg = "ES256"
+			pub, err = p256Key(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d (kid %q): %w", i, k.Kid, err)
+		}
+		keys = append(keys, key{kid: k.Kid, alg: alg, pub: pub})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 or ES256 signing key")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) < 256 {
+		return nil, errors.New("invalid or shorter than 2048 bits modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	exp := new(big.Int).SetBytes(e)
+	if exp.Int64() < 3 || exp.Bit(0) == 0 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
+
+func p256Key(k jwk) (*ecdsa.PublicKey, error) {
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid coordinates")
+	}
+	// ecdh checks that the point is on the curve
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, errors.New("point is not on P-256")
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
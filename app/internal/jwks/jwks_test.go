@@ -0,0 +1,167 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, map[string]string) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(priv.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(priv.E)).Bytes()),
+	}
+}
+
+func ecJWK(t *testing.T, kid string) (*ecdsa.PrivateKey, map[string]string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(priv.X.FillBytes(make([]byte, 32))),
+		"y": base64.RawURLEncoding.EncodeToString(priv.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func document(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// server serves the document held by doc, counting the requests.
+func server(t *testing.T, doc *atomic.Value, hits *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		raw, _ := doc.Load().([]byte)
+		if raw == nil {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(raw)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestKeySetSelectsByKidAndAlgorithm(t *testing.T) {
+	rsaKey, rsaDoc := rsaJWK(t, "r1")
+	ecKey, ecDoc := ecJWK(t, "e1")
+	encryption := map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "x", "e": "AQAB"}
+	var doc atomic.Value
+	doc.Store(document(t, rsaDoc, ecDoc, encryption))
+	var hits atomic.Int32
+	keys := New(server(t, &doc, &hits).URL, time.Second)
+	ctx := context.Background()
+	if err := keys.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if pub, err := keys.Key(ctx, "r1", "RS256"); err != nil || !rsaKey.PublicKey.Equal(pub) {
+		t.Errorf("Key(r1, RS256) = %v, %v", pub, err)
+	}
+	if pub, err := keys.Key(ctx, "e1", "ES256"); err != nil || !ecKey.PublicKey.Equal(pub) {
+		t.Errorf("Key(e1, ES256) = %v, %v", pub, err)
+	}
+	if pub, err := keys.Key(ctx, "", "ES256"); err != nil || !ecKey.PublicKey.Equal(pub) {
+		t.Errorf("Key without kid = %v, %v, want the only ES256 key", pub, err)
+	}
+	if _, err := keys.Key(ctx, "r1", "ES256"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key(r1, ES256): %v, want ErrKeyNotFound", err)
+	}
+	if _, err := keys.Key(ctx, "enc", "RS256"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("encryption key: %v, want ErrKeyNotFound", err)
+	}
+	// Unknown kids right after a reload do not hammer the issuer
+	if hits.Load() != 1 {
+		t.Errorf("%d fetches, want 1", hits.Load())
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	_, oldDoc := rsaJWK(t, "old")
+	newKey, newDoc := rsaJWK(t, "new")
+	var doc atomic.Value
+	doc.Store(document(t, oldDoc))
+	var hits atomic.Int32
+	keys := New(server(t, &doc, &hits).URL, time.Second)
+	keys.MinRefreshInterval = 0
+	ctx := context.Background()
+	if err := keys.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	doc.Store(document(t, oldDoc, newDoc))
+	if pub, err := keys.Key(ctx, "new", "RS256"); err != nil || !newKey.PublicKey.Equal(pub) {
+		t.Fatalf("Key of a rotated-in kid = %v, %v", pub, err)
+	}
+
+	// A failing source keeps the keys loaded last
+	doc.Store([]byte(nil))
+	if err := keys.Refresh(ctx); err == nil {
+		t.Error("Refresh of a failing source succeeded")
+	}
+	if _, err := keys.Key(ctx, "new", "RS256"); err != nil {
+		t.Errorf("Key after a failed refresh: %v", err)
+	}
+}
+
+func TestKeySetFile(t *testing.T) {
+	ecKey, ecDoc := ecJWK(t, "e1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, document(t, ecDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys := New(path, time.Second)
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if pub, err := keys.Key(context.Background(), "e1", "ES256"); err != nil || !ecKey.PublicKey.Equal(pub) {
+		t.Errorf("Key = %v, %v", pub, err)
+	}
+}
+
+func TestParseRejectsMalformedKeys(t *testing.T) {
+	_, ecDoc := ecJWK(t, "e1")
+	offCurve := map[string]string{}
+	for k, v := range ecDoc {
+		offCurve[k] = v
+	}
+	offCurve["y"] = offCurve["x"]
+	for name, raw := range map[string][]byte{
+		"not json":     []byte("keys"),
+		"no keys":      []byte(`{"keys":[]}`),
+		"off curve":    document(t, offCurve),
+		"short rsa":    document(t, map[string]string{"kty": "RSA", "n": "AQAB", "e": "AQAB"}),
+		"only symkeys": document(t, map[string]string{"kty": "oct", "k": "c2VjcmV0"}),
+	} {
+		if _, err := parse(raw); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"os"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var Logger *slog.Logger
@@ -19,3 +22,12 @@ func Init() {
 func WithCorrelationID(correlationID string) *slog.Logger {
 	return Logger.With("correlation_id", correlationID)
 }
+
+// WithTrace attaches trace_id and span_id when ctx carries a span
+func WithTrace(ctx context.Context, log *slog.Logger) *slog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return log
+	}
+	return log.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+}
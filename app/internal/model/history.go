@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Task history actions.
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionDeleted  = "deleted"
+	ActionRestored = "restored"
+)
+
+// FieldChange is the before/after value of one task field. From is null on creation.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// TaskHistoryEntry is one append-only audit record of a task mutation.
+type TaskHistoryEntry struct {
+	ID               int64                  `json:"id"`
+	TaskID           string                 `json:"task_id"`
+	Actor            string                 `json:"actor"`
+	Action           string                 `json:"action"`
+	CorrelationID    string                 `json:"correlation_id,omitempty"`
+	RequestTimestamp *time.Time             `json:"request_timestamp,omitempty"`
+	Changes          map[string]FieldChange `json:"changes"`
+	Version          int64                  `json:"version"` // task version after the change
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// HistoryParams pages through a task history, oldest first.
+type HistoryParams struct {
+	Limit   int   // must be > 0
+	AfterID int64 // entries with a greater ID only
+}
+
+type TaskHistoryPage struct {
+	Items      []TaskHistoryEntry `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
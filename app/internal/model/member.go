@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// Project roles, from the least to the most privileged. Each one has the
+// rights of the previous ones.
+const (
+	RoleViewer = "viewer" // reads the project and its tasks
+	RoleEditor = "editor" // creates, changes and deletes its tasks
+	RoleAdmin  = "admin"  // changes and archives the project, manages members and invitations
+	RoleOwner  = "owner"  // deletes the project; held by its creator only, never granted
+)
+
+// MemberRoles are the roles a member can be given.
+var MemberRoles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3, RoleOwner: 4}
+
+// RoleAllows reports whether role has the rights of need.
+func RoleAllows(role, need string) bool {
+	return roleRanks[need] > 0 && roleRanks[role] >= roleRanks[need]
+}
+
+// Access is what a caller may do with a project or task: the stores are
+// called as OwnerID, the owner of the data, with the rights of Role.
+type Access struct {
+	OwnerID   string
+	ProjectID string // "" for a task outside any project
+	Role      string
+}
+
+// Member is someone a project is shared with, or its owner in member lists.
+type Member struct {
+	ProjectID string    `json:"project_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Invitation offers a role in a project to a user (a token subject), until
+// accepted, declined or revoked.
+type Invitation struct {
+	ID          string    `json:"id"`
+	ProjectID   string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Invitee     string    `json:"invitee"`
+	Role        string    `json:"role"`
+	InvitedBy   string    `json:"invited_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Invitee string `json:"invitee" binding:"required"`
+	Role    string `json:"role" binding:"required"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type MemberList struct {
+	Items []Member `json:"items"`
+}
+
+type InvitationList struct {
+	Items []Invitation `json:"items"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Project groups tasks. Archiving it hides its tasks from GET /tasks.
+type Project struct {
+	ID          string        `json:"id"`
+	OwnerID     string        `json:"owner_id"`
+	Role        string        `json:"role"` // of the caller: owner, admin, editor or viewer
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Archived    bool          `json:"archived"`
+	ArchivedAt  *time.Time    `json:"archived_at,omitempty"`
+	TaskCounts  ProjectCounts `json:"task_counts"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ProjectCounts counts the live tasks of a project. Cancelled tasks are
+// neither open nor done.
+type ProjectCounts struct {
+	Open    int `json:"open"`    // todo, in_progress or blocked
+	Done    int `json:"done"`    // status done
+	Overdue int `json:"overdue"` // open with a due date before today (UTC)
+}
+
+// Count adds t, a live task, to the counts; today is YYYY-MM-DD.
+func (c *ProjectCounts) Count(t Task, today string) {
+	switch t.Status {
+	case StatusDone:
+		c.Done++
+	case StatusCancelled:
+	default:
+		c.Open++
+		if t.DueDate < today {
+			c.Overdue++
+		}
+	}
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+type ProjectList struct {
+	Items []Project `json:"items"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// EventTaskDueSoon is the type of the reminder sent when a task's due date approaches.
+const EventTaskDueSoon = "task.due_soon"
+
+// Reminder is the event delivered for a task coming due. ID stays the same
+// across delivery attempts so receivers can deduplicate.
+type Reminder struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Attempt   int       `json:"attempt"` // 1 for the first delivery
+	CreatedAt time.Time `json:"created_at"`
+	Task      Task      `json:"task"`
+}
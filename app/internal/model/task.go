@@ -3,31 +3,225 @@ package model
 import "time"
 
 type Task struct {
-	ID                   string    `json:"id"`
-	Title                string    `json:"title"`
-	Content              string    `json:"content"`
-	DueDate              string    `json:"due_date"` // keep as YYYY-MM-DD for API simplicity
-	Done                 bool      `json:"done"`
-	LastRequestTimestamp time.Time `json:"last_request_timestamp"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                   string     `json:"id"`
+	OwnerID              string     `json:"owner_id"`
+	Title                string     `json:"title"`
+	Content              string     `json:"content"`
+	DueDate              string     `json:"due_date"` // keep as YYYY-MM-DD for API simplicity
+	Done                 bool       `json:"done"`     // status == done, kept for older clients
+	Status               string     `json:"status"`
+	Priority             string     `json:"priority"`
+	LastRequestTimestamp time.Time  `json:"last_request_timestamp"`
+	Version              int64      `json:"version"` // bumped on every update, exposed as the ETag
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty"` // set while the task is in the trash
+	ParentID             *string    `json:"parent_id,omitempty"`
+	ProjectID            *string    `json:"project_id,omitempty"`
+	Progress             *int       `json:"progress,omitempty"`         // % of live subtasks done, only set on parents
+	Tags                 []string   `json:"tags"`                       // sorted, never null
+	Recurrence           string     `json:"recurrence,omitempty"`       // RFC 5545 RRULE, completing the task creates the next occurrence
+	RecurrenceStart      string     `json:"recurrence_start,omitempty"` // first due date of the series
+}
+
+// Task statuses. Which changes are allowed is configurable (see service.Workflow).
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+var Statuses = []string{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+
+// Task priorities, lowest first.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// TaskNode is a task with its live subtasks, as returned by GET /tasks/:id/tree.
+type TaskNode struct {
+	Task
+	Children []TaskNode `json:"children"`
 }
 
 type CreateTaskRequest struct {
-	Title            string `json:"title" binding:"required"`
-	Content          string `json:"content" binding:"required"`
-	DueDate          string `json:"due_date" binding:"required"`          // YYYY-MM-DD
-	RequestTimestamp string `json:"request_timestamp" binding:"required"` // RFC3339
+	Title            string   `json:"title" binding:"required"`
+	Content          string   `json:"content" binding:"required"`
+	DueDate          string   `json:"due_date" binding:"required"`          // YYYY-MM-DD
+	RequestTimestamp string   `json:"request_timestamp" binding:"required"` // RFC3339
+	ParentID         string   `json:"parent_id,omitempty"`
+	ProjectID        string   `json:"project_id,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Status           string   `json:"status,omitempty"`     // todo when empty
+	Priority         string   `json:"priority,omitempty"`   // medium when empty
+	Recurrence       string   `json:"recurrence,omitempty"` // RRULE, the series starts at due_date
 }
 
 type UpdateTaskRequest struct {
-	Title            *string `json:"title,omitempty"`
-	Content          *string `json:"content,omitempty"`
-	DueDate          *string `json:"due_date,omitempty"` // YYYY-MM-DD
-	Done             *bool   `json:"done,omitempty"`
-	RequestTimestamp string  `json:"request_timestamp" binding:"required"` // RFC3339
+	Title            *string   `json:"title,omitempty"`
+	Content          *string   `json:"content,omitempty"`
+	DueDate          *string   `json:"due_date,omitempty"` // YYYY-MM-DD
+	Done             *bool     `json:"done,omitempty"`     // true means status done, false reopens a done task as todo
+	Status           *string   `json:"status,omitempty"`
+	Priority         *string   `json:"priority,omitempty"`
+	Recurrence       *string   `json:"recurrence,omitempty"`        // restarts the series at due_date, "" stops it
+	ParentID         *string   `json:"parent_id,omitempty"`         // "" makes the task top-level again
+	ProjectID        *string   `json:"project_id,omitempty"`        // "" takes the task out of its project
+	Tags             *[]string `json:"tags,omitempty"`              // replaces the whole set
+	RequestTimestamp string    `json:"request_timestamp,omitempty"` // RFC3339, required unless If-Match is sent
 }
 
 type DeleteTaskRequest struct {
-	RequestTimestamp string `json:"request_timestamp" binding:"required"` // RFC3339
+	RequestTimestamp string `json:"request_timestamp,omitempty"` // RFC3339, required unless If-Match is sent
+}
+
+type RestoreTaskRequest struct {
+	RequestTimestamp string `json:"request_timestamp,omitempty"` // RFC3339, required unless If-Match is sent
+}
+
+// Sort keys accepted by GET /tasks.
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortDueDate   = "due_date"
+)
+
+// ListTasksParams are the validated GET /tasks query parameters.
+type ListTasksParams struct {
+	Limit      int    // must be > 0
+	Sort       string // SortCreatedAt, SortUpdatedAt or SortDueDate
+	Desc       bool
+	Done       *bool
+	Statuses   []string   // tasks in any of these statuses
+	Priorities []string   // tasks with any of these priorities
+	DueBefore  *time.Time // exclusive
+	DueAfter   *time.Time // exclusive
+	Query      string     // case-insensitive substring of title or content
+	Trashed    bool       // list the trash instead of live tasks
+	ParentID   *string    // only the direct subtasks of this task
+	ProjectID  *string    // only the tasks of this project, archived or not
+	Shared     []string   // also the tasks of these projects of other owners, shared with the caller
+	Tags       []string   // tasks having any of these tags, or all of them with TagsAll
+	TagsAll    bool
+	After      *TaskCursor
+
+	// IncludeArchived keeps the tasks of archived projects, otherwise left out
+	// of live listings without ProjectID
+	IncludeArchived bool
+}
+
+// TaskCursor is the keyset position of the last task of a page.
+// Sort and Desc are carried along so a cursor cannot be replayed with a different ordering.
+type TaskCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+// NewTaskCursor returns the cursor positioned right after t.
+func NewTaskCursor(t Task, sort string, desc bool) TaskCursor {
+	cur := TaskCursor{Sort: sort, Desc: desc, ID: t.ID}
+	switch sort {
+	case SortUpdatedAt:
+		cur.Value = t.UpdatedAt
+	case SortDueDate:
+		cur.Value, _ = time.Parse("2006-01-02", t.DueDate)
+	default:
+		cur.Value = t.CreatedAt
+	}
+	return cur
+}
+
+// OccurrenceList holds the upcoming due dates of a recurring task (YYYY-MM-DD).
+type OccurrenceList struct {
+	Items []string `json:"items"`
+}
+
+type TaskPage struct {
+	Items      []Task `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Operations and modes of POST /tasks:batch.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+
+	BatchModeAtomic     = "atomic"      // all-or-nothing, the default
+	BatchModeBestEffort = "best_effort" // failed operations do not undo the others
+)
+
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty"`
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+// BatchOperation is one item of a batch. Create takes the CreateTaskRequest
+// fields, update the UpdateTaskRequest ones and delete only request_timestamp.
+// Version plays the role of If-Match for update and delete.
+type BatchOperation struct {
+	Op               string    `json:"op"`
+	ID               string    `json:"id,omitempty"`
+	Version          *int64    `json:"version,omitempty"`
+	Title            *string   `json:"title,omitempty"`
+	Content          *string   `json:"content,omitempty"`
+	DueDate          *string   `json:"due_date,omitempty"` // YYYY-MM-DD
+	Done             *bool     `json:"done,omitempty"`
+	Status           *string   `json:"status,omitempty"`
+	Priority         *string   `json:"priority,omitempty"`
+	Recurrence       *string   `json:"recurrence,omitempty"`
+	ParentID         *string   `json:"parent_id,omitempty"`
+	ProjectID        *string   `json:"project_id,omitempty"`
+	Tags             *[]string `json:"tags,omitempty"`
+	Children         string    `json:"children,omitempty"`          // delete only: orphan, cascade or block
+	RequestTimestamp string    `json:"request_timestamp,omitempty"` // RFC3339
+}
+
+// TagCount is a tag with the number of live tasks using it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagList struct {
+	Items []TagCount `json:"items"`
+}
+
+// SearchTerm is one element of a full-text query: a word, a "quoted phrase"
+// or a prefix (word*).
+type SearchTerm struct {
+	Text   string
+	Phrase bool
+	Prefix bool
+}
+
+// SearchParams are the validated GET /tasks/search parameters. Every term must match.
+type SearchParams struct {
+	Query  string // as sent, carried in cursors
+	Terms  []SearchTerm
+	Shared []string // also the tasks of these projects of other owners, shared with the caller
+	Limit  int
+	Offset int
+}
+
+// SearchHit is a matching task with its relevance and a highlighted excerpt.
+// Snippet is HTML: the task text is escaped and matches are wrapped in <mark>.
+type SearchHit struct {
+	Task
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type SearchPage struct {
+	Items      []SearchHit `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// File formats of GET /tasks/export and POST /tasks/import.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson" // one JSON TaskRecord per line
+)
+
+// TaskRecord is a task as exported, and as read back by the import: the
+// CreateTaskRequest fields plus the ones describing the original task.
+type TaskRecord struct {
+	ID               string    `json:"id,omitempty"`        // on import, only links the subtasks of the file to their parent
+	ParentID         string    `json:"parent_id,omitempty"` // an id of the file, or of a task of the importer
+	Title            string    `json:"title"`
+	Content          string    `json:"content"`
+	DueDate          string    `json:"due_date"` // YYYY-MM-DD
+	Status           string    `json:"status,omitempty"`
+	Priority         string    `json:"priority,omitempty"`
+	Tags             []string  `json:"tags,omitempty"`
+	Recurrence       string    `json:"recurrence,omitempty"`
+	RequestTimestamp string    `json:"request_timestamp"`   // RFC3339, the last one of the original task
+	CreatedAt        time.Time `json:"created_at,omitzero"` // export only
+	UpdatedAt        time.Time `json:"updated_at,omitzero"` // export only
+}
+
+// NewTaskRecord returns the exported form of t.
+func NewTaskRecord(t Task) TaskRecord {
+	r := TaskRecord{
+		ID:               t.ID,
+		Title:            t.Title,
+		Content:          t.Content,
+		DueDate:          t.DueDate,
+		Status:           t.Status,
+		Priority:         t.Priority,
+		Tags:             t.Tags,
+		Recurrence:       t.Recurrence,
+		RequestTimestamp: t.LastRequestTimestamp.UTC().Format(time.RFC3339Nano),
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+	}
+	if t.ParentID != nil {
+		r.ParentID = *t.ParentID
+	}
+	return r
+}
+
+// CreateRequest returns the request creating a copy of the task.
+func (r TaskRecord) CreateRequest() CreateTaskRequest {
+	return CreateTaskRequest{
+		Title:            r.Title,
+		Content:          r.Content,
+		DueDate:          r.DueDate,
+		RequestTimestamp: r.RequestTimestamp,
+		ParentID:         r.ParentID,
+		Tags:             r.Tags,
+		Status:           r.Status,
+		Priority:         r.Priority,
+		Recurrence:       r.Recurrence,
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// Task lifecycle events, delivered to the owner's webhook subscriptions.
+// Restoring a task from the trash is a task.updated.
+const (
+	EventTaskCreated = "task.created"
+	EventTaskUpdated = "task.updated"
+	EventTaskDeleted = "task.deleted"
+)
+
+var TaskEvents = []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted}
+
+// WebhookSubscription receives the owner's task events at URL.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // every task event when empty
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // signing key, only returned on creation
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"` // generated when empty
+}
+
+// UpdateWebhookRequest changes the fields that are set.
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url,omitempty"`
+	Events *[]string `json:"events,omitempty"`
+	Active *bool     `json:"active,omitempty"`
+	Secret *string   `json:"secret,omitempty"`
+}
+
+type WebhookList struct {
+	Items []WebhookSubscription `json:"items"`
+}
+
+// TaskEvent is the body of a webhook delivery. ID stays the same across
+// delivery attempts so receivers can deduplicate.
+type TaskEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Task      Task      `json:"task"` // state after the change
+}
+
+// WebhookDelivery is one event due to be sent to one subscription.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	Attempt        int // 1 for the first delivery
+	URL            string
+	Secret         string
+	Event          TaskEvent
+}
+
+// Outcomes of a delivery attempt.
+const (
+	AttemptDelivered = "delivered"
+	AttemptRetry     = "retry"
+	AttemptDead      = "dead" // given up: the delivery is dead-lettered
+)
+
+// WebhookAttempt is one entry of a subscription's delivery log.
+type WebhookAttempt struct {
+	ID         int64     `json:"id"`
+	DeliveryID string    `json:"delivery_id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	Outcome    string    `json:"outcome"`
+	StatusCode int       `json:"status_code,omitempty"` // 0 when no answer was received
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookAttemptPage struct {
+	Items      []WebhookAttempt `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
@@ -9,6 +9,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
@@ -19,6 +20,9 @@ type ShutdownFunc func(ctx context.Context) error
 // Init configures an OTLP/gRPC tracer exporter.
 // In GKE you usually point OTEL_EXPORTER_OTLP_ENDPOINT to an OTel Collector service.
 func Init(serviceName, endpoint string, insecure bool) (ShutdownFunc, error) {
+	// Propagate W3C traceparent even when exporting is off, so upstream traces stay linked
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
 	if endpoint == "" {
 		return func(ctx context.Context) error { return nil }, nil
 	}
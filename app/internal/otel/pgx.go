@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "team5/task-manager/pgx"
+
+// PgxTracer implements pgx.QueryTracer: every Query, QueryRow and Exec becomes a
+// client span, child of whatever span the query context carries (the otelgin request span).
+// Install it on pgxpool.Config.ConnConfig.Tracer.
+type PgxTracer struct {
+	tracer trace.Tracer
+}
+
+var _ pgx.QueryTracer = (*PgxTracer)(nil)
+
+// NewPgxTracer uses the global tracer provider, so it may be built before Init runs.
+func NewPgxTracer() *PgxTracer {
+	return &PgxTracer{tracer: otel.Tracer(tracerName)}
+}
+
+func (t *PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, spanName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", strings.TrimSpace(data.SQL)),
+		),
+	)
+	return ctx
+}
+
+func (t *PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// spanName keeps cardinality low: "db SELECT", "db UPDATE", ...
+func spanName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "db"
+	}
+	return "db " + strings.ToUpper(fields[0])
+}
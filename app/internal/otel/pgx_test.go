@@ -0,0 +1,82 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestPgxSpansAreChildrenOfRequestSpan(t *testing.T) {
+	rec := newRecorder(t)
+	tracer := NewPgxTracer()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(otelgin.Middleware("test"))
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		ctx := tracer.TraceQueryStart(c.Request.Context(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1 FROM tasks WHERE id = $1"})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/42", nil))
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	db, req := spans[0], spans[1]
+	if db.Name() != "db SELECT" {
+		t.Errorf("db span name = %q", db.Name())
+	}
+	if req.Name() != "GET /tasks/:id" {
+		t.Errorf("request span name = %q", req.Name())
+	}
+	if db.Parent().SpanID() != req.SpanContext().SpanID() {
+		t.Errorf("db span parent = %s, want request span %s", db.Parent().SpanID(), req.SpanContext().SpanID())
+	}
+	if db.SpanContext().TraceID() != req.SpanContext().TraceID() {
+		t.Errorf("db span is in another trace")
+	}
+}
+
+func TestPgxSpanRecordsError(t *testing.T) {
+	rec := newRecorder(t)
+	tracer := NewPgxTracer()
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "  update tasks SET done = true"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if spans[0].Name() != "db UPDATE" {
+		t.Errorf("span name = %q", spans[0].Name())
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", spans[0].Status().Code)
+	}
+}
@@ -0,0 +1,52 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/webhook"
+)
+
+// Notifier delivers reminders. An error makes the scheduler try again later
+// unless webhook.Retryable says it is pointless.
+type Notifier interface {
+	Notify(ctx context.Context, r model.Reminder) error
+}
+
+// LogNotifier only logs reminders; it is used when no webhook is configured.
+type LogNotifier struct {
+	Log *slog.Logger
+}
+
+func (n LogNotifier) Notify(ctx context.Context, r model.Reminder) error {
+	n.Log.InfoContext(ctx, "task due soon", "reminder_id", r.ID, "task_id", r.Task.ID, "owner_id", r.Task.OwnerID, "due_date", r.Task.DueDate)
+	return nil
+}
+
+// WebhookNotifier posts each reminder as JSON to URL, signed with Secret
+// when set (see webhook.Post).
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// NewWebhookNotifier posts with a plain client: the URL is set by the
+// operator, and usually is a receiver inside the cluster, so it is not held
+// to the public addresses of the subscriptions of users (webhook.NewClient).
+func NewWebhookNotifier(url, secret string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r model.Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = webhook.Post(ctx, n.Client, n.URL, n.Secret, r.ID, body)
+	return err
+}
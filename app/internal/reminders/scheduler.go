@@ -0,0 +1,130 @@
+// Package reminders sends an event when the due date of an open task comes
+// near. Every replica runs the scheduler; an advisory lock makes sure only
+// one of them works at a time, and the reminder table that nothing is sent twice.
+package reminders
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/webhook"
+)
+
+// LockKey is the Postgres advisory lock key of the reminder scheduler.
+const LockKey int64 = 0x7461736b72656d31 // "taskrem1"
+
+var deliveries = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "reminders_deliveries_total",
+		Help: "Reminder delivery attempts by result (sent, retry, failed)",
+	},
+	[]string{"result"},
+)
+
+// Locker elects the replica that runs a tick.
+type Locker interface {
+	// TryLock returns ok=false without waiting when someone else holds the lock.
+	TryLock(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+type Options struct {
+	// Window is how long before its due date a task gets its reminder (24h if zero)
+	Window time.Duration
+	// MaxAttempts per reminder before giving up (8 if zero)
+	MaxAttempts int
+	// Retries wait BaseBackoff, doubled on each attempt up to MaxBackoff (30s and 1h if zero)
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BatchSize caps the reminders delivered per tick (100 if zero)
+	BatchSize int
+}
+
+type Scheduler struct {
+	repo     store.ReminderRepository
+	notifier Notifier
+	lock     Locker
+	log      *slog.Logger
+	opts     Options
+	now      func() time.Time
+}
+
+func NewScheduler(repo store.ReminderRepository, notifier Notifier, lock Locker, log *slog.Logger, opts Options) *Scheduler {
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	return &Scheduler{repo: repo, notifier: notifier, lock: lock, log: log.With("job", "reminders"), opts: opts, now: time.Now}
+}
+
+// Tick enqueues the reminders of tasks entering the window and delivers the
+// pending ones, if this replica gets the lock. Meant for jobs.Every.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	unlock, ok, err := s.lock.TryLock(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	now := s.now()
+	n, err := s.repo.Enqueue(ctx, now, now.Add(s.opts.Window))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.log.Info("reminders enqueued", "count", n)
+	}
+
+	pending, err := s.repo.Pending(ctx, now, s.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, r := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := s.notifier.Notify(ctx, r)
+		if err == nil {
+			deliveries.WithLabelValues("sent").Inc()
+			if err := s.repo.MarkSent(ctx, r.ID, s.now()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if r.Attempt < s.opts.MaxAttempts && webhook.Retryable(err) {
+			at := s.now().Add(s.Backoff(r.Attempt))
+			retryAt = &at
+			deliveries.WithLabelValues("retry").Inc()
+			s.log.Warn("reminder delivery failed, will retry", "reminder_id", r.ID, "attempt", r.Attempt, "retry_at", at, "error", err)
+		} else {
+			deliveries.WithLabelValues("failed").Inc()
+			s.log.Error("reminder delivery failed, giving up", "reminder_id", r.ID, "attempt", r.Attempt, "error", err)
+		}
+		if err := s.repo.MarkFailed(ctx, r.ID, r.Attempt, retryAt, err.Error()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backoff is the wait after failed attempt number attempt (1-based).
+func (s *Scheduler) Backoff(attempt int) time.Duration {
+	return webhook.Backoff(s.opts.BaseBackoff, s.opts.MaxBackoff, attempt)
+}
@@ -0,0 +1,214 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/store/memory"
+	"team5/task-manager/internal/webhook"
+)
+
+const owner = "alice"
+
+type fakeLock struct{ busy bool }
+
+func (l *fakeLock) TryLock(context.Context) (func(), bool, error) {
+	if l.busy {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+// receiver is a webhook endpoint answering with the queued statuses, then 200.
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	got      []model.Reminder
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	unix, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	if !webhook.Verify("s3cret", time.Unix(unix, 0), body, r.Header.Get(webhook.HeaderSignature)) {
+		rc.t.Errorf("bad signature %q", r.Header.Get(webhook.HeaderSignature))
+	}
+	var rem model.Reminder
+	if err := json.Unmarshal(body, &rem); err != nil {
+		rc.t.Errorf("decode: %v", err)
+	}
+	if r.Header.Get(webhook.HeaderID) != rem.ID {
+		rc.t.Errorf("%s = %q, want %q", webhook.HeaderID, r.Header.Get(webhook.HeaderID), rem.ID)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.got = append(rc.got, rem)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) attempts() []int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	out := make([]int, len(rc.got))
+	for i, r := range rc.got {
+		out[i] = r.Attempt
+	}
+	return out
+}
+
+type fixture struct {
+	tasks *memory.TasksStore
+	recv  *receiver
+	lock  *fakeLock
+	sched *Scheduler
+	now   time.Time
+}
+
+func newFixture(t *testing.T, statuses ...int) *fixture {
+	f := &fixture{
+		tasks: memory.NewTasksStore(),
+		recv:  &receiver{t: t, statuses: statuses},
+		lock:  &fakeLock{},
+		now:   time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+	}
+	srv := httptest.NewServer(f.recv)
+	t.Cleanup(srv.Close)
+
+	// The receiver listens on loopback, like one inside the cluster would on
+	// a private address
+	notifier := NewWebhookNotifier(srv.URL, "s3cret", 5*time.Second)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f.sched = NewScheduler(memory.NewReminderStore(f.tasks), notifier, f.lock, log, Options{MaxAttempts: 3})
+	f.sched.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fixture) create(t *testing.T, title, due string) model.Task {
+	t.Helper()
+	d, _ := time.Parse("2006-01-02", due)
+	task, err := f.tasks.Create(context.Background(), owner, store.NewTask{Title: title, Content: "c", DueDate: d, RequestTimestamp: f.now})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return task
+}
+
+func (f *fixture) tick(t *testing.T) {
+	t.Helper()
+	if err := f.sched.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSchedulerDeliversOnce(t *testing.T) {
+	f := newFixture(t)
+	task := f.create(t, "soon", "2025-03-11")
+	f.create(t, "later", "2025-03-20")
+
+	f.tick(t)
+	f.now = f.now.Add(time.Minute)
+	f.tick(t)
+
+	if len(f.recv.got) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(f.recv.got))
+	}
+	got := f.recv.got[0]
+	if got.Type != model.EventTaskDueSoon || got.Task.ID != task.ID || got.Attempt != 1 {
+		t.Errorf("delivered %+v", got)
+	}
+}
+
+func TestSchedulerRetriesWithBackoff(t *testing.T) {
+	f := newFixture(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	f.create(t, "soon", "2025-03-11")
+
+	f.tick(t) // 503, retry in 30s
+	f.now = f.now.Add(20 * time.Second)
+	f.tick(t) // too early
+	f.now = f.now.Add(10 * time.Second)
+	f.tick(t) // 429, retry in 1m
+	f.now = f.now.Add(time.Minute)
+	f.tick(t) // 200
+	f.now = f.now.Add(time.Hour)
+	f.tick(t)
+
+	if got := f.recv.attempts(); !equal(got, []int{1, 2, 3}) {
+		t.Errorf("attempts = %v, want [1 2 3]", got)
+	}
+}
+
+func TestSchedulerGivesUp(t *testing.T) {
+	f := newFixture(t, 500, 500, 500, 500)
+	f.create(t, "soon", "2025-03-11")
+	for range 5 {
+		f.tick(t)
+		f.now = f.now.Add(time.Hour)
+	}
+	if got := f.recv.attempts(); !equal(got, []int{1, 2, 3}) {
+		t.Errorf("attempts = %v, want MaxAttempts", got)
+	}
+
+	f = newFixture(t, http.StatusGone)
+	f.create(t, "soon", "2025-03-11")
+	f.tick(t)
+	f.now = f.now.Add(time.Hour)
+	f.tick(t)
+	if got := f.recv.attempts(); !equal(got, []int{1}) {
+		t.Errorf("attempts after 410 = %v, want [1]", got)
+	}
+}
+
+func TestSchedulerSkips(t *testing.T) {
+	f := newFixture(t)
+	f.lock.busy = true
+	task := f.create(t, "soon", "2025-03-11")
+	f.tick(t)
+	if len(f.recv.got) != 0 {
+		t.Fatalf("delivered without the lock")
+	}
+
+	f.lock.busy = false
+	if _, err := f.tasks.Update(context.Background(), owner, task.ID, model.UpdateTaskRequest{Done: &[]bool{true}[0]}, nil, store.Precondition{}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	f.tick(t)
+	if len(f.recv.got) != 0 {
+		t.Errorf("delivered a reminder for a done task")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, slog.Default(), Options{})
+	for attempt, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 8: time.Hour, 40: time.Hour} {
+		if got := s.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+// ValidationError reports a malformed client-supplied field.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(field, msg string) error {
+	return &ValidationError{Field: field, Message: msg}
+}
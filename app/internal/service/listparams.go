@@ -0,0 +1,197 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ParseListTasksParams validates the GET /tasks query string.
+// Supported: limit, cursor, sort (created_at|updated_at|due_date), order (asc|desc),
+// done, repeated status and priority values, due_before, due_after
+// (YYYY-MM-DD, exclusive), q, and repeated tag values matched with
+// tag_match=any (default) or all, and include_archived to keep the tasks of
+// archived projects.
+// Without an explicit order, due_date sorts ascending and timestamps descending.
+func ParseListTasksParams(q url.Values) (model.ListTasksParams, error) {
+	p := model.ListTasksParams{Limit: DefaultListLimit, Sort: model.SortCreatedAt}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxListLimit {
+			return p, invalid("limit", fmt.Sprintf("invalid limit (1-%d required)", MaxListLimit))
+		}
+		p.Limit = n
+	}
+
+	if v := q.Get("sort"); v != "" {
+		switch v {
+		case model.SortCreatedAt, model.SortUpdatedAt, model.SortDueDate:
+			p.Sort = v
+		default:
+			return p, invalid("sort", "invalid sort (created_at, updated_at or due_date required)")
+		}
+	}
+
+	switch q.Get("order") {
+	case "":
+		p.Desc = p.Sort != model.SortDueDate
+	case "asc":
+		p.Desc = false
+	case "desc":
+		p.Desc = true
+	default:
+		return p, invalid("order", "invalid order (asc or desc required)")
+	}
+
+	if v := q.Get("done"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, invalid("done", "invalid done (true or false required)")
+		}
+		p.Done = &b
+	}
+
+	for _, v := range q["status"] {
+		if err := CheckStatus("status", v); err != nil {
+			return p, err
+		}
+		p.Statuses = append(p.Statuses, v)
+	}
+	for _, v := range q["priority"] {
+		if err := CheckPriority("priority", v); err != nil {
+			return p, err
+		}
+		p.Priorities = append(p.Priorities, v)
+	}
+
+	if v := q.Get("due_before"); v != "" {
+		d, err := ParseDateYYYYMMDD(v)
+		if err != nil {
+			return p, invalid("due_before", "invalid due_before (YYYY-MM-DD required)")
+		}
+		p.DueBefore = &d
+	}
+	if v := q.Get("due_after"); v != "" {
+		d, err := ParseDateYYYYMMDD(v)
+		if err != nil {
+			return p, invalid("due_after", "invalid due_after (YYYY-MM-DD required)")
+		}
+		p.DueAfter = &d
+	}
+
+	p.Query = q.Get("q")
+
+	if tags := q["tag"]; len(tags) > 0 {
+		normalized, err := normalizeTags("tag", tags)
+		if err != nil {
+			return p, err
+		}
+		p.Tags = normalized
+	}
+	switch q.Get("tag_match") {
+	case "", "any":
+	case "all":
+		p.TagsAll = true
+	default:
+		return p, invalid("tag_match", "invalid tag_match (any or all required)")
+	}
+
+	if v := q.Get("include_archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, invalid("include_archived", "invalid include_archived (true or false required)")
+		}
+		p.IncludeArchived = b
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cur, err := DecodeCursor(v)
+		if err != nil {
+			return p, err
+		}
+		if cur.Sort != p.Sort || cur.Desc != p.Desc {
+			return p, invalid("cursor", "invalid cursor (sort or order changed)")
+		}
+		p.After = &cur
+	}
+
+	return p, nil
+}
+
+// EncodeCursor turns a cursor into the opaque token returned as next_cursor.
+func EncodeCursor(cur model.TaskCursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (model.TaskCursor, error) {
+	var cur model.TaskCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, invalid("cursor", "invalid cursor")
+	}
+	if err := json.Unmarshal(b, &cur); err != nil || !validCursor(cur) {
+		return cur, invalid("cursor", "invalid cursor")
+	}
+	return cur, nil
+}
+
+// validCursor checks what the stores cast: a task id, and a timestamp, or a
+// date when sorting by due date.
+func validCursor(cur model.TaskCursor) bool {
+	if _, err := uuid.Parse(cur.ID); err != nil || cur.Value.IsZero() {
+		return false
+	}
+	switch cur.Sort {
+	case model.SortCreatedAt, model.SortUpdatedAt:
+		return true
+	case model.SortDueDate:
+		return cur.Value.Equal(day(cur.Value)) && cur.Value.Location() == time.UTC
+	}
+	return false
+}
+
+// ParseHistoryParams validates the GET /tasks/:id/history query string (limit, cursor).
+func ParseHistoryParams(q url.Values) (model.HistoryParams, error) {
+	p := model.HistoryParams{Limit: DefaultListLimit}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxListLimit {
+			return p, invalid("limit", fmt.Sprintf("invalid limit (1-%d required)", MaxListLimit))
+		}
+		p.Limit = n
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return p, invalid("cursor", "invalid cursor")
+		}
+		id, err := strconv.ParseInt(string(b), 10, 64)
+		if err != nil || id < 1 {
+			return p, invalid("cursor", "invalid cursor")
+		}
+		p.AfterID = id
+	}
+
+	return p, nil
+}
+
+// EncodeHistoryCursor turns the last entry ID of a history page into next_cursor.
+func EncodeHistoryCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"team5/task-manager/internal/model"
+)
+
+const MaxInviteeLength = 255
+
+// ForbiddenError reports a caller who can see a project or task but whose
+// role lacks the rights of Need.
+type ForbiddenError struct {
+	Need string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Need == model.RoleOwner {
+		return "only the owner of the project can do this"
+	}
+	return "requires the " + e.Need + " role in the project"
+}
+
+// Authorize checks that role has the rights of need.
+func Authorize(role, need string) error {
+	if !model.RoleAllows(role, need) {
+		return &ForbiddenError{Need: need}
+	}
+	return nil
+}
+
+// CheckMemberRole validates a role given to a member.
+func CheckMemberRole(field, role string) error {
+	if !slices.Contains(model.MemberRoles, role) {
+		return invalid(field, "invalid "+field+" (viewer, editor or admin required)")
+	}
+	return nil
+}
+
+// NormalizeInvitee validates the user id of an invitee and returns it trimmed.
+func NormalizeInvitee(invitee string) (string, error) {
+	invitee = strings.TrimSpace(invitee)
+	if invitee == "" || utf8.RuneCountInString(invitee) > MaxInviteeLength {
+		return "", invalid("invitee", fmt.Sprintf("invitee must be 1-%d characters long", MaxInviteeLength))
+	}
+	return invitee, nil
+}
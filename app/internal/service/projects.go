@@ -0,0 +1,28 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxProjectNameLength        = 200
+	MaxProjectDescriptionLength = 10000
+)
+
+// NormalizeProjectName validates a project name and returns it trimmed.
+func NormalizeProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxProjectNameLength {
+		return "", invalid("name", fmt.Sprintf("name must be 1-%d characters long", MaxProjectNameLength))
+	}
+	return name, nil
+}
+
+func CheckProjectDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxProjectDescriptionLength {
+		return invalid("description", fmt.Sprintf("description must be at most %d characters long", MaxProjectDescriptionLength))
+	}
+	return nil
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule is the date-only subset of an RFC 5545 recurrence rule that task
+// schedules need: FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, COUNT,
+// UNTIL, BYDAY (with ordinals for MONTHLY and YEARLY), BYMONTHDAY, BYMONTH
+// and WKST. The series starts at a DTSTART date, which is always its first
+// occurrence.
+type RRule struct {
+	freq       string
+	interval   int
+	count      int        // 0 means unbounded
+	until      *time.Time // inclusive
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []int
+	wkst       time.Weekday
+}
+
+type weekdayNum struct {
+	n  int // 0 for every such weekday of the period, else the nth (negative from the end)
+	wd time.Weekday
+}
+
+const (
+	freqDaily   = "DAILY"
+	freqWeekly  = "WEEKLY"
+	freqMonthly = "MONTHLY"
+	freqYearly  = "YEARLY"
+
+	MaxRRuleLength = 200
+	// COUNT bounds the periods walked to find an occurrence, which a series
+	// with a COUNT cannot skip
+	maxCount = 10000
+	// The series is considered over once no occurrence shows up in that many periods
+	maxEmptyPeriods = 1000
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// ParseRRule validates a recurrence rule such as "FREQ=WEEKLY;BYDAY=MO,TH".
+// A leading "RRULE:" is accepted.
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return nil, invalid("recurrence", "recurrence is empty")
+	}
+	if len(s) > MaxRRuleLength {
+		return nil, invalid("recurrence", fmt.Sprintf("recurrence must be at most %d bytes", MaxRRuleLength))
+	}
+
+	r := &RRule{interval: 1, wkst: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[name] {
+			return nil, invalidRRule("malformed part %q", part)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch value {
+			case freqDaily, freqWeekly, freqMonthly, freqYearly:
+				r.freq = value
+			default:
+				return nil, invalidRRule("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err != nil || r.interval < 1 || r.interval > 1000 {
+				return nil, invalidRRule("INTERVAL must be between 1 and 1000")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err != nil || r.count < 1 || r.count > maxCount {
+				return nil, invalidRRule("COUNT must be between 1 and %d", maxCount)
+			}
+		case "UNTIL":
+			until, err := parseRRuleDate(value)
+			if err != nil {
+				return nil, err
+			}
+			r.until = &until
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				d, err := parseWeekdayNum(v)
+				if err != nil {
+					return nil, err
+				}
+				r.byDay = append(r.byDay, d)
+			}
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(value, -31, 31)
+			if err != nil {
+				return nil, invalidRRule("BYMONTHDAY must list days between -31 and 31")
+			}
+		case "BYMONTH":
+			r.byMonth, err = parseInts(value, 1, 12)
+			if err != nil {
+				return nil, invalidRRule("BYMONTH must list months between 1 and 12")
+			}
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				return nil, invalidRRule("invalid WKST %q", value)
+			}
+			r.wkst = wd
+		default:
+			return nil, invalidRRule("unsupported part %s", name)
+		}
+	}
+
+	switch {
+	case r.freq == "":
+		return nil, invalidRRule("FREQ is required")
+	case r.count > 0 && r.until != nil:
+		return nil, invalidRRule("COUNT and UNTIL cannot be combined")
+	case len(r.byMonthDay) > 0 && r.freq == freqWeekly:
+		return nil, invalidRRule("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	for _, d := range r.byDay {
+		if d.n != 0 && r.freq != freqMonthly && r.freq != freqYearly {
+			return nil, invalidRRule("BYDAY ordinals need FREQ=MONTHLY or YEARLY")
+		}
+	}
+	return r, nil
+}
+
+// String returns the rule in canonical form, without the RRULE: prefix.
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.freq}
+	if r.interval != 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.interval))
+	}
+	if r.count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.count))
+	}
+	if r.until != nil {
+		parts = append(parts, "UNTIL="+r.until.Format("20060102"))
+	}
+	if len(r.byDay) > 0 {
+		days := make([]string, len(r.byDay))
+		for i, d := range r.byDay {
+			days[i] = strings.ToUpper(d.wd.String()[:2])
+			if d.n != 0 {
+				days[i] = strconv.Itoa(d.n) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.byMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.byMonthDay))
+	}
+	if len(r.byMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.byMonth))
+	}
+	if r.wkst != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.wkst.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series started at start that
+// falls strictly after after, or false when the series is over.
+func (r *RRule) Next(start, after time.Time) (time.Time, bool) {
+	next := r.After(start, after, 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// After returns up to n occurrences of the series started at start that fall
+// strictly after after, in order.
+func (r *RRule) After(start, after time.Time, n int) []time.Time {
+	var out []time.Time
+	start = day(start)
+	r.withDefaults(start).each(start, after, func(d time.Time) bool {
+		if d.After(after) {
+			out = append(out, d)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// each calls fn with every occurrence in order until fn returns false or the
+// series ends. start is always the first occurrence. Without a COUNT, which
+// needs every occurrence counted, the periods before the one holding from are
+// skipped: their occurrences are not passed to fn.
+func (r *RRule) each(start, from time.Time, fn func(time.Time) bool) {
+	seen := 0
+	emit := func(d time.Time) bool {
+		if r.until != nil && d.After(*r.until) {
+			return false
+		}
+		seen++
+		return fn(d) && (r.count == 0 || seen < r.count)
+	}
+	if !emit(start) {
+		return
+	}
+
+	period := r.periodStart(start)
+	if r.count == 0 {
+		period = r.skip(period, r.periodStart(day(from)))
+	}
+	for empty := 0; empty < maxEmptyPeriods; {
+		found := false
+		for _, d := range r.expand(period) {
+			if !d.After(start) {
+				continue
+			}
+			found = true
+			if !emit(d) {
+				return
+			}
+		}
+		if found {
+			empty = 0
+		} else {
+			empty++
+		}
+		period = r.nextPeriod(period)
+		if r.until != nil && period.After(*r.until) {
+			return
+		}
+	}
+}
+
+func (r *RRule) periodStart(d time.Time) time.Time {
+	switch r.freq {
+	case freqWeekly:
+		return d.AddDate(0, 0, -((int(d.Weekday()) - int(r.wkst) + 7) % 7))
+	case freqMonthly:
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case freqYearly:
+		return time.Date(d.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return d
+}
+
+func (r *RRule) nextPeriod(p time.Time) time.Time {
+	return r.nextPeriods(p, 1)
+}
+
+// skip returns the last period of the series from p that starts no later
+// than target (a period start too), without walking the periods in between.
+func (r *RRule) skip(p, target time.Time) time.Time {
+	days := int((target.Unix() - p.Unix()) / 86400)
+	var n int
+	switch r.freq {
+	case freqDaily:
+		n = days / r.interval
+	case freqWeekly:
+		n = days / (7 * r.interval)
+	case freqMonthly:
+		n = ((target.Year()-p.Year())*12 + int(target.Month()-p.Month())) / r.interval
+	case freqYearly:
+		n = (target.Year() - p.Year()) / r.interval
+	}
+	if n <= 0 {
+		return p
+	}
+	return r.nextPeriods(p, n)
+}
+
+func (r *RRule) nextPeriods(p time.Time, n int) time.Time {
+	switch r.freq {
+	case freqWeekly:
+		return p.AddDate(0, 0, 7*r.interval*n)
+	case freqMonthly:
+		return p.AddDate(0, r.interval*n, 0)
+	case freqYearly:
+		return p.AddDate(r.interval*n, 0, 0)
+	}
+	return p.AddDate(0, 0, r.interval*n)
+}
+
+// expand lists the candidate dates of the period starting at p, sorted.
+func (r *RRule) expand(p time.Time) []time.Time {
+	var days []time.Time
+	switch r.freq {
+	case freqDaily:
+		days = []time.Time{p}
+	case freqWeekly:
+		for i := 0; i < 7; i++ {
+			days = append(days, p.AddDate(0, 0, i))
+		}
+	case freqMonthly:
+		days = r.expandMonth(p)
+	case freqYearly:
+		if len(r.byMonth) == 0 && len(r.byMonthDay) == 0 && len(r.byDay) > 0 {
+			// BYDAY ordinals count within the whole year
+			days = expandByDay(p, p.AddDate(1, 0, 0), r.byDay)
+			break
+		}
+		for m := 1; m <= 12; m++ {
+			days = append(days, r.expandMonth(time.Date(p.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC))...)
+		}
+	}
+
+	out := days[:0]
+	for _, d := range days {
+		if r.keep(d) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// expandMonth lists the BYMONTHDAY and BYDAY dates of the month starting at p.
+func (r *RRule) expandMonth(p time.Time) []time.Time {
+	end := p.AddDate(0, 1, 0)
+	if len(r.byDay) > 0 && len(r.byMonthDay) == 0 {
+		return expandByDay(p, end, r.byDay)
+	}
+	var days []time.Time
+	last := end.AddDate(0, 0, -1).Day()
+	for _, md := range r.byMonthDay {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if md >= 1 && md <= last {
+			days = append(days, p.AddDate(0, 0, md-1))
+		}
+	}
+	return days
+}
+
+// keep applies the BYxxx parts that limit rather than expand the candidates.
+func (r *RRule) keep(d time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, int(d.Month())) {
+		return false
+	}
+	switch r.freq {
+	case freqDaily:
+		if len(r.byMonthDay) > 0 && !matchesMonthDay(d, r.byMonthDay) {
+			return false
+		}
+		if len(r.byDay) > 0 && !matchesWeekday(d, r.byDay) {
+			return false
+		}
+	case freqWeekly:
+		if len(r.byDay) > 0 && !matchesWeekday(d, r.byDay) {
+			return false
+		}
+	case freqMonthly, freqYearly:
+		// BYDAY limits BYMONTHDAY when both are set
+		if len(r.byMonthDay) > 0 && len(r.byDay) > 0 && !matchesWeekday(d, r.byDay) {
+			return false
+		}
+	}
+	return true
+}
+
+// expandByDay lists the dates of [from, to) matching days; an ordinal picks
+// the nth such weekday of the range.
+func expandByDay(from, to time.Time, days []weekdayNum) []time.Time {
+	var out []time.Time
+	for _, wd := range days {
+		var all []time.Time
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == wd.wd {
+				all = append(all, d)
+			}
+		}
+		switch {
+		case wd.n == 0:
+			out = append(out, all...)
+		case wd.n > 0 && wd.n <= len(all):
+			out = append(out, all[wd.n-1])
+		case wd.n < 0 && -wd.n <= len(all):
+			out = append(out, all[len(all)+wd.n])
+		}
+	}
+	return out
+}
+
+func matchesWeekday(d time.Time, days []weekdayNum) bool {
+	for _, wd := range days {
+		if wd.wd == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesMonthDay(d time.Time, monthDays []int) bool {
+	last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range monthDays {
+		if md == d.Day() || md < 0 && last+md+1 == d.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// withDefaults fills the BYxxx parts RFC 5545 derives from DTSTART when a
+// rule does not say which days it falls on.
+func (r *RRule) withDefaults(start time.Time) *RRule {
+	c := *r
+	switch r.freq {
+	case freqWeekly:
+		if len(c.byDay) == 0 {
+			c.byDay = []weekdayNum{{wd: start.Weekday()}}
+		}
+	case freqMonthly:
+		if len(c.byDay) == 0 && len(c.byMonthDay) == 0 {
+			c.byMonthDay = []int{start.Day()}
+		}
+	case freqYearly:
+		if len(c.byDay) == 0 && len(c.byMonthDay) == 0 {
+			c.byMonthDay = []int{start.Day()}
+			if len(c.byMonth) == 0 {
+				c.byMonth = []int{int(start.Month())}
+			}
+		}
+	}
+	return &c
+}
+
+// parseRRuleDate reads an UNTIL value: a DATE (YYYYMMDD) or a UTC DATE-TIME
+// (YYYYMMDDTHHMMSSZ) of which only the date is kept.
+func parseRRuleDate(v string) (time.Time, error) {
+	if len(v) != 8 && !(len(v) == 16 && v[8] == 'T' && v[15] == 'Z') {
+		return time.Time{}, invalidRRule("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+	d, err := ParseDateYYYYMMDD(v[0:4] + "-" + v[4:6] + "-" + v[6:8])
+	if err != nil {
+		return time.Time{}, invalidRRule("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+	return d, nil
+}
+
+func parseWeekdayNum(v string) (weekdayNum, error) {
+	if len(v) < 2 {
+		return weekdayNum{}, invalidRRule("invalid BYDAY %q", v)
+	}
+	wd, ok := weekdays[v[len(v)-2:]]
+	if !ok {
+		return weekdayNum{}, invalidRRule("invalid BYDAY %q", v)
+	}
+	d := weekdayNum{wd: wd}
+	if prefix := v[:len(v)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return weekdayNum{}, invalidRRule("invalid BYDAY %q", v)
+		}
+		d.n = n
+	}
+	return d, nil
+}
+
+func parseInts(v string, lo, hi int) ([]int, error) {
+	var out []int
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n == 0 || n < lo || n > hi {
+			return nil, fmt.Errorf("invalid value %q", s)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func invalidRRule(format string, args ...any) error {
+	return invalid("recurrence", "invalid recurrence: "+fmt.Sprintf(format, args...))
+}
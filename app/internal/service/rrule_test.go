@@ -0,0 +1,104 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRRuleAfter(t *testing.T) {
+	for _, tc := range []struct {
+		rule, start, after string // after defaults to the day before start
+		want               []string
+	}{
+		// BYDAY ordinals
+		{"FREQ=MONTHLY;BYDAY=-1FR", "2025-01-31", "", []string{"2025-01-31", "2025-02-28", "2025-03-28", "2025-04-25", "2025-05-30"}},
+		{"FREQ=MONTHLY;BYDAY=2MO", "2025-01-13", "", []string{"2025-01-13", "2025-02-10", "2025-03-10", "2025-04-14"}},
+		{"FREQ=YEARLY;BYDAY=-1FR", "2025-12-26", "", []string{"2025-12-26", "2026-12-25", "2027-12-31"}},
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", "2025-11-27", "", []string{"2025-11-27", "2026-11-26", "2027-11-25"}},
+		// Negative BYMONTHDAY
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2025-01-31", "", []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-2", "2024-01-30", "", []string{"2024-01-30", "2024-02-28", "2024-03-30"}},
+		// Days missing from some periods are skipped, not moved
+		{"FREQ=YEARLY", "2024-02-29", "", []string{"2024-02-29", "2028-02-29", "2032-02-29"}},
+		{"FREQ=MONTHLY", "2025-01-31", "", []string{"2025-01-31", "2025-03-31", "2025-05-31", "2025-07-31"}},
+		// COUNT counts from the start, UNTIL is inclusive
+		{"FREQ=DAILY;COUNT=3", "2025-01-01", "", []string{"2025-01-01", "2025-01-02", "2025-01-03"}},
+		{"FREQ=DAILY;COUNT=3", "2025-01-01", "2025-01-02", []string{"2025-01-03"}},
+		{"FREQ=DAILY;UNTIL=20250103", "2025-01-01", "", []string{"2025-01-01", "2025-01-02", "2025-01-03"}},
+		{"FREQ=DAILY;UNTIL=20250103T120000Z", "2025-01-01", "2025-01-02", []string{"2025-01-03"}},
+		{"FREQ=WEEKLY;UNTIL=20250101", "2025-01-01", "2025-01-01", nil},
+		// WKST decides the weeks INTERVAL skips (RFC 5545 examples)
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", "1997-08-05", "", []string{"1997-08-05", "1997-08-10", "1997-08-19", "1997-08-24"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", "1997-08-05", "", []string{"1997-08-05", "1997-08-17", "1997-08-19", "1997-08-31"}},
+		// Far from the start, keeping the phase of INTERVAL
+		{"FREQ=DAILY", "2025-01-01", "9999-06-01", []string{"9999-06-02", "9999-06-03"}},
+		{"FREQ=WEEKLY;INTERVAL=3", "2025-01-06", "2030-01-01", []string{"2030-01-07", "2030-01-28"}},
+		{"FREQ=MONTHLY;INTERVAL=5", "2025-01-15", "9000-03-01", []string{"9000-06-15", "9000-11-15"}},
+		{"FREQ=YEARLY", "2024-02-29", "9000-01-01", []string{"9004-02-29", "9008-02-29"}},
+	} {
+		r, err := ParseRRule(tc.rule)
+		if err != nil {
+			t.Errorf("ParseRRule(%q): %v", tc.rule, err)
+			continue
+		}
+		start := date(t, tc.start)
+		after := start.AddDate(0, 0, -1)
+		if tc.after != "" {
+			after = date(t, tc.after)
+		}
+		// A series with COUNT or UNTIL is asked for more than it has
+		n := len(tc.want)
+		if strings.Contains(tc.rule, "COUNT") || strings.Contains(tc.rule, "UNTIL") {
+			n = 10
+		}
+		var got []string
+		for _, d := range r.After(start, after, n) {
+			got = append(got, d.Format("2006-01-02"))
+		}
+		if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("%s from %s after %s = %v, want %v", tc.rule, tc.start, after.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=10001",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=DAILY;UNTIL=2025-01-01",
+		"FREQ=DAILY;WKST=XX",
+		"FREQ=DAILY;FREQ=DAILY",
+		"BYDAY=MO",
+	} {
+		if _, err := ParseRRule(rule); err == nil {
+			t.Errorf("ParseRRule(%q) accepted", rule)
+		}
+	}
+}
+
+func TestRRuleString(t *testing.T) {
+	r, err := ParseRRule("rrule:wkst=su;byday=-1fr,mo;freq=monthly;interval=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.String(), "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,MO;WKST=SU"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"team5/task-manager/internal/model"
+)
+
+const (
+	MaxSearchQuery = 200
+	maxSearchTerms = 10
+)
+
+type searchCursor struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+// ParseSearchParams validates the GET /tasks/search query string: q (required),
+// limit and cursor. The cursor is only valid for the query that produced it.
+func ParseSearchParams(q url.Values) (model.SearchParams, error) {
+	p := model.SearchParams{Limit: DefaultListLimit, Query: q.Get("q")}
+
+	terms, err := ParseSearchQuery(p.Query)
+	if err != nil {
+		return p, err
+	}
+	p.Terms = terms
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxListLimit {
+			return p, invalid("limit", fmt.Sprintf("invalid limit (1-%d required)", MaxListLimit))
+		}
+		p.Limit = n
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		var cur searchCursor
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil || json.Unmarshal(b, &cur) != nil || cur.Offset < 1 {
+			return p, invalid("cursor", "invalid cursor")
+		}
+		if cur.Query != p.Query {
+			return p, invalid("cursor", "invalid cursor (q changed)")
+		}
+		p.Offset = cur.Offset
+	}
+	return p, nil
+}
+
+// EncodeSearchCursor returns the next_cursor of the page after p.
+func EncodeSearchCursor(p model.SearchParams) string {
+	b, _ := json.Marshal(searchCursor{Query: p.Query, Offset: p.Offset + p.Limit})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseSearchQuery splits a query into terms: "quoted text" is a phrase, a
+// word ending in * a prefix, anything else a plain word.
+func ParseSearchQuery(q string) ([]model.SearchTerm, error) {
+	if strings.TrimSpace(q) == "" {
+		return nil, invalid("q", "q is required")
+	}
+	if len(q) > MaxSearchQuery {
+		return nil, invalid("q", fmt.Sprintf("q must be at most %d bytes", MaxSearchQuery))
+	}
+
+	var terms []model.SearchTerm
+	rest := q
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		if rest[0] == '"' {
+			phrase, after, closed := strings.Cut(rest[1:], `"`)
+			if !closed {
+				return nil, invalid("q", "unterminated phrase in q")
+			}
+			if phrase = strings.Join(strings.Fields(phrase), " "); phrase != "" {
+				terms = append(terms, model.SearchTerm{Text: phrase, Phrase: true})
+			}
+			rest = after
+			continue
+		}
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+		term := model.SearchTerm{Text: strings.TrimRight(word, "*")}
+		term.Prefix = term.Text != word
+		if term.Text != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, invalid("q", "q has no searchable terms")
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, invalid("q", fmt.Sprintf("q has more than %d terms", maxSearchTerms))
+	}
+	return terms, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 50
+)
+
+// NormalizeTags validates tag names and returns them trimmed, lower-cased,
+// deduplicated and sorted, so "Work" and " work" are the same tag.
+func NormalizeTags(tags []string) ([]string, error) {
+	return normalizeTags("tags", tags)
+}
+
+// normalizeTags is NormalizeTags reporting errors on field.
+func normalizeTags(field string, tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, invalid(field, fmt.Sprintf("tags must be 1-%d characters long", MaxTagLength))
+		}
+		if strings.IndexFunc(tag, func(r rune) bool { return r == ',' || unicode.IsControl(r) }) >= 0 {
+			return nil, invalid(field, "tags cannot contain commas or control characters")
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	if len(out) > MaxTags {
+		return nil, invalid(field, fmt.Sprintf("a task has at most %d tags", MaxTags))
+	}
+	sort.Strings(out)
+	return out, nil
+}
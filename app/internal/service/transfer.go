@@ -0,0 +1,59 @@
+package service
+
+import (
+	"maps"
+	"mime"
+	"net/url"
+	"strconv"
+
+	"team5/task-manager/internal/model"
+)
+
+// ExportPageSize is how many tasks an export reads at a time.
+const ExportPageSize = MaxListLimit
+
+// ParseExportParams validates the GET /tasks/export query string: format
+// (csv or ndjson) and the GET /tasks filters. Tasks come oldest first unless
+// sort or order say otherwise; limit and cursor are ignored.
+func ParseExportParams(q url.Values) (string, model.ListTasksParams, error) {
+	format := q.Get("format")
+	if format != model.FormatCSV && format != model.FormatNDJSON {
+		return "", model.ListTasksParams{}, invalid("format", "invalid format (csv or ndjson required)")
+	}
+
+	q = maps.Clone(q)
+	q.Del("limit")
+	q.Del("cursor")
+	if q.Get("order") == "" {
+		q.Set("order", "asc")
+	}
+	p, err := ParseListTasksParams(q)
+	p.Limit = ExportPageSize
+	return format, p, err
+}
+
+// ParseImportParams validates the POST /tasks/import query string: dry_run,
+// and format, which defaults to the one of the content type (text/csv or
+// application/x-ndjson).
+func ParseImportParams(q url.Values, contentType string) (format string, dryRun bool, err error) {
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			return "", false, invalid("dry_run", "invalid dry_run (true or false required)")
+		}
+	}
+
+	format = q.Get("format")
+	if format == "" {
+		switch mt, _, _ := mime.ParseMediaType(contentType); mt {
+		case "text/csv":
+			format = model.FormatCSV
+		case "application/x-ndjson":
+			format = model.FormatNDJSON
+		}
+	}
+	if format != model.FormatCSV && format != model.FormatNDJSON {
+		return "", false, invalid("format", "invalid format (csv or ndjson required, or a text/csv or application/x-ndjson body)")
+	}
+	return format, dryRun, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"team5/task-manager/internal/model"
+)
+
+const (
+	MaxWebhookURLLength    = 2048
+	MinWebhookSecretLength = 16
+)
+
+// CheckWebhookURL validates an absolute https endpoint of a subscription sent
+// in field. Deliveries also refuse non-public addresses when they connect.
+func CheckWebhookURL(field, v string) error {
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || len(v) > MaxWebhookURLLength {
+		return invalid(field, field+" must be an absolute https URL")
+	}
+	return nil
+}
+
+// CheckEndpointURL validates an absolute http(s) endpoint set by the operator
+// in field.
+func CheckEndpointURL(field, v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || len(v) > MaxWebhookURLLength {
+		return invalid(field, field+" must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// NormalizeEvents validates the task event types of a subscription and
+// returns them deduplicated and sorted; empty means every event.
+func NormalizeEvents(events []string) ([]string, error) {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(model.TaskEvents, e) {
+			return nil, invalid("events", "invalid event "+e+" ("+strings.Join(model.TaskEvents, ", ")+" required)")
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// CheckWebhookSecret validates a signing key chosen by the client.
+func CheckWebhookSecret(secret string) error {
+	if len(secret) < MinWebhookSecretLength {
+		return invalid("secret", fmt.Sprintf("secret must be at least %d bytes long", MinWebhookSecretLength))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"team5/task-manager/internal/model"
+)
+
+// Workflow maps each status to the statuses a task may move to from it.
+// Statuses missing from the map are terminal.
+type Workflow map[string][]string
+
+// DefaultWorkflow lets work start, stall and finish freely; a blocked task
+// must be unblocked before it is done, and closed tasks can only be reopened.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		model.StatusTodo:       {model.StatusInProgress, model.StatusBlocked, model.StatusDone, model.StatusCancelled},
+		model.StatusInProgress: {model.StatusTodo, model.StatusBlocked, model.StatusDone, model.StatusCancelled},
+		model.StatusBlocked:    {model.StatusTodo, model.StatusInProgress, model.StatusCancelled},
+		model.StatusDone:       {model.StatusTodo, model.StatusInProgress},
+		model.StatusCancelled:  {model.StatusTodo},
+	}
+}
+
+// ParseWorkflow reads a transition graph written as
+// "todo=in_progress,done;in_progress=done;done=todo". An empty spec is the
+// DefaultWorkflow.
+func ParseWorkflow(spec string) (Workflow, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultWorkflow(), nil
+	}
+
+	w := make(Workflow)
+	for _, rule := range strings.Split(spec, ";") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		from, tos, ok := strings.Cut(rule, "=")
+		from = strings.TrimSpace(from)
+		if !ok || !slices.Contains(model.Statuses, from) {
+			return nil, fmt.Errorf("invalid transition rule %q", rule)
+		}
+		for _, to := range strings.Split(tos, ",") {
+			to = strings.TrimSpace(to)
+			if !slices.Contains(model.Statuses, to) {
+				return nil, fmt.Errorf("invalid status %q in transition rule %q", to, rule)
+			}
+			if to != from && !slices.Contains(w[from], to) {
+				w[from] = append(w[from], to)
+			}
+		}
+	}
+	return w, nil
+}
+
+// Allows reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (w Workflow) Allows(from, to string) bool {
+	return from == to || slices.Contains(w[from], to)
+}
+
+// CheckStatus validates a status value sent in field.
+func CheckStatus(field, status string) error {
+	if !slices.Contains(model.Statuses, status) {
+		return invalid(field, "invalid "+field+" ("+strings.Join(model.Statuses, ", ")+" required)")
+	}
+	return nil
+}
+
+// CheckPriority validates a priority value sent in field.
+func CheckPriority(field, priority string) error {
+	if !slices.Contains(model.Priorities, priority) {
+		return invalid(field, "invalid "+field+" ("+strings.Join(model.Priorities, ", ")+" required)")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"team5/task-manager/internal/model"
+)
+
+// Actor identifies who performs a mutation, recorded in the task history.
+type Actor struct {
+	ID            string
+	CorrelationID string
+}
+
+type actorKey struct{}
+
+// WithActor attaches the acting caller to ctx.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFrom returns the actor attached to ctx; an empty ID falls back to ownerID.
+func ActorFrom(ctx context.Context, ownerID string) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	if a.ID == "" {
+		a.ID = ownerID
+	}
+	return a
+}
+
+// Diff lists the task fields that differ between before and after.
+// A nil before (creation) reports every field.
+func Diff(before *model.Task, after model.Task) map[string]model.FieldChange {
+	changes := make(map[string]model.FieldChange)
+	add := func(field string, from, to any, changed bool) {
+		if before == nil {
+			from = nil
+		}
+		if before == nil || changed {
+			changes[field] = model.FieldChange{From: from, To: to}
+		}
+	}
+
+	var b model.Task
+	if before != nil {
+		b = *before
+	}
+	add("title", b.Title, after.Title, b.Title != after.Title)
+	add("content", b.Content, after.Content, b.Content != after.Content)
+	add("due_date", b.DueDate, after.DueDate, b.DueDate != after.DueDate)
+	add("done", b.Done, after.Done, b.Done != after.Done)
+	add("status", b.Status, after.Status, b.Status != after.Status)
+	add("priority", b.Priority, after.Priority, b.Priority != after.Priority)
+	if before != nil || after.ParentID != nil {
+		add("parent_id", nullable(b.ParentID), nullable(after.ParentID), nullable(b.ParentID) != nullable(after.ParentID))
+	}
+	if before != nil || after.ProjectID != nil {
+		add("project_id", nullable(b.ProjectID), nullable(after.ProjectID), nullable(b.ProjectID) != nullable(after.ProjectID))
+	}
+	if before != nil || len(after.Tags) > 0 {
+		add("tags", b.Tags, after.Tags, strings.Join(b.Tags, ",") != strings.Join(after.Tags, ","))
+	}
+	if before != nil || after.Recurrence != "" {
+		add("recurrence", b.Recurrence, after.Recurrence, b.Recurrence != after.Recurrence)
+	}
+	if before != nil {
+		add("deleted", b.DeletedAt != nil, after.DeletedAt != nil, (b.DeletedAt != nil) != (after.DeletedAt != nil))
+	}
+	return changes
+}
+
+// nullable turns an optional string into a JSON-friendly value (nil or the string).
+func nullable(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
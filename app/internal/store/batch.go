@@ -0,0 +1,54 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"team5/task-manager/internal/model"
+)
+
+// ErrRolledBack marks the operations of an all-or-nothing batch undone
+// because another operation failed.
+var ErrRolledBack = errors.New("rolled back")
+
+// BatchOp is one validated operation of a bulk request.
+type BatchOp struct {
+	Kind string // model.BatchOpCreate, model.BatchOpUpdate or model.BatchOpDelete
+	ID   string // update and delete
+
+	New NewTask // create
+
+	// Update
+	Patch        model.UpdateTaskRequest
+	PatchDueDate *time.Time
+
+	Cond     Precondition // update and delete
+	Children ChildrenMode // delete
+}
+
+// BatchResult is the outcome of one BatchOp. Task is unset for deletes and failures.
+type BatchResult struct {
+	Task model.Task
+	Err  error
+}
+
+// IsOpError reports whether err is a per-operation failure a batch can report
+// and carry on from, as opposed to an infrastructure error.
+func IsOpError(err error) bool {
+	for _, target := range []error{ErrNotFound, ErrConflict, ErrPreconditionFailed, ErrInvalidParent, ErrParentCycle, ErrHasChildren, ErrInvalidTransition, ErrInvalidProject} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkRolledBack marks every result of an all-or-nothing batch as rolled back, except
+// the failed operation which keeps its own error.
+func MarkRolledBack(results []BatchResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchResult{Err: ErrRolledBack}
+		}
+	}
+}
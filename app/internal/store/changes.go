@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+
+	"team5/task-manager/internal/model"
+)
+
+// ChangeLog reads the history of all the tasks of an owner, the change log
+// of GET /tasks/events. It includes the tasks currently in the shared
+// projects, those of other owners a member reads. Entry IDs grow with time
+// but may commit out of order.
+type ChangeLog interface {
+	// Changes returns up to limit entries of the owner's history after
+	// afterID, oldest first.
+	Changes(ctx context.Context, ownerID string, shared []string, afterID int64, limit int) ([]model.TaskHistoryEntry, error)
+	// Overlapping returns the owner's entries below id whose transactions may
+	// have committed after entry id, oldest first: a reader that got entry id
+	// may have missed them.
+	Overlapping(ctx context.Context, ownerID string, shared []string, id int64) ([]model.TaskHistoryEntry, error)
+	// ChangesByID returns the owner's entries among ids, oldest first.
+	ChangesByID(ctx context.Context, ownerID string, shared []string, ids []int64) ([]model.TaskHistoryEntry, error)
+}
+
+// ChangeFeed announces the history entries committed for an owner, on every
+// replica.
+type ChangeFeed interface {
+	// Subscribe returns the IDs of the new entries of the owners in tenant
+	// until cancel is called. The channel may close first, when
+	// notifications could be lost: the subscriber then catches up from the
+	// ChangeLog.
+	Subscribe(tenant string, ownerIDs ...string) (ids <-chan int64, cancel func())
+}
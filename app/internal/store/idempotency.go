@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// StaleReservation is how long an unfinished reservation blocks its key.
+// Past that, the request that made it is assumed dead and the key can be reclaimed.
+const StaleReservation = time.Minute
+
+// IdempotencyRecord is the outcome stored for an Idempotency-Key.
+// StatusCode is 0 while the original request is still in flight.
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+}
+
+// IdempotencyRepository persists Idempotency-Key outcomes per owner.
+type IdempotencyRepository interface {
+	// Reserve claims key for a new request. When a live record already holds
+	// the key, it is returned with reserved=false and nothing is written.
+	Reserve(ctx context.Context, ownerID, key, requestHash string, expiresAt time.Time) (rec IdempotencyRecord, reserved bool, err error)
+	// Complete stores the response of a reserved key.
+	Complete(ctx context.Context, ownerID, key string, statusCode int, body []byte) error
+	// Release drops a reservation whose request failed, so it can be retried.
+	Release(ctx context.Context, ownerID, key string) error
+	// DeleteExpired removes records expired at now and returns how many were removed.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"team5/task-manager/internal/model"
+)
+
+// ErrAlreadyMember: the invitee already has a role in the project
+var ErrAlreadyMember = errors.New("already a member")
+
+// MemberRepository shares projects: members act on the project and its tasks
+// as its owner, within the rights of their role. Apart from the access
+// lookups and the invitee's side of invitations, methods are scoped to the
+// project owner like ProjectRepository.
+type MemberRepository interface {
+	// ProjectAccess returns the owner of the project and the role of userID
+	// in it; ErrNotFound when the project is neither theirs nor shared with them.
+	ProjectAccess(ctx context.Context, userID, projectID string) (model.Access, error)
+	// TaskAccess is ProjectAccess for a live or trashed task, through its
+	// project for a member.
+	TaskAccess(ctx context.Context, userID, taskID string) (model.Access, error)
+	// SharedProjects returns the access of userID to each project of another
+	// owner they are a member of, by project id.
+	SharedProjects(ctx context.Context, userID string) ([]model.Access, error)
+
+	// ListMembers returns the owner, then the members by user id.
+	ListMembers(ctx context.Context, ownerID, projectID string) ([]model.Member, error)
+	// SetMemberRole changes the role of a member (ErrNotFound for anyone else).
+	SetMemberRole(ctx context.Context, ownerID, projectID, userID, role string) (model.Member, error)
+	RemoveMember(ctx context.Context, ownerID, projectID, userID string) error
+
+	// CreateInvitation invites in.Invitee with in.Role, replacing a pending
+	// invitation of the same user; ErrAlreadyMember for the owner or a member.
+	CreateInvitation(ctx context.Context, ownerID, projectID string, in model.Invitation) (model.Invitation, error)
+	ListInvitations(ctx context.Context, ownerID, projectID string) ([]model.Invitation, error)
+	DeleteInvitation(ctx context.Context, ownerID, projectID, id string) error
+
+	// PendingInvitations returns the invitations sent to invitee, oldest first.
+	PendingInvitations(ctx context.Context, invitee string) ([]model.Invitation, error)
+	// AcceptInvitation makes invitee a member; the invitation is consumed.
+	AcceptInvitation(ctx context.Context, invitee, id string) (model.Member, error)
+	DeclineInvitation(ctx context.Context, invitee, id string) error
+}
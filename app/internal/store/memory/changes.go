@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"team5/task-manager/internal/model"
+)
+
+func (s *TasksStore) Changes(ctx context.Context, ownerID string, shared []string, afterID int64, limit int) ([]model.TaskHistoryEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []model.TaskHistoryEntry{}
+	for _, r := range s.history {
+		if len(out) == limit {
+			break
+		}
+		if s.logs(r, ownerID, shared) && r.entry.ID > afterID {
+			out = append(out, r.entry)
+		}
+	}
+	return out, nil
+}
+
+// Overlapping finds nothing: mutations hold the write lock, so entries are
+// appended in the order of their IDs.
+func (s *TasksStore) Overlapping(ctx context.Context, ownerID string, shared []string, id int64) ([]model.TaskHistoryEntry, error) {
+	return []model.TaskHistoryEntry{}, ctx.Err()
+}
+
+func (s *TasksStore) ChangesByID(ctx context.Context, ownerID string, shared []string, ids []int64) ([]model.TaskHistoryEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []model.TaskHistoryEntry{}
+	for _, r := range s.history {
+		if s.logs(r, ownerID, shared) && slices.Contains(ids, r.entry.ID) {
+			out = append(out, r.entry)
+		}
+	}
+	return out, nil
+}
+
+// logs reports whether the change log of ownerID and the shared projects
+// has r: the task must be in one of them now. The caller holds the lock.
+func (s *TasksStore) logs(r historyRecord, ownerID string, shared []string) bool {
+	if r.ownerID == ownerID {
+		return true
+	}
+	t, ok := s.tasks[r.entry.TaskID]
+	return ok && reads(t, ownerID, shared)
+}
+
+// Subscribe makes the store its own store.ChangeFeed.
+func (s *TasksStore) Subscribe(tenant string, ownerIDs ...string) (<-chan int64, func()) {
+	return s.feed.Subscribe(tenant, ownerIDs...)
+}
+
+// publish announces the history entries appended from index n on, once the
+// mutation is final (a rolled back batch leaves none); the caller holds the
+// write lock.
+func (s *TasksStore) publish(n int) {
+	for _, r := range s.history[n:] {
+		s.feed.Publish(s.tenant, r.ownerID, r.entry.ID)
+	}
+}
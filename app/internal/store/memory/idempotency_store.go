@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"team5/task-manager/internal/store"
+)
+
+type idempotencyEntry struct {
+	rec       store.IdempotencyRecord
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// IdempotencyStore is the in-memory store.IdempotencyRepository.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[[3]string]idempotencyEntry // (tenant, owner, key)
+}
+
+var _ store.IdempotencyRepository = (*IdempotencyStore)(nil)
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{entries: make(map[[3]string]idempotencyEntry)}
+}
+
+func (s *IdempotencyStore) Reserve(ctx context.Context, ownerID, key, requestHash string, expiresAt time.Time) (store.IdempotencyRecord, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	k := [3]string{store.TenantFrom(ctx), ownerID, key}
+	if e, ok := s.entries[k]; ok {
+		abandoned := e.rec.StatusCode == 0 && e.createdAt.Before(now.Add(-store.StaleReservation))
+		if now.Before(e.expiresAt) && !abandoned {
+			return e.rec, false, nil
+		}
+	}
+
+	rec := store.IdempotencyRecord{RequestHash: requestHash}
+	s.entries[k] = idempotencyEntry{rec: rec, createdAt: now, expiresAt: expiresAt}
+	return rec, true, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, ownerID, key string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := [3]string{store.TenantFrom(ctx), ownerID, key}
+	if e, ok := s.entries[k]; ok {
+		e.rec.StatusCode = statusCode
+		e.rec.Body = append([]byte(nil), body...)
+		s.entries[k] = e
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, ownerID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := [3]string{store.TenantFrom(ctx), ownerID, key}
+	if e, ok := s.entries[k]; ok && e.rec.StatusCode == 0 {
+		delete(s.entries, k)
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+			n++
+		}
+	}
+	return n, nil
+}
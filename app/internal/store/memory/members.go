@@ -0,0 +1,291 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+var _ store.MemberRepository = (*ProjectStore)(nil)
+
+// access returns the role of userID in the project; the caller holds the lock.
+func (s *TasksStore) access(userID, projectID string) (model.Access, bool) {
+	p, ok := s.projects[projectID]
+	if !ok {
+		return model.Access{}, false
+	}
+	a := model.Access{OwnerID: p.OwnerID, ProjectID: projectID, Role: model.RoleOwner}
+	if p.OwnerID == userID {
+		return a, true
+	}
+	m, ok := s.members[projectID][userID]
+	a.Role = m.Role
+	return a, ok
+}
+
+// reads reports whether a listing of ownerID and the shared projects covers t.
+func reads(t model.Task, ownerID string, shared []string) bool {
+	return t.OwnerID == ownerID || (t.ProjectID != nil && slices.Contains(shared, *t.ProjectID))
+}
+
+func (s *ProjectStore) ProjectAccess(ctx context.Context, userID, projectID string) (model.Access, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Access{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	a, ok := s.tasks.access(userID, projectID)
+	if !ok {
+		return model.Access{}, store.ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *ProjectStore) TaskAccess(ctx context.Context, userID, taskID string) (model.Access, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Access{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	t, ok := s.tasks.tasks[taskID]
+	if !ok {
+		return model.Access{}, store.ErrNotFound
+	}
+	if t.OwnerID == userID {
+		a := model.Access{OwnerID: userID, Role: model.RoleOwner}
+		if t.ProjectID != nil {
+			a.ProjectID = *t.ProjectID
+		}
+		return a, nil
+	}
+	if t.ProjectID == nil {
+		return model.Access{}, store.ErrNotFound
+	}
+	a, ok := s.tasks.access(userID, *t.ProjectID)
+	if !ok {
+		return model.Access{}, store.ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *ProjectStore) SharedProjects(ctx context.Context, userID string) ([]model.Access, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	out := []model.Access{}
+	for id, members := range s.tasks.members {
+		if m, ok := members[userID]; ok {
+			out = append(out, model.Access{OwnerID: s.tasks.projects[id].OwnerID, ProjectID: id, Role: m.Role})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProjectID < out[j].ProjectID })
+	return out, nil
+}
+
+func (s *ProjectStore) ListMembers(ctx context.Context, ownerID, projectID string) ([]model.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	p, ok := s.tasks.projects[projectID]
+	if !ok || p.OwnerID != ownerID {
+		return nil, store.ErrNotFound
+	}
+	var members []model.Member
+	for _, m := range s.tasks.members[projectID] {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	owner := model.Member{ProjectID: projectID, UserID: ownerID, Role: model.RoleOwner, CreatedAt: p.CreatedAt}
+	return append([]model.Member{owner}, members...), nil
+}
+
+func (s *ProjectStore) SetMemberRole(ctx context.Context, ownerID, projectID, userID, role string) (model.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Member{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	if p, ok := s.tasks.projects[projectID]; !ok || p.OwnerID != ownerID {
+		return model.Member{}, store.ErrNotFound
+	}
+	m, ok := s.tasks.members[projectID][userID]
+	if !ok {
+		return model.Member{}, store.ErrNotFound
+	}
+	m.Role = role
+	s.tasks.members[projectID][userID] = m
+	return m, nil
+}
+
+func (s *ProjectStore) RemoveMember(ctx context.Context, ownerID, projectID, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	if p, ok := s.tasks.projects[projectID]; !ok || p.OwnerID != ownerID {
+		return store.ErrNotFound
+	}
+	if _, ok := s.tasks.members[projectID][userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.tasks.members[projectID], userID)
+	return nil
+}
+
+func (s *ProjectStore) CreateInvitation(ctx context.Context, ownerID, projectID string, in model.Invitation) (model.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Invitation{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	p, ok := s.tasks.projects[projectID]
+	if !ok || p.OwnerID != ownerID {
+		return model.Invitation{}, store.ErrNotFound
+	}
+	if _, ok := s.tasks.access(in.Invitee, projectID); ok {
+		return model.Invitation{}, store.ErrAlreadyMember
+	}
+	inv := model.Invitation{ID: uuid.NewString(), ProjectID: projectID, Invitee: in.Invitee, CreatedAt: now()}
+	for _, pending := range s.tasks.invitations {
+		if pending.ProjectID == projectID && pending.Invitee == in.Invitee {
+			inv = pending
+		}
+	}
+	inv.Role, inv.InvitedBy = in.Role, in.InvitedBy
+	s.tasks.invitations[inv.ID] = inv
+	inv.ProjectName = p.Name
+	return inv, nil
+}
+
+func (s *ProjectStore) ListInvitations(ctx context.Context, ownerID, projectID string) ([]model.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	if p, ok := s.tasks.projects[projectID]; !ok || p.OwnerID != ownerID {
+		return nil, store.ErrNotFound
+	}
+	return s.tasks.invitationsWhere(func(inv model.Invitation) bool { return inv.ProjectID == projectID }), nil
+}
+
+func (s *ProjectStore) DeleteInvitation(ctx context.Context, ownerID, projectID, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	inv, ok := s.tasks.invitations[id]
+	if !ok || inv.ProjectID != projectID || s.tasks.projects[projectID].OwnerID != ownerID {
+		return store.ErrNotFound
+	}
+	delete(s.tasks.invitations, id)
+	return nil
+}
+
+func (s *ProjectStore) PendingInvitations(ctx context.Context, invitee string) ([]model.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	return s.tasks.invitationsWhere(func(inv model.Invitation) bool { return inv.Invitee == invitee }), nil
+}
+
+// invitationsWhere returns the matching invitations, oldest first, with
+// their project names; the caller holds the lock.
+func (s *TasksStore) invitationsWhere(match func(model.Invitation) bool) []model.Invitation {
+	out := []model.Invitation{}
+	for _, inv := range s.invitations {
+		if match(inv) {
+			inv.ProjectName = s.projects[inv.ProjectID].Name
+			out = append(out, inv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (s *ProjectStore) AcceptInvitation(ctx context.Context, invitee, id string) (model.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Member{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	inv, ok := s.tasks.invitations[id]
+	if !ok || inv.Invitee != invitee {
+		return model.Member{}, store.ErrNotFound
+	}
+	delete(s.tasks.invitations, id)
+	m := model.Member{ProjectID: inv.ProjectID, UserID: invitee, Role: inv.Role, CreatedAt: now()}
+	if s.tasks.members[inv.ProjectID] == nil {
+		s.tasks.members[inv.ProjectID] = make(map[string]model.Member)
+	}
+	s.tasks.members[inv.ProjectID][invitee] = m
+	return m, nil
+}
+
+func (s *ProjectStore) DeclineInvitation(ctx context.Context, invitee, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	inv, ok := s.tasks.invitations[id]
+	if !ok || inv.Invitee != invitee {
+		return store.ErrNotFound
+	}
+	delete(s.tasks.invitations, id)
+	return nil
+}
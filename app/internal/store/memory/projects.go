@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// ProjectStore is the in-memory store.ProjectRepository over the projects of
+// a TasksStore.
+type ProjectStore struct {
+	tasks *TasksStore
+}
+
+var _ store.ProjectRepository = (*ProjectStore)(nil)
+
+func NewProjectStore(tasks *TasksStore) *ProjectStore {
+	return &ProjectStore{tasks: tasks}
+}
+
+// of returns the store over the partition of the caller's tenant.
+func (s *ProjectStore) of(ctx context.Context) *ProjectStore {
+	return &ProjectStore{tasks: s.tasks.of(ctx)}
+}
+
+// checkProject validates projectID as the project of a task of ownerID; the
+// caller holds the lock.
+func (s *TasksStore) checkProject(ownerID, projectID string) error {
+	if p, ok := s.projects[projectID]; !ok || p.OwnerID != ownerID {
+		return store.ErrInvalidProject
+	}
+	return nil
+}
+
+// inArchivedProject reports whether t belongs to an archived project; the
+// caller holds the lock.
+func (s *TasksStore) inArchivedProject(t model.Task) bool {
+	return t.ProjectID != nil && s.projects[*t.ProjectID].Archived
+}
+
+// project returns the caller's project with its task counts; the caller holds the lock.
+func (s *TasksStore) project(ownerID, id string) (model.Project, bool) {
+	p, ok := s.projects[id]
+	if !ok || p.OwnerID != ownerID {
+		return model.Project{}, false
+	}
+	p.Role = model.RoleOwner
+	today := now().Format("2006-01-02")
+	for _, t := range s.tasks {
+		if t.ProjectID != nil && *t.ProjectID == id && t.DeletedAt == nil {
+			p.TaskCounts.Count(t, today)
+		}
+	}
+	return p, true
+}
+
+func (s *ProjectStore) CreateProject(ctx context.Context, ownerID string, in model.Project) (model.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Project{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	ts := now()
+	p := model.Project{ID: uuid.NewString(), OwnerID: ownerID, Name: in.Name, Description: in.Description, CreatedAt: ts, UpdatedAt: ts}
+	s.tasks.projects[p.ID] = p
+	return p, nil
+}
+
+func (s *ProjectStore) ListProjects(ctx context.Context, userID string, includeArchived bool) ([]model.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	out := []model.Project{}
+	for id, p := range s.tasks.projects {
+		a, ok := s.tasks.access(userID, id)
+		if !ok || (p.Archived && !includeArchived) {
+			continue
+		}
+		p, _ = s.tasks.project(p.OwnerID, id)
+		p.Role = a.Role
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return strings.Compare(out[i].ID, out[j].ID) < 0
+	})
+	return out, nil
+}
+
+func (s *ProjectStore) GetProject(ctx context.Context, ownerID, id string) (model.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Project{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	p, ok := s.tasks.project(ownerID, id)
+	if !ok {
+		return model.Project{}, store.ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *ProjectStore) UpdateProject(ctx context.Context, ownerID, id string, patch model.UpdateProjectRequest) (model.Project, error) {
+	return s.change(ctx, ownerID, id, func(p *model.Project) {
+		if patch.Name != nil {
+			p.Name = *patch.Name
+		}
+		if patch.Description != nil {
+			p.Description = *patch.Description
+		}
+		p.UpdatedAt = now()
+	})
+}
+
+func (s *ProjectStore) SetArchived(ctx context.Context, ownerID, id string, archived bool) (model.Project, error) {
+	return s.change(ctx, ownerID, id, func(p *model.Project) {
+		if p.Archived == archived {
+			return
+		}
+		ts := now()
+		p.Archived, p.ArchivedAt, p.UpdatedAt = archived, nil, ts
+		if archived {
+			p.ArchivedAt = &ts
+		}
+	})
+}
+
+// change applies fn to the caller's project.
+func (s *ProjectStore) change(ctx context.Context, ownerID, id string, fn func(p *model.Project)) (model.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Project{}, err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	p, ok := s.tasks.projects[id]
+	if !ok || p.OwnerID != ownerID {
+		return model.Project{}, store.ErrNotFound
+	}
+	fn(&p)
+	s.tasks.projects[id] = p
+	p, _ = s.tasks.project(ownerID, id)
+	return p, nil
+}
+
+func (s *ProjectStore) DeleteProject(ctx context.Context, ownerID, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+
+	if p, ok := s.tasks.projects[id]; !ok || p.OwnerID != ownerID {
+		return store.ErrNotFound
+	}
+	for _, t := range s.tasks.tasks {
+		if t.ProjectID != nil && *t.ProjectID == id && t.DeletedAt == nil {
+			return store.ErrProjectNotEmpty
+		}
+	}
+	delete(s.tasks.projects, id)
+	delete(s.tasks.members, id)
+	for iid, inv := range s.tasks.invitations {
+		if inv.ProjectID == id {
+			delete(s.tasks.invitations, iid)
+		}
+	}
+	// Like the ON DELETE SET NULL foreign key
+	for tid, t := range s.tasks.tasks {
+		if t.ProjectID != nil && *t.ProjectID == id {
+			t.ProjectID = nil
+			s.tasks.tasks[tid] = t
+		}
+	}
+	return nil
+}
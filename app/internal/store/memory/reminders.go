@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// ReminderStore is the in-memory store.ReminderRepository over a TasksStore.
+type ReminderStore struct {
+	parts *tenants[*ReminderStore] // nil in a partition
+	tasks *TasksStore
+
+	mu        sync.Mutex
+	reminders map[string]*reminder
+	byDue     map[string]string // task id + due date -> reminder id
+}
+
+type reminder struct {
+	id          string
+	taskID      string
+	dueDate     string
+	status      string
+	attempts    int
+	nextAttempt time.Time
+	lastError   string
+	createdAt   time.Time
+	sentAt      *time.Time
+}
+
+var _ store.ReminderRepository = (*ReminderStore)(nil)
+
+// NewReminderStore returns the store over tasks, as returned by NewTasksStore.
+func NewReminderStore(tasks *TasksStore) *ReminderStore {
+	return &ReminderStore{tasks: tasks, parts: newTenants(func(tenant string) *ReminderStore {
+		return &ReminderStore{tasks: tasks.parts.get(tenant), reminders: make(map[string]*reminder), byDue: make(map[string]string)}
+	})}
+}
+
+func (s *ReminderStore) Enqueue(ctx context.Context, now, until time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	from, to := now.UTC().Format("2006-01-02"), until.UTC().Format("2006-01-02")
+
+	var n int64
+	for _, tasks := range s.tasks.partitions() {
+		n += s.parts.get(tasks.tenant).enqueue(now, from, to)
+	}
+	return n, nil
+}
+
+func (s *ReminderStore) enqueue(now time.Time, from, to string) int64 {
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for _, t := range s.tasks.tasks {
+		if t.DeletedAt != nil || !needsReminder(t) || t.DueDate < from || t.DueDate > to {
+			continue
+		}
+		key := t.ID + "/" + t.DueDate
+		if _, ok := s.byDue[key]; ok {
+			continue
+		}
+		r := &reminder{id: uuid.NewString(), taskID: t.ID, dueDate: t.DueDate, status: store.ReminderPending, nextAttempt: now, createdAt: now}
+		s.reminders[r.id] = r
+		s.byDue[key] = r.id
+		n++
+	}
+	return n
+}
+
+func (s *ReminderStore) Pending(ctx context.Context, now time.Time, limit int) ([]model.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var due []pendingReminder
+	for _, p := range s.parts.all() {
+		due = append(due, p.due(now, limit)...)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	out := make([]model.Reminder, 0, len(due))
+	for _, r := range due {
+		out = append(out, r.Reminder)
+	}
+	return out, nil
+}
+
+type pendingReminder struct {
+	nextAttempt time.Time
+	model.Reminder
+}
+
+// due returns the first limit reminders of the partition due at now.
+func (s *ReminderStore) due(now time.Time, limit int) []pendingReminder {
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*reminder
+	for _, r := range s.reminders {
+		if r.status != store.ReminderPending || r.nextAttempt.After(now) {
+			continue
+		}
+		t, ok := s.tasks.tasks[r.taskID]
+		if !ok {
+			// Purged along with its task, like the ON DELETE CASCADE
+			delete(s.reminders, r.id)
+			delete(s.byDue, r.taskID+"/"+r.dueDate)
+			continue
+		}
+		if t.DeletedAt != nil || !needsReminder(t) || t.DueDate != r.dueDate {
+			r.status = store.ReminderSkipped
+			continue
+		}
+		due = append(due, r)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].id < due[j].id
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	out := make([]pendingReminder, 0, len(due))
+	for _, r := range due {
+		out = append(out, pendingReminder{nextAttempt: r.nextAttempt, Reminder: model.Reminder{
+			ID:        r.id,
+			Type:      model.EventTaskDueSoon,
+			Attempt:   r.attempts + 1,
+			CreatedAt: r.createdAt,
+			Task:      s.tasks.view(s.tasks.tasks[r.taskID]),
+		}})
+	}
+	return out
+}
+
+func (s *ReminderStore) MarkSent(ctx context.Context, id string, at time.Time) error {
+	s.update(id, func(r *reminder) {
+		r.status, r.sentAt, r.lastError = store.ReminderSent, &at, ""
+		r.attempts++
+	})
+	return nil
+}
+
+func (s *ReminderStore) MarkFailed(ctx context.Context, id string, attempt int, retryAt *time.Time, reason string) error {
+	s.update(id, func(r *reminder) {
+		r.attempts, r.lastError = attempt, reason
+		if retryAt == nil {
+			r.status = store.ReminderFailed
+		} else {
+			r.nextAttempt = *retryAt
+		}
+	})
+	return nil
+}
+
+// update applies fn to the reminder id, in whichever partition holds it.
+func (s *ReminderStore) update(id string, fn func(r *reminder)) {
+	for _, p := range s.parts.all() {
+		p.mu.Lock()
+		r, ok := p.reminders[id]
+		if ok {
+			fn(r)
+		}
+		p.mu.Unlock()
+		if ok {
+			return
+		}
+	}
+}
+
+// needsReminder reports whether a task is still open.
+func needsReminder(t model.Task) bool {
+	return t.Status != model.StatusDone && t.Status != model.StatusCancelled
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"team5/task-manager/internal/model"
+)
+
+// Search approximates the Postgres ranking without stemming or stop words: a
+// word matches a whole word, a prefix the start of one, a phrase consecutive
+// words. Title matches weigh more than content matches.
+func (s *TasksStore) Search(ctx context.Context, ownerID string, p model.SearchParams) ([]model.SearchHit, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []model.SearchHit
+	for _, t := range s.tasks {
+		if !reads(t, ownerID, p.Shared) || t.DeletedAt != nil {
+			continue
+		}
+		title, content := tokenize(t.Title), tokenize(t.Content)
+		rank, inContent, ok := 0.0, false, true
+		for _, term := range p.Terms {
+			q := words(tokenize(term.Text))
+			if len(q) == 0 {
+				continue
+			}
+			inT, inC := len(find(title, q, term.Prefix)) > 0, len(find(content, q, term.Prefix)) > 0
+			if !inT && !inC {
+				ok = false
+				break
+			}
+			if inT {
+				rank += 1
+			}
+			if inC {
+				rank += 0.4
+				inContent = true
+			}
+		}
+		if !ok {
+			continue
+		}
+		field, tokens := t.Title, title
+		if inContent {
+			field, tokens = t.Content, content
+		}
+		hits = append(hits, model.SearchHit{Task: s.view(t), Rank: rank, Snippet: highlight(field, tokens, p.Terms)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	if p.Offset >= len(hits) {
+		return []model.SearchHit{}, false, nil
+	}
+	hits = hits[p.Offset:]
+	more := len(hits) > p.Limit
+	if more {
+		hits = hits[:p.Limit]
+	}
+	return hits, more, nil
+}
+
+// token is a lowercased word and its byte span in the original text.
+type token struct {
+	word       string
+	start, end int
+}
+
+func tokenize(s string) []token {
+	var out []token
+	start := -1
+	for i, r := range s + " " {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			out = append(out, token{word: strings.ToLower(s[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	return out
+}
+
+func words(tokens []token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.word
+	}
+	return out
+}
+
+// find returns the positions where q starts in tokens; with prefix, the last
+// word of q only has to start a token.
+func find(tokens []token, q []string, prefix bool) []int {
+	var at []int
+	for i := 0; i+len(q) <= len(tokens); i++ {
+		ok := true
+		for j, w := range q {
+			got := tokens[i+j].word
+			if got != w && !(prefix && j == len(q)-1 && strings.HasPrefix(got, w)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			at = append(at, i)
+		}
+	}
+	return at
+}
+
+// highlight escapes text and wraps every word matched by terms in <mark>.
+func highlight(text string, tokens []token, terms []model.SearchTerm) string {
+	marked := make([]bool, len(tokens))
+	for _, term := range terms {
+		q := words(tokenize(term.Text))
+		for _, i := range find(tokens, q, term.Prefix) {
+			for j := range q {
+				marked[i+j] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, t := range tokens {
+		if !marked[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:t.start]))
+		b.WriteString("<mark>" + html.EscapeString(text[t.start:t.end]) + "</mark>")
+		last = t.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
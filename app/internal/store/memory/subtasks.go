@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// view returns t with its computed progress; the caller holds the lock.
+func (s *TasksStore) view(t model.Task) model.Task {
+	var total, done int
+	for _, c := range s.children(t.ID) {
+		total++
+		if c.Done {
+			done++
+		}
+	}
+	t.Progress = nil
+	if total > 0 {
+		p := 100 * done / total
+		t.Progress = &p
+	}
+	return t
+}
+
+// children returns the live direct subtasks of id, oldest first.
+func (s *TasksStore) children(id string) []model.Task {
+	var out []model.Task
+	for _, t := range s.tasks {
+		if t.ParentID != nil && *t.ParentID == id && t.DeletedAt == nil {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := out[i].CreatedAt.Compare(out[j].CreatedAt); c != 0 {
+			return c < 0
+		}
+		return strings.Compare(out[i].ID, out[j].ID) < 0
+	})
+	return out
+}
+
+// checkParent validates parentID as the parent of task id ("" for a task being created).
+func (s *TasksStore) checkParent(ownerID, id, parentID string) error {
+	parent, ok := s.live(ownerID, parentID)
+	if !ok {
+		return store.ErrInvalidParent
+	}
+	for a := &parent; id != ""; {
+		if a.ID == id {
+			return store.ErrParentCycle
+		}
+		if a.ParentID == nil {
+			break
+		}
+		next, ok := s.tasks[*a.ParentID]
+		if !ok {
+			break
+		}
+		a = &next
+	}
+	return nil
+}
+
+func (s *TasksStore) deleteLocked(ctx context.Context, ownerID, id string, cond store.Precondition, children store.ChildrenMode) error {
+	t, ok := s.live(ownerID, id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if err := cond.Check(t.LastRequestTimestamp, t.Version); err != nil {
+		return err
+	}
+	if children == store.ChildrenBlock && len(s.children(id)) > 0 {
+		return store.ErrHasChildren
+	}
+	if _, err := s.setDeletedLocked(ctx, ownerID, id, true, cond); err != nil {
+		return err
+	}
+
+	switch children {
+	case store.ChildrenCascade:
+		for _, c := range s.children(id) {
+			if p := cond.CascadeProject; p != nil && (c.ProjectID == nil || *c.ProjectID != *p) {
+				continue
+			}
+			// Subtasks are trashed unconditionally, their own preconditions do not apply
+			if err := s.deleteLocked(ctx, ownerID, c.ID, store.Precondition{CascadeProject: cond.CascadeProject}, store.ChildrenCascade); err != nil {
+				return err
+			}
+		}
+	case store.ChildrenOrphan, "":
+		for _, c := range s.children(id) {
+			before := c
+			c.ParentID = nil
+			c.Version++
+			c.UpdatedAt = now()
+			s.tasks[c.ID] = c
+			s.record(ctx, model.ActionUpdated, &before, c, nil)
+		}
+	}
+	return nil
+}
+
+func (s *TasksStore) Tree(ctx context.Context, ownerID, id string) (model.TaskNode, error) {
+	if err := ctx.Err(); err != nil {
+		return model.TaskNode{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	root, ok := s.live(ownerID, id)
+	if !ok {
+		return model.TaskNode{}, store.ErrNotFound
+	}
+	tasks := []model.Task{s.view(root)}
+	for i := 0; i < len(tasks); i++ {
+		for _, c := range s.children(tasks[i].ID) {
+			tasks = append(tasks, s.view(c))
+		}
+	}
+	node, _ := store.BuildTree(id, tasks)
+	return node, nil
+}
@@ -0,0 +1,565 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/events"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// TasksStore is an in-memory store.TaskRepository with the same semantics as
+// the Postgres one. Meant for tests and local runs; nothing is persisted.
+// Like the Postgres stores it only shows the rows of the caller's tenant (see
+// store.WithTenant): each tenant has its own partition.
+type TasksStore struct {
+	parts  *tenants[*TasksStore] // nil in a partition
+	tenant string
+	seq    *atomic.Int64 // history ids, unique across tenants like the feed they are published on
+
+	mu      sync.RWMutex
+	tasks   map[string]model.Task
+	history []historyRecord
+	outbox  []model.TaskEvent // webhook events, read by WebhookStore
+	feed    *events.Broker
+	// projects are managed by ProjectStore, under mu like the tasks joining them
+	projects    map[string]model.Project
+	members     map[string]map[string]model.Member // by project, then user
+	invitations map[string]model.Invitation
+}
+
+type historyRecord struct {
+	ownerID string
+	entry   model.TaskHistoryEntry
+}
+
+var (
+	_ store.TaskRepository = (*TasksStore)(nil)
+	_ store.ChangeLog      = (*TasksStore)(nil)
+	_ store.ChangeFeed     = (*TasksStore)(nil)
+)
+
+func NewTasksStore() *TasksStore {
+	s := &TasksStore{feed: events.NewBroker(0), seq: new(atomic.Int64)}
+	s.parts = newTenants(func(tenant string) *TasksStore {
+		return &TasksStore{tenant: tenant, seq: s.seq, feed: s.feed, tasks: make(map[string]model.Task), projects: make(map[string]model.Project), members: make(map[string]map[string]model.Member), invitations: make(map[string]model.Invitation)}
+	})
+	return s
+}
+
+// of returns the partition of the caller's tenant.
+func (s *TasksStore) of(ctx context.Context) *TasksStore {
+	if s.parts == nil {
+		return s
+	}
+	return s.parts.of(ctx)
+}
+
+// partitions returns the partitions of every tenant, for the background jobs.
+func (s *TasksStore) partitions() []*TasksStore {
+	if s.parts == nil {
+		return []*TasksStore{s}
+	}
+	return s.parts.all()
+}
+
+// now mirrors Postgres timestamptz precision so cursors round-trip identically.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (s *TasksStore) Create(ctx context.Context, ownerID string, in store.NewTask) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.publish(len(s.history))
+	return s.create(ctx, ownerID, in)
+}
+
+// create, update, setDeletedLocked and deleteLocked expect the write lock to be held.
+func (s *TasksStore) create(ctx context.Context, ownerID string, in store.NewTask) (model.Task, error) {
+	if in.ParentID != "" {
+		if err := s.checkParent(ownerID, "", in.ParentID); err != nil {
+			return model.Task{}, err
+		}
+	}
+	if in.ProjectID != "" {
+		if err := s.checkProject(ownerID, in.ProjectID); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	ts := now()
+	t := model.Task{
+		ID:                   uuid.NewString(),
+		OwnerID:              ownerID,
+		Title:                in.Title,
+		Content:              in.Content,
+		DueDate:              in.DueDate.Format("2006-01-02"),
+		LastRequestTimestamp: in.RequestTimestamp.UTC().Truncate(time.Microsecond),
+		Version:              1,
+		CreatedAt:            ts,
+		UpdatedAt:            ts,
+		Tags:                 append([]string{}, in.Tags...),
+		Status:               in.Status,
+		Priority:             in.Priority,
+		Recurrence:           in.Recurrence,
+	}
+	if in.Recurrence != "" {
+		start := in.RecurrenceStart
+		if start.IsZero() {
+			start = in.DueDate
+		}
+		t.RecurrenceStart = start.Format("2006-01-02")
+	}
+	if t.Status == "" {
+		t.Status = model.StatusTodo
+	}
+	if t.Priority == "" {
+		t.Priority = model.PriorityMedium
+	}
+	t.Done = t.Status == model.StatusDone
+	if in.ParentID != "" {
+		t.ParentID = &in.ParentID
+	}
+	if in.ProjectID != "" {
+		t.ProjectID = &in.ProjectID
+	}
+	s.tasks[t.ID] = t
+	s.record(ctx, model.ActionCreated, nil, t, &in.RequestTimestamp)
+	return s.view(t), nil
+}
+
+func (s *TasksStore) List(ctx context.Context, ownerID string, p model.ListTasksParams) ([]model.Task, *model.TaskCursor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type keyed struct {
+		task model.Task
+		cur  model.TaskCursor
+	}
+	var matched []keyed
+	for _, t := range s.tasks {
+		if !reads(t, ownerID, p.Shared) || (t.DeletedAt != nil) != p.Trashed || !matches(t, p) {
+			continue
+		}
+		if p.ProjectID == nil && !p.Trashed && !p.IncludeArchived && s.inArchivedProject(t) {
+			continue
+		}
+		k := keyed{task: t, cur: model.NewTaskCursor(t, p.Sort, p.Desc)}
+		if p.After != nil && !after(k.cur, *p.After, p.Desc) {
+			continue
+		}
+		matched = append(matched, k)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return after(matched[j].cur, matched[i].cur, p.Desc)
+	})
+
+	out := make([]model.Task, 0, p.Limit)
+	for i := 0; i < len(matched) && i < p.Limit; i++ {
+		out = append(out, s.view(matched[i].task))
+	}
+	if len(matched) <= p.Limit {
+		return out, nil, nil
+	}
+	next := model.NewTaskCursor(out[len(out)-1], p.Sort, p.Desc)
+	return out, &next, nil
+}
+
+func matches(t model.Task, p model.ListTasksParams) bool {
+	if len(p.Statuses) > 0 && !slices.Contains(p.Statuses, t.Status) {
+		return false
+	}
+	if len(p.Priorities) > 0 && !slices.Contains(p.Priorities, t.Priority) {
+		return false
+	}
+	if p.Done != nil && t.Done != *p.Done {
+		return false
+	}
+	if p.ParentID != nil && (t.ParentID == nil || *t.ParentID != *p.ParentID) {
+		return false
+	}
+	if p.ProjectID != nil && (t.ProjectID == nil || *t.ProjectID != *p.ProjectID) {
+		return false
+	}
+	if len(p.Tags) > 0 {
+		n := 0
+		for _, tag := range p.Tags {
+			if slices.Contains(t.Tags, tag) {
+				n++
+			}
+		}
+		if n == 0 || (p.TagsAll && n < len(p.Tags)) {
+			return false
+		}
+	}
+	due, _ := time.Parse("2006-01-02", t.DueDate)
+	if p.DueBefore != nil && !due.Before(*p.DueBefore) {
+		return false
+	}
+	if p.DueAfter != nil && !due.After(*p.DueAfter) {
+		return false
+	}
+	if p.Query != "" {
+		q := strings.ToLower(p.Query)
+		if !strings.Contains(strings.ToLower(t.Title), q) && !strings.Contains(strings.ToLower(t.Content), q) {
+			return false
+		}
+	}
+	return true
+}
+
+// after reports whether a comes strictly after b in (value, id) order.
+func after(a, b model.TaskCursor, desc bool) bool {
+	c := a.Value.Compare(b.Value)
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if desc {
+		return c < 0
+	}
+	return c > 0
+}
+
+func (s *TasksStore) Get(ctx context.Context, ownerID, id string) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.live(ownerID, id)
+	if !ok {
+		return model.Task{}, store.ErrNotFound
+	}
+	return s.view(t), nil
+}
+
+// lookup returns the caller's task when it is in the wanted state (trashed or live).
+func (s *TasksStore) lookup(ownerID, id string, trashed bool) (model.Task, bool) {
+	t, ok := s.tasks[id]
+	if !ok || t.OwnerID != ownerID || (t.DeletedAt != nil) != trashed {
+		return model.Task{}, false
+	}
+	return t, true
+}
+
+func (s *TasksStore) live(ownerID, id string) (model.Task, bool) {
+	return s.lookup(ownerID, id, false)
+}
+
+func (s *TasksStore) Update(ctx context.Context, ownerID, id string, patch model.UpdateTaskRequest, dueDate *time.Time, cond store.Precondition) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.publish(len(s.history))
+	return s.update(ctx, ownerID, id, patch, dueDate, cond)
+}
+
+func (s *TasksStore) update(ctx context.Context, ownerID, id string, patch model.UpdateTaskRequest, dueDate *time.Time, cond store.Precondition) (model.Task, error) {
+	t, ok := s.live(ownerID, id)
+	if !ok {
+		return model.Task{}, store.ErrNotFound
+	}
+	if err := cond.Check(t.LastRequestTimestamp, t.Version); err != nil {
+		return model.Task{}, err
+	}
+	if patch.ParentID != nil && *patch.ParentID != "" {
+		if err := s.checkParent(ownerID, id, *patch.ParentID); err != nil {
+			return model.Task{}, err
+		}
+	}
+	if patch.ProjectID != nil && *patch.ProjectID != "" {
+		if err := s.checkProject(ownerID, *patch.ProjectID); err != nil {
+			return model.Task{}, err
+		}
+	}
+	status := store.NextStatus(t.Status, patch)
+	if err := cond.CheckTransition(t.Status, status); err != nil {
+		return model.Task{}, err
+	}
+	before := t
+
+	if patch.Title != nil {
+		t.Title = *patch.Title
+	}
+	if patch.Content != nil {
+		t.Content = *patch.Content
+	}
+	if dueDate != nil {
+		t.DueDate = dueDate.Format("2006-01-02")
+	}
+	t.Status, t.Done = status, status == model.StatusDone
+	if patch.Priority != nil {
+		t.Priority = *patch.Priority
+	}
+	if patch.Tags != nil {
+		t.Tags = append([]string{}, *patch.Tags...)
+	}
+	if patch.ParentID != nil {
+		t.ParentID = nil
+		if *patch.ParentID != "" {
+			parent := *patch.ParentID
+			t.ParentID = &parent
+		}
+	}
+	if patch.ProjectID != nil {
+		t.ProjectID = nil
+		if *patch.ProjectID != "" {
+			project := *patch.ProjectID
+			t.ProjectID = &project
+		}
+	}
+	t.Recurrence, t.RecurrenceStart = store.Recurrence(t, patch)
+	occurrence := t
+	completes := store.Completes(before.Status, t.Status, t.Recurrence)
+	if completes {
+		t.Recurrence, t.RecurrenceStart = "", ""
+	}
+	if cond.RequestTimestamp != nil {
+		t.LastRequestTimestamp = cond.RequestTimestamp.UTC().Truncate(time.Microsecond)
+	}
+	t.Version++
+	t.UpdatedAt = now()
+
+	s.tasks[id] = t
+	s.record(ctx, model.ActionUpdated, &before, t, cond.RequestTimestamp)
+
+	if completes {
+		occurrence.LastRequestTimestamp = t.LastRequestTimestamp
+		if next, ok := store.NextOccurrence(occurrence); ok {
+			if _, err := s.create(ctx, ownerID, next); err != nil {
+				return model.Task{}, err
+			}
+		}
+	}
+	return s.view(t), nil
+}
+
+func (s *TasksStore) Delete(ctx context.Context, ownerID, id string, cond store.Precondition, children store.ChildrenMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.publish(len(s.history))
+	return s.deleteLocked(ctx, ownerID, id, cond, children)
+}
+
+func (s *TasksStore) Restore(ctx context.Context, ownerID, id string, cond store.Precondition) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.publish(len(s.history))
+	t, err := s.setDeletedLocked(ctx, ownerID, id, false, cond)
+	if err != nil {
+		return model.Task{}, err
+	}
+	return s.view(t), nil
+}
+
+func (s *TasksStore) setDeletedLocked(ctx context.Context, ownerID, id string, deleted bool, cond store.Precondition) (model.Task, error) {
+	t, ok := s.lookup(ownerID, id, !deleted)
+	if !ok {
+		return model.Task{}, store.ErrNotFound
+	}
+	if err := cond.Check(t.LastRequestTimestamp, t.Version); err != nil {
+		return model.Task{}, err
+	}
+	before := t
+
+	ts := now()
+	t.DeletedAt = nil
+	if deleted {
+		t.DeletedAt = &ts
+	}
+	if cond.RequestTimestamp != nil {
+		t.LastRequestTimestamp = cond.RequestTimestamp.UTC().Truncate(time.Microsecond)
+	}
+	t.Version++
+	t.UpdatedAt = ts
+
+	s.tasks[id] = t
+	action := model.ActionRestored
+	if deleted {
+		action = model.ActionDeleted
+	}
+	s.record(ctx, action, &before, t, cond.RequestTimestamp)
+	return t, nil
+}
+
+func (s *TasksStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	for _, p := range s.partitions() {
+		n += p.purgeDeleted(before)
+	}
+	return n, nil
+}
+
+func (s *TasksStore) purgeDeleted(before time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, t := range s.tasks {
+		if t.DeletedAt != nil && t.DeletedAt.Before(before) {
+			delete(s.tasks, id)
+			n++
+		}
+	}
+	// Like the ON DELETE SET NULL foreign key
+	for id, t := range s.tasks {
+		if t.ParentID != nil {
+			if _, ok := s.tasks[*t.ParentID]; !ok {
+				t.ParentID = nil
+				s.tasks[id] = t
+			}
+		}
+	}
+	return n
+}
+
+// record appends a history entry and its webhook event; the caller holds the write lock.
+func (s *TasksStore) record(ctx context.Context, action string, before *model.Task, after model.Task, reqTS *time.Time) {
+	actor := store.ActorFrom(ctx, after.OwnerID)
+	e := model.TaskHistoryEntry{
+		ID:            s.seq.Add(1),
+		TaskID:        after.ID,
+		Actor:         actor.ID,
+		Action:        action,
+		CorrelationID: actor.CorrelationID,
+		Changes:       store.Diff(before, after),
+		Version:       after.Version,
+		CreatedAt:     after.UpdatedAt,
+	}
+	if reqTS != nil {
+		ts := reqTS.UTC().Truncate(time.Microsecond)
+		e.RequestTimestamp = &ts
+	}
+	s.history = append(s.history, historyRecord{ownerID: after.OwnerID, entry: e})
+	s.outbox = append(s.outbox, model.TaskEvent{ID: uuid.NewString(), Type: store.EventType(action), CreatedAt: after.UpdatedAt, Task: s.view(after)})
+}
+
+func (s *TasksStore) History(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.TaskHistoryEntry, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.TaskHistoryEntry, 0, p.Limit)
+	for _, r := range s.history {
+		e := r.entry
+		if e.TaskID != id || r.ownerID != ownerID || e.ID <= p.AfterID {
+			continue
+		}
+		if len(out) == p.Limit {
+			return out, out[len(out)-1].ID, nil
+		}
+		out = append(out, e)
+	}
+	// An empty first page means the task never existed for this caller
+	if len(out) == 0 && p.AfterID == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+	return out, 0, nil
+}
+
+func (s *TasksStore) Batch(ctx context.Context, ownerID string, ops []store.BatchOp, atomic bool) ([]store.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.publish(len(s.history))
+
+	// An atomic batch is undone by restoring this snapshot
+	tasks := make(map[string]model.Task, len(s.tasks))
+	for id, t := range s.tasks {
+		tasks[id] = t
+	}
+	history, outbox := len(s.history), len(s.outbox)
+
+	results := make([]store.BatchResult, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case model.BatchOpCreate:
+			results[i].Task, results[i].Err = s.create(ctx, ownerID, op.New)
+		case model.BatchOpUpdate:
+			results[i].Task, results[i].Err = s.update(ctx, ownerID, op.ID, op.Patch, op.PatchDueDate, op.Cond)
+		case model.BatchOpDelete:
+			results[i].Err = s.deleteLocked(ctx, ownerID, op.ID, op.Cond, op.Children)
+		default:
+			s.tasks, s.history, s.outbox = tasks, s.history[:history], s.outbox[:outbox]
+			return nil, fmt.Errorf("unknown batch operation %q", op.Kind)
+		}
+		if results[i].Err != nil && atomic {
+			s.tasks, s.history, s.outbox = tasks, s.history[:history], s.outbox[:outbox]
+			store.MarkRolledBack(results)
+			return results, nil
+		}
+	}
+	return results, nil
+}
+
+func (s *TasksStore) Tags(ctx context.Context, ownerID string, shared []string) ([]model.TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, t := range s.tasks {
+		if !reads(t, ownerID, shared) || t.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+	out := make([]model.TagCount, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, model.TagCount{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/store/storetest"
+)
+
+func TestTasksStore(t *testing.T) {
+	storetest.RunTaskRepository(t, func(t *testing.T) store.TaskRepository {
+		return NewTasksStore()
+	})
+}
+
+func TestReminderStore(t *testing.T) {
+	storetest.RunReminderRepository(t, func(t *testing.T) (store.TaskRepository, store.ReminderRepository) {
+		tasks := NewTasksStore()
+		return tasks, NewReminderStore(tasks)
+	})
+}
+
+func TestWebhookStore(t *testing.T) {
+	storetest.RunWebhookRepository(t, func(t *testing.T) (store.TaskRepository, storetest.WebhookStore) {
+		tasks := NewTasksStore()
+		return tasks, NewWebhookStore(tasks)
+	})
+}
+
+func TestChangeLog(t *testing.T) {
+	storetest.RunChangeLog(t, func(t *testing.T) (store.TaskRepository, store.ChangeLog) {
+		tasks := NewTasksStore()
+		return tasks, tasks
+	})
+}
+
+func TestProjectStore(t *testing.T) {
+	storetest.RunProjectRepository(t, func(t *testing.T) (store.TaskRepository, store.ProjectRepository) {
+		tasks := NewTasksStore()
+		return tasks, NewProjectStore(tasks)
+	})
+}
+
+func TestMemberStore(t *testing.T) {
+	storetest.RunMemberRepository(t, func(t *testing.T) (store.TaskRepository, store.ProjectRepository, store.MemberRepository) {
+		tasks := NewTasksStore()
+		projects := NewProjectStore(tasks)
+		return tasks, projects, projects
+	})
+}
+
+func TestBackgroundJobsSpanTenants(t *testing.T) {
+	tasks := NewTasksStore()
+	reminders, hooks := NewReminderStore(tasks), NewWebhookStore(tasks)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, tenant := range []string{"acme", "globex"} {
+		tctx := store.WithTenant(ctx, tenant)
+		if _, err := hooks.CreateWebhook(tctx, "alice", model.WebhookSubscription{URL: "https://example.com/" + tenant}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tasks.Create(tctx, "alice", store.NewTask{Title: tenant, Content: "c", DueDate: now.AddDate(0, 0, 1), RequestTimestamp: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := reminders.Enqueue(ctx, now, now.Add(48*time.Hour)); err != nil || n != 2 {
+		t.Fatalf("Enqueue = %d, %v; want 2", n, err)
+	}
+	due, err := reminders.Pending(ctx, now, 10)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("Pending = %d reminders, %v; want 2", len(due), err)
+	}
+	for _, r := range due {
+		if err := reminders.MarkSent(ctx, r.ID, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if due, _ := reminders.Pending(ctx, now, 10); len(due) != 0 {
+		t.Errorf("Pending after MarkSent = %d reminders", len(due))
+	}
+
+	if n, err := hooks.Fanout(ctx, now, 10); err != nil || n != 2 {
+		t.Fatalf("Fanout = %d, %v; want 2", n, err)
+	}
+	deliveries, err := hooks.Pending(ctx, now, 10)
+	if err != nil || len(deliveries) != 2 {
+		t.Fatalf("Pending = %d deliveries, %v; want 2", len(deliveries), err)
+	}
+	// Each tenant's event only goes to its own subscription
+	for _, d := range deliveries {
+		if want := "https://example.com/" + d.Event.Task.Title; d.URL != want {
+			t.Errorf("event of %s delivered to %s, want %s", d.Event.Task.Title, d.URL, want)
+		}
+	}
+}
+
+func TestChangeFeedRoutesByTenant(t *testing.T) {
+	tasks := NewTasksStore()
+	ids, cancel := tasks.Subscribe("acme", "alice")
+	defer cancel()
+
+	now := time.Now().UTC()
+	if _, err := tasks.Create(store.WithTenant(context.Background(), "globex"), "alice", store.NewTask{Title: "globex", Content: "c", RequestTimestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.Create(store.WithTenant(context.Background(), "acme"), "alice", store.NewTask{Title: "acme", Content: "c", RequestTimestamp: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := tasks.Changes(store.WithTenant(context.Background(), "acme"), "alice", nil, 0, 10)
+	if err != nil || len(entries) != 1 || entries[0].TaskID != task.ID {
+		t.Fatalf("Changes = %+v, %v", entries, err)
+	}
+	if id := <-ids; id != entries[0].ID {
+		t.Errorf("announced %d, want %d of the subscriber's tenant only", id, entries[0].ID)
+	}
+}
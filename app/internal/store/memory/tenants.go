@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"team5/task-manager/internal/store"
+)
+
+// tenants holds one partition of a store per tenant, like the row level
+// security of the Postgres stores: the stores built by the New functions
+// only dispatch to the partition of the caller's tenant (store.TenantFrom),
+// and the background jobs visit them all.
+type tenants[T any] struct {
+	mu    sync.Mutex
+	parts map[string]T
+	new   func(tenant string) T
+}
+
+func newTenants[T any](newPart func(tenant string) T) *tenants[T] {
+	return &tenants[T]{parts: make(map[string]T), new: newPart}
+}
+
+// of returns the partition of the tenant of ctx, created on first use.
+func (t *tenants[T]) of(ctx context.Context) T {
+	return t.get(store.TenantFrom(ctx))
+}
+
+func (t *tenants[T]) get(tenant string) T {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.parts[tenant]
+	if !ok {
+		p = t.new(tenant)
+		t.parts[tenant] = p
+	}
+	return p
+}
+
+// all returns every partition, in tenant order.
+func (t *tenants[T]) all() []T {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.parts))
+	for name := range t.parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]T, len(names))
+	for i, name := range names {
+		out[i] = t.parts[name]
+	}
+	return out
+}
@@ -0,0 +1,366 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// WebhookStore is the in-memory store.WebhookRepository and
+// store.WebhookOutbox over the outbox of a TasksStore.
+type WebhookStore struct {
+	parts *tenants[*WebhookStore] // nil in a partition
+	tasks *TasksStore
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+	deliveries    map[string]*delivery
+	attempts      []attemptRecord
+	lastAttemptID int64
+	dispatched    int // events of tasks.outbox already fanned out
+}
+
+type subscription struct {
+	ownerID string
+	secret  string
+	model.WebhookSubscription
+}
+
+type delivery struct {
+	id             string
+	subscriptionID string
+	event          model.TaskEvent
+	status         string
+	attempts       int
+	nextAttempt    time.Time
+}
+
+type attemptRecord struct {
+	subscriptionID string
+	model.WebhookAttempt
+}
+
+var (
+	_ store.WebhookRepository = (*WebhookStore)(nil)
+	_ store.WebhookOutbox     = (*WebhookStore)(nil)
+)
+
+// NewWebhookStore returns the store over tasks, as returned by NewTasksStore.
+func NewWebhookStore(tasks *TasksStore) *WebhookStore {
+	return &WebhookStore{tasks: tasks, parts: newTenants(func(tenant string) *WebhookStore {
+		return &WebhookStore{tasks: tasks.parts.get(tenant), subscriptions: make(map[string]*subscription), deliveries: make(map[string]*delivery)}
+	})}
+}
+
+// of returns the partition of the caller's tenant.
+func (s *WebhookStore) of(ctx context.Context) *WebhookStore {
+	if s.parts == nil {
+		return s
+	}
+	return s.parts.of(ctx)
+}
+
+func (s *WebhookStore) CreateWebhook(ctx context.Context, ownerID string, in model.WebhookSubscription) (model.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := now()
+	w := model.WebhookSubscription{
+		ID:        uuid.NewString(),
+		URL:       in.URL,
+		Events:    append([]string{}, in.Events...),
+		Active:    true,
+		CreatedAt: ts,
+		UpdatedAt: ts,
+	}
+	s.subscriptions[w.ID] = &subscription{ownerID: ownerID, secret: in.Secret, WebhookSubscription: w}
+	w.Secret = in.Secret
+	return w, nil
+}
+
+func (s *WebhookStore) ListWebhooks(ctx context.Context, ownerID string) ([]model.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []model.WebhookSubscription{}
+	for _, w := range s.subscriptions {
+		if w.ownerID == ownerID {
+			out = append(out, w.WebhookSubscription)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (s *WebhookStore) GetWebhook(ctx context.Context, ownerID, id string) (model.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.lookup(ownerID, id)
+	if !ok {
+		return model.WebhookSubscription{}, store.ErrNotFound
+	}
+	return w.WebhookSubscription, nil
+}
+
+// lookup returns the caller's subscription; the caller holds the lock.
+func (s *WebhookStore) lookup(ownerID, id string) (*subscription, bool) {
+	w, ok := s.subscriptions[id]
+	if !ok || w.ownerID != ownerID {
+		return nil, false
+	}
+	return w, true
+}
+
+func (s *WebhookStore) UpdateWebhook(ctx context.Context, ownerID, id string, patch model.UpdateWebhookRequest) (model.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.lookup(ownerID, id)
+	if !ok {
+		return model.WebhookSubscription{}, store.ErrNotFound
+	}
+	if patch.URL != nil {
+		w.URL = *patch.URL
+	}
+	if patch.Events != nil {
+		w.Events = append([]string{}, *patch.Events...)
+	}
+	if patch.Active != nil {
+		w.Active = *patch.Active
+	}
+	if patch.Secret != nil {
+		w.secret = *patch.Secret
+	}
+	w.UpdatedAt = now()
+	return w.WebhookSubscription, nil
+}
+
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, ownerID, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(ownerID, id); !ok {
+		return store.ErrNotFound
+	}
+	delete(s.subscriptions, id)
+	// Like the ON DELETE CASCADE foreign keys
+	for did, d := range s.deliveries {
+		if d.subscriptionID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	kept := s.attempts[:0]
+	for _, a := range s.attempts {
+		if a.subscriptionID != id {
+			kept = append(kept, a)
+		}
+	}
+	s.attempts = kept
+	return nil
+}
+
+func (s *WebhookStore) Attempts(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.WebhookAttempt, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	s = s.of(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(ownerID, id); !ok {
+		return nil, 0, store.ErrNotFound
+	}
+	out := make([]model.WebhookAttempt, 0, p.Limit)
+	for _, a := range s.attempts {
+		if a.subscriptionID != id || a.ID <= p.AfterID {
+			continue
+		}
+		if len(out) == p.Limit {
+			return out, out[len(out)-1].ID, nil
+		}
+		out = append(out, a.WebhookAttempt)
+	}
+	return out, 0, nil
+}
+
+// Fanout visits the tenants in turn, each with what is left of limit.
+func (s *WebhookStore) Fanout(ctx context.Context, now time.Time, limit int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	// Every tenant with tasks, so no event waits for a first subscription
+	for _, tasks := range s.tasks.partitions() {
+		if n == int64(limit) {
+			break
+		}
+		n += s.parts.get(tasks.tenant).fanout(now, limit-int(n))
+	}
+	return n, nil
+}
+
+func (s *WebhookStore) fanout(now time.Time, limit int) int64 {
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.tasks.outbox[s.dispatched:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	for _, e := range events {
+		for _, w := range s.subscriptions {
+			if w.ownerID != e.Task.OwnerID || !w.Active || (len(w.Events) > 0 && !slices.Contains(w.Events, e.Type)) {
+				continue
+			}
+			d := &delivery{id: uuid.NewString(), subscriptionID: w.ID, event: e, status: store.DeliveryPending, nextAttempt: now}
+			s.deliveries[d.id] = d
+		}
+	}
+	s.dispatched += len(events)
+	return int64(len(events))
+}
+
+func (s *WebhookStore) Pending(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var due []pendingDelivery
+	for _, p := range s.parts.all() {
+		due = append(due, p.due(now, limit)...)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	out := make([]model.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		out = append(out, d.WebhookDelivery)
+	}
+	return out, nil
+}
+
+type pendingDelivery struct {
+	nextAttempt time.Time
+	model.WebhookDelivery
+}
+
+// due returns the first limit deliveries of the partition due at now.
+func (s *WebhookStore) due(now time.Time, limit int) []pendingDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*delivery
+	for _, d := range s.deliveries {
+		if d.status == store.DeliveryPending && !d.nextAttempt.After(now) && s.subscriptions[d.subscriptionID].Active {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].id < due[j].id
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	out := make([]pendingDelivery, 0, len(due))
+	for _, d := range due {
+		w := s.subscriptions[d.subscriptionID]
+		out = append(out, pendingDelivery{nextAttempt: d.nextAttempt, WebhookDelivery: model.WebhookDelivery{
+			ID:             d.id,
+			SubscriptionID: w.ID,
+			Attempt:        d.attempts + 1,
+			URL:            w.URL,
+			Secret:         w.secret,
+			Event:          d.event,
+		}})
+	}
+	return out
+}
+
+func (s *WebhookStore) RecordAttempt(ctx context.Context, a model.WebhookAttempt, retryAt *time.Time) error {
+	for _, p := range s.parts.all() {
+		if p.recordAttempt(a, retryAt) {
+			break
+		}
+	}
+	return nil
+}
+
+// recordAttempt reports whether the delivery belongs to the partition.
+func (s *WebhookStore) recordAttempt(a model.WebhookAttempt, retryAt *time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[a.DeliveryID]
+	if !ok {
+		return false
+	}
+	d.attempts = a.Attempt
+	switch a.Outcome {
+	case model.AttemptDelivered:
+		d.status = store.DeliveryDelivered
+	case model.AttemptDead:
+		d.status = store.DeliveryDead
+	}
+	if retryAt != nil {
+		d.nextAttempt = *retryAt
+	}
+
+	s.lastAttemptID++
+	a.ID = s.lastAttemptID
+	a.EventID, a.EventType = d.event.ID, d.event.Type
+	s.attempts = append(s.attempts, attemptRecord{subscriptionID: d.subscriptionID, WebhookAttempt: a})
+	return true
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// errBatchFailed aborts the transaction of an atomic batch; the cause is in the results.
+var errBatchFailed = errors.New("batch operation failed")
+
+// Batch runs every operation in a single transaction. In best-effort mode each
+// operation gets its own savepoint so a failure only undoes itself.
+func (s *TasksStore) Batch(ctx context.Context, ownerID string, ops []store.BatchOp, atomic bool) ([]store.BatchResult, error) {
+	results := make([]store.BatchResult, len(ops))
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		for i, op := range ops {
+			if atomic {
+				results[i].Task, results[i].Err = s.applyOp(ctx, tx, ownerID, op)
+				if results[i].Err != nil {
+					if store.IsOpError(results[i].Err) {
+						return errBatchFailed
+					}
+					return results[i].Err
+				}
+				continue
+			}
+
+			sp, err := tx.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			results[i].Task, results[i].Err = s.applyOp(ctx, sp, ownerID, op)
+			if results[i].Err == nil {
+				err = sp.Commit(ctx)
+			} else if store.IsOpError(results[i].Err) {
+				err = sp.Rollback(ctx)
+			} else {
+				err = results[i].Err
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchFailed) {
+		store.MarkRolledBack(results)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *TasksStore) applyOp(ctx context.Context, tx pgx.Tx, ownerID string, op store.BatchOp) (model.Task, error) {
+	switch op.Kind {
+	case model.BatchOpCreate:
+		return s.createTask(ctx, tx, ownerID, op.New)
+	case model.BatchOpUpdate:
+		return s.updateTask(ctx, tx, ownerID, op.ID, op.Patch, op.PatchDueDate, op.Cond)
+	case model.BatchOpDelete:
+		return model.Task{}, deleteTask(ctx, tx, ownerID, op.ID, op.Cond, op.Children)
+	}
+	return model.Task{}, fmt.Errorf("unknown batch operation %q", op.Kind)
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// appendHistory records a mutation of after in the caller's transaction, so
+// the audit row and the webhook event commit or roll back with the change itself.
+func appendHistory(ctx context.Context, tx pgx.Tx, action string, before *model.Task, after model.Task, reqTS *time.Time) error {
+	changes, err := json.Marshal(store.Diff(before, after))
+	if err != nil {
+		return err
+	}
+	actor := store.ActorFrom(ctx, after.OwnerID)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO task_history (task_id, owner_id, actor, action, correlation_id, request_timestamp, changes, version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, after.ID, after.OwnerID, actor.ID, action, actor.CorrelationID, reqTS, changes, after.Version, after.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return appendEvent(ctx, tx, store.EventType(action), after)
+}
+
+// historyColumns is the select list matching queryHistory.
+const historyColumns = `id, task_id::text, actor, action, correlation_id, request_timestamp, changes, version, created_at`
+
+// queryHistory runs a query selecting historyColumns and reads every row.
+func queryHistory(ctx context.Context, q querier, sql string, args ...any) ([]model.TaskHistoryEntry, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.TaskHistoryEntry{}
+	for rows.Next() {
+		var e model.TaskHistoryEntry
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.Actor, &e.Action, &e.CorrelationID, &e.RequestTimestamp, &changes, &e.Version, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *TasksStore) History(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.TaskHistoryEntry, int64, error) {
+	out, err := queryHistory(ctx, s.pool, `
+		SELECT `+historyColumns+`
+		FROM task_history
+		WHERE task_id = $1 AND owner_id = $2 AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`, id, ownerID, p.AfterID, p.Limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(out) > p.Limit {
+		out = out[:p.Limit]
+		return out, out[len(out)-1].ID, nil
+	}
+	// An empty first page means the task never existed for this caller
+	if len(out) == 0 && p.AfterID == 0 {
+		return nil, 0, ErrNotFound
+	}
+	return out, 0, nil
+}
+
+// Changes is served by idx_task_history_owner_id.
+// inChangeLog is the condition on task_history of the change log of owner
+// $1 and the shared projects $2: the entries of the tasks now in them.
+const inChangeLog = `(owner_id = $1 OR task_id IN (SELECT id FROM tasks WHERE project_id = ANY($2::uuid[])))`
+
+func (s *TasksStore) Changes(ctx context.Context, ownerID string, shared []string, afterID int64, limit int) ([]model.TaskHistoryEntry, error) {
+	return queryHistory(ctx, s.pool, `
+		SELECT `+historyColumns+`
+		FROM task_history
+		WHERE `+inChangeLog+` AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`, ownerID, shared, afterID, limit)
+}
+
+// Overlapping is served by idx_task_history_owner_xact: the transactions of
+// the entries a reader of entry id may have missed were running when it was
+// written, so they are not older than its horizon.
+func (s *TasksStore) Overlapping(ctx context.Context, ownerID string, shared []string, id int64) ([]model.TaskHistoryEntry, error) {
+	return queryHistory(ctx, s.pool, `
+		SELECT `+historyColumns+`
+		FROM task_history
+		WHERE `+inChangeLog+` AND id < $3
+		  AND xact >= (SELECT xact_horizon FROM task_history WHERE id = $3 AND `+inChangeLog+`)
+		ORDER BY id
+	`, ownerID, shared, id)
+}
+
+func (s *TasksStore) ChangesByID(ctx context.Context, ownerID string, shared []string, ids []int64) ([]model.TaskHistoryEntry, error) {
+	return queryHistory(ctx, s.pool, `
+		SELECT `+historyColumns+`
+		FROM task_history
+		WHERE id = ANY($3) AND `+inChangeLog+`
+		ORDER BY id
+	`, ownerID, shared, ids)
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/store"
+)
+
+type IdempotencyStore struct {
+	pool *pgxpool.Pool
+}
+
+var _ store.IdempotencyRepository = (*IdempotencyStore)(nil)
+
+func NewIdempotencyStore(pool *pgxpool.Pool) *IdempotencyStore {
+	return &IdempotencyStore{pool: pool}
+}
+
+func (s *IdempotencyStore) Reserve(ctx context.Context, ownerID, key, requestHash string, expiresAt time.Time) (store.IdempotencyRecord, bool, error) {
+	// Expired records and abandoned reservations are taken over in place
+	var ignored string
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (owner_id, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, owner_id, key) DO UPDATE SET
+		  request_hash = EXCLUDED.request_hash,
+		  status_code = NULL,
+		  response_body = NULL,
+		  created_at = now(),
+		  expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $5)
+		RETURNING key
+	`, ownerID, key, requestHash, expiresAt, time.Now().Add(-store.StaleReservation)).Scan(&ignored)
+	if err == nil {
+		return store.IdempotencyRecord{RequestHash: requestHash}, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return store.IdempotencyRecord{}, false, err
+	}
+
+	var rec store.IdempotencyRecord
+	var status *int32
+	err = s.pool.QueryRow(ctx, `
+		SELECT request_hash, status_code, response_body
+		FROM idempotency_keys
+		WHERE owner_id = $1 AND key = $2
+	`, ownerID, key).Scan(&rec.RequestHash, &status, &rec.Body)
+	if err != nil {
+		// Deleted by the sweeper in between: let the client retry
+		if errors.Is(err, pgx.ErrNoRows) {
+			return store.IdempotencyRecord{}, false, store.ErrConflict
+		}
+		return store.IdempotencyRecord{}, false, err
+	}
+	if status != nil {
+		rec.StatusCode = int(*status)
+	}
+	return rec, false, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, ownerID, key string, statusCode int, body []byte) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE idempotency_keys SET status_code = $3, response_body = $4
+		WHERE owner_id = $1 AND key = $2
+	`, ownerID, key, statusCode, body)
+	return err
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, ownerID, key string) error {
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE owner_id = $1 AND key = $2 AND status_code IS NULL
+	`, ownerID, key)
+	return err
+}
+
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := s.pool.Exec(allTenants(ctx), `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/events"
+)
+
+// changesChannel is notified by the task_history_notify trigger.
+const changesChannel = "task_changes"
+
+// Listener relays the task_changes notifications of every replica to a
+// Broker, over a connection taken from the pool for as long as it runs.
+type Listener struct {
+	pool   *pgxpool.Pool
+	broker *events.Broker
+	log    *slog.Logger
+}
+
+func NewListener(pool *pgxpool.Pool, broker *events.Broker, log *slog.Logger) *Listener {
+	return &Listener{pool: pool, broker: broker, log: log.With("component", "task_changes_listener")}
+}
+
+// Run listens until ctx is done. Notifications sent while the connection is
+// down are lost, so every subscriber is disconnected when it drops and
+// catches up from the change log on reconnecting.
+func (l *Listener) Run(ctx context.Context) {
+	for {
+		err := l.listen(ctx)
+		l.broker.DisconnectAll()
+		if ctx.Err() != nil {
+			return
+		}
+		l.log.Error("listen failed, retrying", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A listening session must not go back to the pool, nor keep the tenant
+	// of its last user
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+	if err := clearTenant(ctx, conn); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+changesChannel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change struct {
+			ID       int64  `json:"id"`
+			TenantID string `json:"tenant_id"`
+			OwnerID  string `json:"owner_id"`
+		}
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			l.log.Warn("malformed notification", "payload", n.Payload, "error", err)
+			continue
+		}
+		l.broker.Publish(change.TenantID, change.OwnerID, change.ID)
+	}
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+var _ store.MemberRepository = (*ProjectStore)(nil)
+
+// invitationColumns is the select list of scanInvitation, over
+// project_invitations i joined with projects p.
+const invitationColumns = `i.id::text, i.project_id::text, p.name, i.invitee, i.role, i.invited_by, i.created_at`
+
+func scanInvitation(row pgx.Row) (model.Invitation, error) {
+	var inv model.Invitation
+	err := row.Scan(&inv.ID, &inv.ProjectID, &inv.ProjectName, &inv.Invitee, &inv.Role, &inv.InvitedBy, &inv.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return inv, ErrNotFound
+	}
+	return inv, err
+}
+
+func scanMember(row pgx.Row) (model.Member, error) {
+	var m model.Member
+	err := row.Scan(&m.ProjectID, &m.UserID, &m.Role, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return m, ErrNotFound
+	}
+	return m, err
+}
+
+func (s *ProjectStore) ProjectAccess(ctx context.Context, userID, projectID string) (model.Access, error) {
+	if _, err := uuid.Parse(projectID); err != nil {
+		return model.Access{}, ErrNotFound
+	}
+	a := model.Access{ProjectID: projectID}
+	err := s.pool.QueryRow(ctx, `
+		SELECT p.owner_id, CASE WHEN p.owner_id = $2 THEN 'owner' ELSE m.role END
+		FROM projects p
+		LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+		WHERE p.id = $1 AND (p.owner_id = $2 OR m.user_id IS NOT NULL)
+	`, projectID, userID).Scan(&a.OwnerID, &a.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *ProjectStore) TaskAccess(ctx context.Context, userID, taskID string) (model.Access, error) {
+	if _, err := uuid.Parse(taskID); err != nil {
+		return model.Access{}, ErrNotFound
+	}
+	var a model.Access
+	err := s.pool.QueryRow(ctx, `
+		SELECT t.owner_id, COALESCE(t.project_id::text, ''), CASE WHEN t.owner_id = $2 THEN 'owner' ELSE m.role END
+		FROM tasks t
+		LEFT JOIN project_members m ON m.project_id = t.project_id AND m.user_id = $2
+		WHERE t.id = $1 AND (t.owner_id = $2 OR m.user_id IS NOT NULL)
+	`, taskID, userID).Scan(&a.OwnerID, &a.ProjectID, &a.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *ProjectStore) SharedProjects(ctx context.Context, userID string) ([]model.Access, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT p.owner_id, p.id::text, m.role
+		FROM project_members m JOIN projects p ON p.id = m.project_id
+		WHERE m.user_id = $1
+		ORDER BY p.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Access{}
+	for rows.Next() {
+		var a model.Access
+		if err := rows.Scan(&a.OwnerID, &a.ProjectID, &a.Role); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *ProjectStore) ListMembers(ctx context.Context, ownerID, projectID string) ([]model.Member, error) {
+	// The owner row is there whenever the project is
+	rows, err := s.pool.Query(ctx, `
+		SELECT * FROM (
+		  SELECT id::text, owner_id AS user_id, 'owner' AS role, created_at FROM projects
+		  WHERE id = $1 AND owner_id = $2
+		  UNION ALL
+		  SELECT m.project_id::text, m.user_id, m.role, m.created_at
+		  FROM project_members m JOIN projects p ON p.id = m.project_id
+		  WHERE m.project_id = $1 AND p.owner_id = $2
+		) members
+		ORDER BY role <> 'owner', user_id
+	`, projectID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+func (s *ProjectStore) SetMemberRole(ctx context.Context, ownerID, projectID, userID, role string) (model.Member, error) {
+	return scanMember(s.pool.QueryRow(ctx, `
+		UPDATE project_members m SET role = $4
+		FROM projects p
+		WHERE m.project_id = $1 AND m.user_id = $3 AND p.id = m.project_id AND p.owner_id = $2
+		RETURNING m.project_id::text, m.user_id, m.role, m.created_at
+	`, projectID, ownerID, userID, role))
+}
+
+func (s *ProjectStore) RemoveMember(ctx context.Context, ownerID, projectID, userID string) error {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM project_members m
+		USING projects p
+		WHERE m.project_id = $1 AND m.user_id = $3 AND p.id = m.project_id AND p.owner_id = $2
+	`, projectID, ownerID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *ProjectStore) CreateInvitation(ctx context.Context, ownerID, projectID string, in model.Invitation) (inv model.Invitation, err error) {
+	err = pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var member bool
+		err := tx.QueryRow(ctx, `
+			SELECT p.owner_id = $3 OR EXISTS (SELECT 1 FROM project_members WHERE project_id = p.id AND user_id = $3)
+			FROM projects p
+			WHERE p.id = $1 AND p.owner_id = $2
+		`, projectID, ownerID, in.Invitee).Scan(&member)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return err
+		case member:
+			return store.ErrAlreadyMember
+		}
+		inv, err = scanInvitation(tx.QueryRow(ctx, `
+			WITH i AS (
+			  INSERT INTO project_invitations (project_id, invitee, role, invited_by)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (project_id, invitee) DO UPDATE SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by
+			  RETURNING *
+			)
+			SELECT `+invitationColumns+` FROM i JOIN projects p ON p.id = i.project_id
+		`, projectID, in.Invitee, in.Role, in.InvitedBy))
+		return err
+	})
+	return inv, err
+}
+
+func (s *ProjectStore) ListInvitations(ctx context.Context, ownerID, projectID string) ([]model.Invitation, error) {
+	var owned bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND owner_id = $2)`, projectID, ownerID).Scan(&owned); err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, ErrNotFound
+	}
+	return s.queryInvitations(ctx, `i.project_id = $1`, projectID)
+}
+
+func (s *ProjectStore) DeleteInvitation(ctx context.Context, ownerID, projectID, id string) error {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM project_invitations i
+		USING projects p
+		WHERE i.id = $1 AND i.project_id = $2 AND p.id = i.project_id AND p.owner_id = $3
+	`, id, projectID, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *ProjectStore) PendingInvitations(ctx context.Context, invitee string) ([]model.Invitation, error) {
+	return s.queryInvitations(ctx, `i.invitee = $1`, invitee)
+}
+
+// queryInvitations returns the invitations matching where, oldest first.
+func (s *ProjectStore) queryInvitations(ctx context.Context, where string, arg any) ([]model.Invitation, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+invitationColumns+`
+		FROM project_invitations i JOIN projects p ON p.id = i.project_id
+		WHERE `+where+`
+		ORDER BY i.created_at, i.id
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+func (s *ProjectStore) AcceptInvitation(ctx context.Context, invitee, id string) (model.Member, error) {
+	return scanMember(s.pool.QueryRow(ctx, `
+		WITH i AS (
+		  DELETE FROM project_invitations WHERE id = $1 AND invitee = $2
+		  RETURNING project_id, invitee, role
+		)
+		INSERT INTO project_members (project_id, user_id, role)
+		SELECT project_id, invitee, role FROM i
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING project_id::text, user_id, role, created_at
+	`, id, invitee))
+}
+
+func (s *ProjectStore) DeclineInvitation(ctx context.Context, invitee, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM project_invitations WHERE id = $1 AND invitee = $2`, id, invitee)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// projectColumns is the select list matching scanProject. The task counts
+// are computed from the live tasks, as {open, done, overdue}.
+const projectColumns = `id::text, owner_id, name, description, archived_at, created_at, updated_at,
+		(SELECT ARRAY[
+		   count(*) FILTER (WHERE t.status NOT IN ('done', 'cancelled')),
+		   count(*) FILTER (WHERE t.status = 'done'),
+		   count(*) FILTER (WHERE t.status NOT IN ('done', 'cancelled') AND t.due_date < (now() AT TIME ZONE 'UTC')::date)]
+		 FROM tasks t WHERE t.project_id = projects.id AND t.deleted_at IS NULL)`
+
+// ProjectStore is the Postgres store.ProjectRepository.
+type ProjectStore struct {
+	pool *pgxpool.Pool
+}
+
+var _ store.ProjectRepository = (*ProjectStore)(nil)
+
+func NewProjectStore(pool *pgxpool.Pool) *ProjectStore {
+	return &ProjectStore{pool: pool}
+}
+
+// scanProject reads projectColumns, then extra, as a project of the owner.
+func scanProject(row pgx.Row, extra ...any) (model.Project, error) {
+	p := model.Project{Role: model.RoleOwner}
+	var counts []int64
+	dest := append([]any{&p.ID, &p.OwnerID, &p.Name, &p.Description, &p.ArchivedAt, &p.CreatedAt, &p.UpdatedAt, &counts}, extra...)
+	err := row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, ErrNotFound
+	}
+	if err != nil {
+		return p, err
+	}
+	p.Archived = p.ArchivedAt != nil
+	p.TaskCounts = model.ProjectCounts{Open: int(counts[0]), Done: int(counts[1]), Overdue: int(counts[2])}
+	return p, nil
+}
+
+// checkProject validates projectID as the project of a task of ownerID. The
+// lock keeps the project from being deleted until tx ends.
+func checkProject(ctx context.Context, tx pgx.Tx, ownerID, projectID string) error {
+	var one int
+	err := tx.QueryRow(ctx, `
+		SELECT 1 FROM projects
+		WHERE id = $1 AND owner_id = $2
+		FOR KEY SHARE
+	`, projectID, ownerID).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrInvalidProject
+	}
+	return err
+}
+
+func (s *ProjectStore) CreateProject(ctx context.Context, ownerID string, in model.Project) (model.Project, error) {
+	return scanProject(s.pool.QueryRow(ctx, `
+		INSERT INTO projects (owner_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING `+projectColumns, ownerID, in.Name, in.Description))
+}
+
+func (s *ProjectStore) ListProjects(ctx context.Context, userID string, includeArchived bool) ([]model.Project, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+projectColumns+`,
+		  CASE WHEN owner_id = $1 THEN 'owner'
+		    ELSE (SELECT role FROM project_members m WHERE m.project_id = projects.id AND m.user_id = $1) END
+		FROM projects
+		WHERE (owner_id = $1 OR id IN (SELECT project_id FROM project_members WHERE user_id = $1))
+		  AND ($2 OR archived_at IS NULL)
+		ORDER BY name, id
+	`, userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Project{}
+	for rows.Next() {
+		var role string
+		p, err := scanProject(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		p.Role = role
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (s *ProjectStore) GetProject(ctx context.Context, ownerID, id string) (model.Project, error) {
+	return scanProject(s.pool.QueryRow(ctx, `
+		SELECT `+projectColumns+` FROM projects
+		WHERE id = $1 AND owner_id = $2
+	`, id, ownerID))
+}
+
+func (s *ProjectStore) UpdateProject(ctx context.Context, ownerID, id string, patch model.UpdateProjectRequest) (model.Project, error) {
+	return scanProject(s.pool.QueryRow(ctx, `
+		UPDATE projects SET
+		  name = COALESCE($3, name),
+		  description = COALESCE($4, description),
+		  updated_at = now()
+		WHERE id = $1 AND owner_id = $2
+		RETURNING `+projectColumns,
+		id, ownerID, patch.Name, patch.Description))
+}
+
+func (s *ProjectStore) SetArchived(ctx context.Context, ownerID, id string, archived bool) (model.Project, error) {
+	return scanProject(s.pool.QueryRow(ctx, `
+		UPDATE projects SET
+		  archived_at = CASE WHEN $3 THEN COALESCE(archived_at, now()) END,
+		  updated_at = CASE WHEN (archived_at IS NOT NULL) = $3 THEN updated_at ELSE now() END
+		WHERE id = $1 AND owner_id = $2
+		RETURNING `+projectColumns,
+		id, ownerID, archived))
+}
+
+// DeleteProject locks the project first: a task cannot join it meanwhile
+// (see checkProject).
+func (s *ProjectStore) DeleteProject(ctx context.Context, ownerID, id string) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var live bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM tasks WHERE project_id = p.id AND deleted_at IS NULL)
+			FROM projects p
+			WHERE p.id = $1 AND p.owner_id = $2
+			FOR UPDATE OF p
+		`, id, ownerID).Scan(&live)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return err
+		case live:
+			return store.ErrProjectNotEmpty
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM projects WHERE id = $1`, id)
+		return err
+	})
+}
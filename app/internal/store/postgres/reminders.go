@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+type ReminderStore struct {
+	pool *pgxpool.Pool
+}
+
+var _ store.ReminderRepository = (*ReminderStore)(nil)
+
+func NewReminderStore(pool *pgxpool.Pool) *ReminderStore {
+	return &ReminderStore{pool: pool}
+}
+
+func (s *ReminderStore) Enqueue(ctx context.Context, now, until time.Time) (int64, error) {
+	// The due_date range is served by idx_tasks_due_date
+	tag, err := s.pool.Exec(allTenants(ctx), `
+		INSERT INTO task_reminders (task_id, owner_id, tenant_id, due_date, next_attempt_at)
+		SELECT id, owner_id, tenant_id, due_date, $3
+		FROM tasks
+		WHERE due_date BETWEEN $1::date AND $2::date
+		  AND deleted_at IS NULL AND status NOT IN ('done', 'cancelled')
+		ON CONFLICT (task_id, due_date) DO NOTHING
+	`, now.UTC().Format("2006-01-02"), until.UTC().Format("2006-01-02"), now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *ReminderStore) Pending(ctx context.Context, now time.Time, limit int) ([]model.Reminder, error) {
+	_, err := s.pool.Exec(allTenants(ctx), `
+		UPDATE task_reminders r SET status = 'skipped'
+		FROM tasks t
+		WHERE t.id = r.task_id AND r.status = 'pending' AND r.next_attempt_at <= $1
+		  AND (t.deleted_at IS NOT NULL OR t.status IN ('done', 'cancelled') OR t.due_date <> r.due_date)
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lateral subquery keeps taskColumns' unqualified names pointing at tasks
+	rows, err := s.pool.Query(allTenants(ctx), `
+		SELECT t.*, r.id::text, r.attempts + 1, r.created_at
+		FROM task_reminders r
+		CROSS JOIN LATERAL (SELECT `+taskColumns+` FROM tasks WHERE tasks.id = r.task_id) t
+		WHERE r.status = 'pending' AND r.next_attempt_at <= $1
+		ORDER BY r.next_attempt_at, r.id
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]model.Reminder, 0, limit)
+	for rows.Next() {
+		r := model.Reminder{Type: model.EventTaskDueSoon}
+		r.Task, err = scanTask(rows, &r.ID, &r.Attempt, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *ReminderStore) MarkSent(ctx context.Context, id string, at time.Time) error {
+	_, err := s.pool.Exec(allTenants(ctx), `
+		UPDATE task_reminders SET status = 'sent', attempts = attempts + 1, sent_at = $2, last_error = NULL
+		WHERE id = $1
+	`, id, at)
+	return err
+}
+
+func (s *ReminderStore) MarkFailed(ctx context.Context, id string, attempt int, retryAt *time.Time, reason string) error {
+	_, err := s.pool.Exec(allTenants(ctx), `
+		UPDATE task_reminders SET
+		  attempts = $2,
+		  status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		  next_attempt_at = COALESCE($3, next_attempt_at),
+		  last_error = $4
+		WHERE id = $1
+	`, id, attempt, retryAt, reason)
+	return err
+}
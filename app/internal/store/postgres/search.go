@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"team5/task-manager/internal/model"
+)
+
+// headlineOptions wraps matches in <mark> and keeps a couple of short fragments.
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+// Search ranks the live tasks of the owner and p.Shared matching every term with ts_rank over
+// search_vector (title weighs more than content). The snippet is the content
+// excerpt when the content matches, the title otherwise.
+func (s *TasksStore) Search(ctx context.Context, ownerID string, p model.SearchParams) ([]model.SearchHit, bool, error) {
+	args := []any{ownerID, s.opts.SearchLanguage}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	parts := make([]string, 0, len(p.Terms))
+	for _, t := range p.Terms {
+		switch {
+		case t.Phrase:
+			parts = append(parts, "phraseto_tsquery($2::regconfig, "+arg(t.Text)+")")
+		case t.Prefix:
+			// The words are parsed like a phrase, the last one made a prefix:
+			// tsquery syntax in the text stays text
+			parts = append(parts, "(SELECT CASE WHEN numnode(p) = 0 THEN p ELSE (p::text || ':*')::tsquery END FROM phraseto_tsquery($2::regconfig, "+arg(t.Text)+") p)")
+		default:
+			parts = append(parts, "plainto_tsquery($2::regconfig, "+arg(t.Text)+")")
+		}
+	}
+
+	query := `
+		WITH q AS (SELECT ` + strings.Join(parts, " && ") + ` AS query)
+		SELECT ` + taskColumns + `,
+		       ts_rank(search_vector, q.query)::float8 AS rank,
+		       CASE WHEN to_tsvector($2::regconfig, content) @@ q.query
+		            THEN ts_headline($2::regconfig, ` + escapeHTML("content") + `, q.query, '` + headlineOptions + `')
+		            ELSE ts_headline($2::regconfig, ` + escapeHTML("title") + `, q.query, '` + headlineOptions + `')
+		       END
+		FROM tasks, q
+		WHERE ` + readable("", p.Shared, arg) + ` AND deleted_at IS NULL AND search_vector @@ q.query
+		ORDER BY rank DESC, created_at DESC, id
+		LIMIT ` + arg(p.Limit+1) + ` OFFSET ` + arg(p.Offset)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	out := make([]model.SearchHit, 0, p.Limit)
+	for rows.Next() {
+		var h model.SearchHit
+		h.Task, err = scanTask(rows, &h.Rank, &h.Snippet)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	more := len(out) > p.Limit
+	if more {
+		out = out[:p.Limit]
+	}
+	return out, more, nil
+}
+
+// escapeHTML makes a text column safe to embed in HTML before ts_headline adds its markup.
+func escapeHTML(col string) string {
+	return "replace(replace(replace(" + col + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+}
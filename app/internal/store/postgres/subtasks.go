@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// subtreeIDs selects the ids of the live descendants of task $1.
+const subtreeIDs = `
+	WITH RECURSIVE sub AS (
+	  SELECT id FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL
+	  UNION
+	  SELECT t.id FROM tasks t JOIN sub ON t.parent_id = sub.id WHERE t.deleted_at IS NULL
+	)
+	SELECT id FROM sub`
+
+// subtreeInProject is subtreeIDs through the tasks of project $3 only.
+const subtreeInProject = `
+	WITH RECURSIVE sub AS (
+	  SELECT id FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL AND project_id = $3
+	  UNION
+	  SELECT t.id FROM tasks t JOIN sub ON t.parent_id = sub.id WHERE t.deleted_at IS NULL AND t.project_id = $3
+	)
+	SELECT id FROM sub`
+
+// checkParent validates parentID as the parent of task id ("" for a task being created).
+func checkParent(ctx context.Context, tx pgx.Tx, ownerID, id, parentID string) error {
+	var one int
+	err := tx.QueryRow(ctx, `
+		SELECT 1 FROM tasks
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		FOR KEY SHARE
+	`, parentID, ownerID).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrInvalidParent
+	}
+	if err != nil || id == "" {
+		return err
+	}
+	if parentID == id {
+		return store.ErrParentCycle
+	}
+
+	// Two concurrent moves could each pass the check below and build a cycle
+	// together: moves are serialised per owner for the rest of the transaction.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('task_parent:' || $1))`, ownerID); err != nil {
+		return err
+	}
+	var cycle bool
+	err = tx.QueryRow(ctx, `
+		WITH RECURSIVE ancestors AS (
+		  SELECT id, parent_id FROM tasks WHERE id = $1
+		  UNION
+		  SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+	`, parentID, id).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return store.ErrParentCycle
+	}
+	return nil
+}
+
+// deleteTask trashes a task and applies children to its live subtasks.
+func deleteTask(ctx context.Context, tx pgx.Tx, ownerID, id string, cond store.Precondition, children store.ChildrenMode) error {
+	if _, err := setDeleted(ctx, tx, ownerID, id, true, cond); err != nil {
+		return err
+	}
+
+	var scope, set, action string
+	args := []any{id, ownerID}
+	switch children {
+	case store.ChildrenBlock:
+		var has bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL)`, id).Scan(&has)
+		if err != nil {
+			return err
+		}
+		if has {
+			return store.ErrHasChildren
+		}
+		return nil
+	case store.ChildrenCascade:
+		scope, set, action = subtreeIDs, "deleted_at = now()", model.ActionDeleted
+		if cond.CascadeProject != nil {
+			scope = subtreeInProject
+			args = append(args, *cond.CascadeProject)
+		}
+	default:
+		scope, set, action = `SELECT id FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL`, "parent_id = NULL", model.ActionUpdated
+	}
+
+	before, err := queryTasks(ctx, tx, `
+		SELECT `+taskColumns+` FROM tasks
+		WHERE owner_id = $2 AND id IN (`+scope+`)
+		FOR UPDATE
+	`, args...)
+	if err != nil || len(before) == 0 {
+		return err
+	}
+	ids := make([]string, len(before))
+	byID := make(map[string]model.Task, len(before))
+	for i, t := range before {
+		ids[i] = t.ID
+		byID[t.ID] = t
+	}
+
+	after, err := queryTasks(ctx, tx, `
+		UPDATE tasks SET `+set+`, version = version + 1, updated_at = now()
+		WHERE id = ANY($1::uuid[])
+		RETURNING `+taskColumns, ids)
+	if err != nil {
+		return err
+	}
+	for _, t := range after {
+		b := byID[t.ID]
+		if err := appendHistory(ctx, tx, action, &b, t, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TasksStore) Tree(ctx context.Context, ownerID, id string) (model.TaskNode, error) {
+	tasks, err := queryTasks(ctx, s.pool, `
+		SELECT `+taskColumns+` FROM tasks
+		WHERE owner_id = $2 AND deleted_at IS NULL AND (id = $1 OR id IN (`+subtreeIDs+`))
+		ORDER BY created_at, id
+	`, id, ownerID)
+	if err != nil {
+		return model.TaskNode{}, err
+	}
+	node, ok := store.BuildTree(id, tasks)
+	if !ok {
+		return model.TaskNode{}, ErrNotFound
+	}
+	return node, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	"team5/task-manager/internal/model"
+)
+
+// setTags replaces the tags of a task, creating the owner's missing tags.
+func setTags(ctx context.Context, tx pgx.Tx, ownerID, taskID string, names []string) error {
+	if len(names) > 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tags (owner_id, name)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT (tenant_id, owner_id, name) DO NOTHING
+		`, ownerID, names)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM task_tags WHERE task_id = $1`, taskID); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT $1, id FROM tags WHERE owner_id = $2 AND name = ANY($3)
+	`, taskID, ownerID, names)
+	return err
+}
+
+// Tags counts the tags of the owners of shared projects by name with the
+// owner's own.
+func (s *TasksStore) Tags(ctx context.Context, ownerID string, shared []string) ([]model.TagCount, error) {
+	args := []any{ownerID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT g.name, count(*)
+		FROM tags g
+		JOIN task_tags tt ON tt.tag_id = g.id
+		JOIN tasks t ON t.id = tt.task_id AND t.deleted_at IS NULL
+		WHERE `+readable("t.", shared, arg)+`
+		GROUP BY g.name
+		ORDER BY g.name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.TagCount{}
+	for rows.Next() {
+		var tc model.TagCount
+		if err := rows.Scan(&tc.Name, &tc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, tc)
+	}
+	return out, rows.Err()
+}
@@ -3,47 +3,77 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
 )
 
+// Aliases of the store sentinels, kept so callers can match either.
 var (
-	ErrNotFound = errors.New("not found")
-	ErrConflict = errors.New("conflict")
+	ErrNotFound           = store.ErrNotFound
+	ErrConflict           = store.ErrConflict
+	ErrPreconditionFailed = store.ErrPreconditionFailed
 )
 
+// taskColumns is the select list matching scanTask. Progress is computed from
+// the live direct subtasks, NULL when there are none.
+const taskColumns = `id::text, owner_id, title, content, to_char(due_date,'YYYY-MM-DD'), done,
+		       last_request_timestamp, version, created_at, updated_at, deleted_at, parent_id::text,
+		       (SELECT (100 * count(*) FILTER (WHERE c.done) / NULLIF(count(*), 0))::int
+		        FROM tasks c WHERE c.parent_id = tasks.id AND c.deleted_at IS NULL),
+		       ARRAY(SELECT g.name FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+		             WHERE tt.task_id = tasks.id ORDER BY g.name),
+		       status, priority, COALESCE(recurrence, ''), COALESCE(to_char(recurrence_start, 'YYYY-MM-DD'), ''),
+		       project_id::text`
+
+// TasksStore is the Postgres store.TaskRepository.
 type TasksStore struct {
 	pool *pgxpool.Pool
+	opts Options
 }
 
-func NewTasksStore(pool *pgxpool.Pool) *TasksStore {
-	return &TasksStore{pool: pool}
+type Options struct {
+	// SearchLanguage is the text search configuration new tasks are indexed
+	// with and search queries are parsed with. Default: english. Tasks keep
+	// the configuration they were created with (tasks.search_language).
+	SearchLanguage string
 }
 
-func (s *TasksStore) Create(ctx context.Context, title, content string, dueDate time.Time, reqTS time.Time) (model.Task, error) {
-	var t model.Task
-	row := s.pool.QueryRow(ctx, `
-		INSERT INTO tasks (title, content, due_date, done, last_request_timestamp)
-		VALUES ($1, $2, $3, false, $4)
-		RETURNING id::text, title, content, to_char(due_date,'YYYY-MM-DD'), done,
-		          last_request_timestamp, created_at, updated_at
-	`, title, content, dueDate, reqTS)
+var (
+	_ store.TaskRepository = (*TasksStore)(nil)
+	_ store.ChangeLog      = (*TasksStore)(nil)
+)
 
-	err := row.Scan(&t.ID, &t.Title, &t.Content, &t.DueDate, &t.Done, &t.LastRequestTimestamp, &t.CreatedAt, &t.UpdatedAt)
+func NewTasksStore(pool *pgxpool.Pool, opts Options) *TasksStore {
+	if opts.SearchLanguage == "" {
+		opts.SearchLanguage = "english"
+	}
+	return &TasksStore{pool: pool, opts: opts}
+}
+
+// scanTask reads taskColumns, then any extra selected columns into extra.
+func scanTask(row pgx.Row, extra ...any) (model.Task, error) {
+	var t model.Task
+	dest := []any{&t.ID, &t.OwnerID, &t.Title, &t.Content, &t.DueDate, &t.Done, &t.LastRequestTimestamp, &t.Version, &t.CreatedAt, &t.UpdatedAt, &t.DeletedAt, &t.ParentID, &t.Progress, &t.Tags, &t.Status, &t.Priority, &t.Recurrence, &t.RecurrenceStart, &t.ProjectID}
+	err := row.Scan(append(dest, extra...)...)
 	return t, err
 }
 
-func (s *TasksStore) List(ctx context.Context) ([]model.Task, error) {
-	rows, err := s.pool.Query(ctx, `
-		SELECT id::text, title, content, to_char(due_date,'YYYY-MM-DD'), done,
-		       last_request_timestamp, created_at, updated_at
-		FROM tasks
-		ORDER BY created_at DESC
-	`)
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// queryTasks runs a query selecting taskColumns and reads every row.
+func queryTasks(ctx context.Context, q querier, sql string, args ...any) ([]model.Task, error) {
+	rows, err := q.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +81,8 @@ func (s *TasksStore) List(ctx context.Context) ([]model.Task, error) {
 
 	var out []model.Task
 	for rows.Next() {
-		var t model.Task
-		if err := rows.Scan(&t.ID, &t.Title, &t.Content, &t.DueDate, &t.Done, &t.LastRequestTimestamp, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, t)
@@ -60,16 +90,199 @@ func (s *TasksStore) List(ctx context.Context) ([]model.Task, error) {
 	return out, rows.Err()
 }
 
-func (s *TasksStore) Get(ctx context.Context, id string) (model.Task, error) {
-	var t model.Task
+func (s *TasksStore) Create(ctx context.Context, ownerID string, in store.NewTask) (t model.Task, err error) {
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		t, err = s.createTask(ctx, tx, ownerID, in)
+		return err
+	})
+	return t, err
+}
+
+// inTx runs fn in a transaction committed only when fn succeeds.
+func (s *TasksStore) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *TasksStore) createTask(ctx context.Context, tx pgx.Tx, ownerID string, in store.NewTask) (model.Task, error) {
+	if in.ParentID != "" {
+		if err := checkParent(ctx, tx, ownerID, "", in.ParentID); err != nil {
+			return model.Task{}, err
+		}
+	}
+	if in.ProjectID != "" {
+		if err := checkProject(ctx, tx, ownerID, in.ProjectID); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	var start *time.Time
+	if in.Recurrence != "" {
+		start = &in.DueDate
+		if !in.RecurrenceStart.IsZero() {
+			start = &in.RecurrenceStart
+		}
+	}
+
+	row := tx.QueryRow(ctx, `
+		INSERT INTO tasks (owner_id, title, content, due_date, last_request_timestamp, parent_id, search_language, status, priority, recurrence, recurrence_start, project_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7::regconfig, COALESCE(NULLIF($8, ''), 'todo'), COALESCE(NULLIF($9, ''), 'medium'), NULLIF($10, ''), $11::date, NULLIF($12, '')::uuid)
+		RETURNING `+taskColumns+`
+	`, ownerID, in.Title, in.Content, in.DueDate, in.RequestTimestamp, in.ParentID, s.opts.SearchLanguage, in.Status, in.Priority, in.Recurrence, start, in.ProjectID)
+	t, err := scanTask(row)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if len(in.Tags) > 0 {
+		if err := setTags(ctx, tx, ownerID, t.ID, in.Tags); err != nil {
+			return model.Task{}, err
+		}
+		t.Tags = append([]string{}, in.Tags...)
+	}
+
+	if err := appendHistory(ctx, tx, model.ActionCreated, nil, t, &in.RequestTimestamp); err != nil {
+		return model.Task{}, err
+	}
+	return t, nil
+}
+
+// sortColumns maps the public sort keys to their column and the type used to
+// bind the keyset cursor value.
+var sortColumns = map[string]struct{ col, typ string }{
+	model.SortCreatedAt: {"created_at", "timestamptz"},
+	model.SortUpdatedAt: {"updated_at", "timestamptz"},
+	model.SortDueDate:   {"due_date", "date"},
+}
+
+// Predicates stay on the raw columns so idx_tasks_due_date and the
+// (owner_id, created_at|updated_at, id) indexes can serve both filter and order.
+func (s *TasksStore) List(ctx context.Context, ownerID string, p model.ListTasksParams) ([]model.Task, *model.TaskCursor, error) {
+	sc, ok := sortColumns[p.Sort]
+	if !ok {
+		sc = sortColumns[model.SortCreatedAt]
+	}
+
+	args := []any{ownerID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := []string{readable("", p.Shared, arg), "deleted_at IS NULL"}
+	if p.Trashed {
+		where[1] = "deleted_at IS NOT NULL"
+	}
+	if p.Done != nil {
+		where = append(where, "done = "+arg(*p.Done))
+	}
+	if len(p.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(p.Statuses)+")")
+	}
+	if len(p.Priorities) > 0 {
+		where = append(where, "priority = ANY("+arg(p.Priorities)+")")
+	}
+	if p.DueBefore != nil {
+		where = append(where, "due_date < "+arg(*p.DueBefore)+"::date")
+	}
+	if p.DueAfter != nil {
+		where = append(where, "due_date > "+arg(*p.DueAfter)+"::date")
+	}
+	if p.ParentID != nil {
+		where = append(where, "parent_id = "+arg(*p.ParentID)+"::uuid")
+	}
+	switch {
+	case p.ProjectID != nil:
+		where = append(where, "project_id = "+arg(*p.ProjectID)+"::uuid")
+	case !p.Trashed && !p.IncludeArchived:
+		where = append(where, "(project_id IS NULL OR NOT EXISTS (SELECT 1 FROM projects WHERE id = tasks.project_id AND archived_at IS NOT NULL))")
+	}
+	if len(p.Tags) > 0 {
+		tagged := `(SELECT count(*) FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.task_id = tasks.id AND g.name = ANY(` + arg(p.Tags) + `))`
+		if p.TagsAll {
+			where = append(where, tagged+" = "+arg(len(p.Tags)))
+		} else {
+			where = append(where, tagged+" > 0")
+		}
+	}
+	if p.Query != "" {
+		pattern := arg("%" + escapeLike(p.Query) + "%")
+		where = append(where, "(title ILIKE "+pattern+" OR content ILIKE "+pattern+")")
+	}
+
+	cmp, dir := ">", "ASC"
+	if p.Desc {
+		cmp, dir = "<", "DESC"
+	}
+	if p.After != nil {
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			sc.col, cmp, arg(p.After.Value), sc.typ, arg(p.After.ID)))
+	}
+
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY ` + sc.col + ` ` + dir + `, id ` + dir + `
+		LIMIT ` + arg(p.Limit+1)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	out := make([]model.Task, 0, p.Limit)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// One extra row was fetched to know whether another page exists
+	if len(out) <= p.Limit {
+		return out, nil, nil
+	}
+	out = out[:p.Limit]
+	next := model.NewTaskCursor(out[len(out)-1], p.Sort, p.Desc)
+	return out, &next, nil
+}
+
+// readable is the condition on the tasks (of alias t, "t.") covered by a
+// listing of owner $1 and the shared projects, bound with arg.
+func readable(t string, shared []string, arg func(any) string) string {
+	if len(shared) == 0 {
+		return t + "owner_id = $1"
+	}
+	return "(" + t + "owner_id = $1 OR " + t + "project_id = ANY(" + arg(shared) + "::uuid[]))"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *TasksStore) Get(ctx context.Context, ownerID, id string) (model.Task, error) {
 	row := s.pool.QueryRow(ctx, `
-		SELECT id::text, title, content, to_char(due_date,'YYYY-MM-DD'), done,
-		       last_request_timestamp, created_at, updated_at
+		SELECT `+taskColumns+`
 		FROM tasks
-		WHERE id = $1
-	`, id)
+		WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+	`, id, ownerID)
 
-	if err := row.Scan(&t.ID, &t.Title, &t.Content, &t.DueDate, &t.Done, &t.LastRequestTimestamp, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	t, err := scanTask(row)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.Task{}, ErrNotFound
 		}
@@ -78,79 +291,169 @@ func (s *TasksStore) Get(ctx context.Context, id string) (model.Task, error) {
 	return t, nil
 }
 
-func (s *TasksStore) Update(ctx context.Context, id string, patch model.UpdateTaskRequest, dueDate *time.Time, reqTS time.Time) (model.Task, error) {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+func (s *TasksStore) Update(ctx context.Context, ownerID, id string, patch model.UpdateTaskRequest, dueDate *time.Time, cond store.Precondition) (t model.Task, err error) {
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		t, err = s.updateTask(ctx, tx, ownerID, id, patch, dueDate, cond)
+		return err
+	})
+	return t, err
+}
+
+// updateTask applies patch; completing an occurrence of a recurring task
+// moves its series to a newly created next occurrence.
+func (s *TasksStore) updateTask(ctx context.Context, tx pgx.Tx, ownerID, id string, patch model.UpdateTaskRequest, dueDate *time.Time, cond store.Precondition) (model.Task, error) {
+	before, err := lockTask(ctx, tx, ownerID, id, false, cond)
 	if err != nil {
 		return model.Task{}, err
 	}
-	defer func() { _ = tx.Rollback(ctx) }()
+	status := store.NextStatus(before.Status, patch)
+	if err := cond.CheckTransition(before.Status, status); err != nil {
+		return model.Task{}, err
+	}
+	series := before
+	if dueDate != nil {
+		series.DueDate = dueDate.Format("2006-01-02")
+	}
+	series.Recurrence, series.RecurrenceStart = store.Recurrence(series, patch)
+	recurrence, start := series.Recurrence, series.RecurrenceStart
+	completes := store.Completes(before.Status, status, recurrence)
+	if completes {
+		recurrence, start = "", ""
+	}
+	var parentID string
+	if patch.ParentID != nil {
+		parentID = *patch.ParentID
+		if parentID != "" {
+			if err := checkParent(ctx, tx, ownerID, id, parentID); err != nil {
+				return model.Task{}, err
+			}
+		}
+	}
 
-	var lastTS time.Time
-	err = tx.QueryRow(ctx, `SELECT last_request_timestamp FROM tasks WHERE id = $1 FOR UPDATE`, id).Scan(&lastTS)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return model.Task{}, ErrNotFound
+	var projectID string
+	if patch.ProjectID != nil {
+		projectID = *patch.ProjectID
+		if projectID != "" {
+			if err := checkProject(ctx, tx, ownerID, projectID); err != nil {
+				return model.Task{}, err
+			}
 		}
-		return model.Task{}, err
 	}
-	if !reqTS.After(lastTS) {
-		return model.Task{}, ErrConflict
+
+	if patch.Tags != nil {
+		if err := setTags(ctx, tx, ownerID, id, *patch.Tags); err != nil {
+			return model.Task{}, err
+		}
 	}
 
 	// patch partiel avec COALESCE
-	_, err = tx.Exec(ctx, `
+	row := tx.QueryRow(ctx, `
 		UPDATE tasks SET
 		  title = COALESCE($2, title),
 		  content = COALESCE($3, content),
 		  due_date = COALESCE($4, due_date),
-		  done = COALESCE($5, done),
-		  last_request_timestamp = $6,
+		  status = $5,
+		  priority = COALESCE($9, priority),
+		  recurrence = NULLIF($10, ''),
+		  recurrence_start = NULLIF($11, '')::date,
+		  last_request_timestamp = COALESCE($6, last_request_timestamp),
+		  parent_id = CASE WHEN $7 THEN NULLIF($8, '')::uuid ELSE parent_id END,
+		  project_id = CASE WHEN $12 THEN NULLIF($13, '')::uuid ELSE project_id END,
+		  version = version + 1,
 		  updated_at = now()
 		WHERE id = $1
-	`, id, patch.Title, patch.Content, dueDate, patch.Done, reqTS)
+		RETURNING `+taskColumns+`
+	`, id, patch.Title, patch.Content, dueDate, status, cond.RequestTimestamp, patch.ParentID != nil, parentID, patch.Priority, recurrence, start, patch.ProjectID != nil, projectID)
+	t, err := scanTask(row)
 	if err != nil {
 		return model.Task{}, err
 	}
 
-	var t model.Task
-	row := tx.QueryRow(ctx, `
-		SELECT id::text, title, content, to_char(due_date,'YYYY-MM-DD'), done,
-		       last_request_timestamp, created_at, updated_at
-		FROM tasks WHERE id = $1
-	`, id)
-	if err := row.Scan(&t.ID, &t.Title, &t.Content, &t.DueDate, &t.Done, &t.LastRequestTimestamp, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	if err := appendHistory(ctx, tx, model.ActionUpdated, &before, t, cond.RequestTimestamp); err != nil {
 		return model.Task{}, err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return model.Task{}, err
+	if completes {
+		occurrence := t
+		occurrence.Recurrence, occurrence.RecurrenceStart = series.Recurrence, series.RecurrenceStart
+		if next, ok := store.NextOccurrence(occurrence); ok {
+			if _, err := s.createTask(ctx, tx, ownerID, next); err != nil {
+				return model.Task{}, err
+			}
+		}
 	}
 	return t, nil
 }
 
-func (s *TasksStore) Delete(ctx context.Context, id string, reqTS time.Time) error {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
+// Delete moves the task to the trash; PurgeDeleted removes it for good.
+func (s *TasksStore) Delete(ctx context.Context, ownerID, id string, cond store.Precondition, children store.ChildrenMode) error {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		return deleteTask(ctx, tx, ownerID, id, cond, children)
+	})
+}
+
+func (s *TasksStore) Restore(ctx context.Context, ownerID, id string, cond store.Precondition) (t model.Task, err error) {
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		t, err = setDeleted(ctx, tx, ownerID, id, false, cond)
 		return err
+	})
+	return t, err
+}
+
+// setDeleted moves a task in or out of the trash. Like any mutation it
+// bumps the version and records the request timestamp.
+func setDeleted(ctx context.Context, tx pgx.Tx, ownerID, id string, deleted bool, cond store.Precondition) (model.Task, error) {
+	before, err := lockTask(ctx, tx, ownerID, id, !deleted, cond)
+	if err != nil {
+		return model.Task{}, err
 	}
-	defer func() { _ = tx.Rollback(ctx) }()
 
-	var lastTS time.Time
-	err = tx.QueryRow(ctx, `SELECT last_request_timestamp FROM tasks WHERE id = $1 FOR UPDATE`, id).Scan(&lastTS)
+	row := tx.QueryRow(ctx, `
+		UPDATE tasks SET
+		  deleted_at = CASE WHEN $2 THEN now() END,
+		  last_request_timestamp = COALESCE($3, last_request_timestamp),
+		  version = version + 1,
+		  updated_at = now()
+		WHERE id = $1
+		RETURNING `+taskColumns+`
+	`, id, deleted, cond.RequestTimestamp)
+	t, err := scanTask(row)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
-		}
-		return err
+		return model.Task{}, err
 	}
-	if !reqTS.After(lastTS) {
-		return ErrConflict
+
+	action := model.ActionRestored
+	if deleted {
+		action = model.ActionDeleted
 	}
+	if err := appendHistory(ctx, tx, action, &before, t, cond.RequestTimestamp); err != nil {
+		return model.Task{}, err
+	}
+	return t, nil
+}
 
-	_, err = tx.Exec(ctx, `DELETE FROM tasks WHERE id = $1`, id)
+func (s *TasksStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.pool.Exec(allTenants(ctx), `DELETE FROM tasks WHERE deleted_at < $1`, before)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	return tag.RowsAffected(), nil
+}
 
-	return tx.Commit(ctx)
+// lockTask row-locks the caller's task for the rest of tx, checks cond against
+// it and returns its state before the mutation.
+// trashed selects whether the task must be in the trash or live.
+func lockTask(ctx context.Context, tx pgx.Tx, ownerID, id string, trashed bool, cond store.Precondition) (model.Task, error) {
+	t, err := scanTask(tx.QueryRow(ctx, `
+		SELECT `+taskColumns+` FROM tasks
+		WHERE id = $1 AND owner_id = $2 AND (deleted_at IS NOT NULL) = $3
+		FOR UPDATE
+	`, id, ownerID, trashed))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Task{}, ErrNotFound
+		}
+		return model.Task{}, err
+	}
+	return t, cond.Check(t.LastRequestTimestamp, t.Version)
 }
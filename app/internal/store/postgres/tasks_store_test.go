@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq"
+
+	migfs "team5/task-manager/db/migrations"
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/store/storetest"
+)
+
+// newTestPool migrates and connects to TEST_DATABASE_URL, skipping the test
+// when it is not set. Tests use unique owners, so the database can be reused.
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("sql open: %v", err)
+	}
+	defer db.Close()
+	if err := migfs.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("pool config: %v", err)
+	}
+	ConfigurePool(cfg)
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestTasksStore(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunTaskRepository(t, func(t *testing.T) store.TaskRepository {
+		return NewTasksStore(pool, Options{})
+	})
+}
+
+func TestReminderStore(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunReminderRepository(t, func(t *testing.T) (store.TaskRepository, store.ReminderRepository) {
+		return NewTasksStore(pool, Options{}), NewReminderStore(pool)
+	})
+}
+
+func TestWebhookStore(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunWebhookRepository(t, func(t *testing.T) (store.TaskRepository, storetest.WebhookStore) {
+		return NewTasksStore(pool, Options{}), NewWebhookStore(pool)
+	})
+}
+
+func TestChangeLog(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunChangeLog(t, func(t *testing.T) (store.TaskRepository, store.ChangeLog) {
+		tasks := NewTasksStore(pool, Options{})
+		return tasks, tasks
+	})
+}
+
+// TestOverlapping commits an entry after a higher one: a reader resuming
+// after the higher one must get it from Overlapping.
+func TestOverlapping(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	owner := "overlap-" + uuid.NewString()
+	insert := func(tx pgx.Tx) int64 {
+		t.Helper()
+		var id int64
+		if _, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', 'default', true)`); err != nil {
+			t.Fatal(err)
+		}
+		err := tx.QueryRow(ctx, `
+			INSERT INTO task_history (task_id, owner_id, actor, action, version)
+			VALUES ($1, $2, $2, 'updated', 1) RETURNING id
+		`, uuid.NewString(), owner).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	slow, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Rollback(ctx)
+	first := insert(slow)
+	fast, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Rollback(ctx)
+	second := insert(fast)
+	if err := fast.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := slow.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := NewTasksStore(pool, Options{})
+	got, err := tasks.Overlapping(ctx, owner, nil, second)
+	if err != nil || len(got) != 1 || got[0].ID != first {
+		t.Errorf("Overlapping(%d) = %+v, %v, want entry %d", second, got, err, first)
+	}
+	if got, err := tasks.Overlapping(ctx, owner, nil, first); err != nil || len(got) != 0 {
+		t.Errorf("Overlapping(%d) = %+v, %v, want none", first, got, err)
+	}
+}
+
+func TestProjectStore(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunProjectRepository(t, func(t *testing.T) (store.TaskRepository, store.ProjectRepository) {
+		return NewTasksStore(pool, Options{}), NewProjectStore(pool)
+	})
+}
+
+func TestMemberStore(t *testing.T) {
+	pool := newTestPool(t)
+	storetest.RunMemberRepository(t, func(t *testing.T) (store.TaskRepository, store.ProjectRepository, store.MemberRepository) {
+		projects := NewProjectStore(pool)
+		return NewTasksStore(pool, Options{}), projects, projects
+	})
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/store"
+)
+
+// tenantKey is the CustomData entry of a connection holding the settings
+// last applied by setTenant.
+const tenantKey = "tenant"
+
+type allTenantsKey struct{}
+
+// allTenants marks ctx for the background jobs working across tenants
+// (purge, sweeps, reminders, webhook deliveries); rows they insert must copy
+// the tenant_id of the rows they derive from.
+func allTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// ConfigurePool scopes the connections of the pool built from cfg to the
+// tenant of the context they are acquired with (store.TenantFrom):
+// app.tenant_id is set for the session, and the row level security policies
+// of the tenant tables hide and refuse the rows of every other tenant. A
+// query forgetting its owner filter still cannot cross tenants. The stores
+// must be given such a pool; without it they see no rows.
+//
+// The tenant is set for the session rather than for each transaction
+// (set_config(..., true)), which would wrap every statement in one. That is
+// as tight: every acquisition applies the tenant of its context, so a
+// connection released by another tenant never leaks its setting, and the
+// statement is only sent when the tenant changes. The stores only take a
+// pool, so all their connections go through this; a connection leaving the
+// pool for good (Hijack) must drop its setting with clearTenant.
+//
+// The database role of the service must not be a superuser nor have
+// BYPASSRLS, which skip the policies.
+func ConfigurePool(cfg *pgxpool.Config) {
+	prepare := cfg.PrepareConn
+	cfg.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		if prepare != nil {
+			if ok, err := prepare(ctx, conn); !ok || err != nil {
+				return ok, err
+			}
+		}
+		if err := setTenant(ctx, conn); err != nil {
+			// The session settings are unknown: drop the connection
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+// clearTenant leaves conn without a tenant: the policies show it no rows.
+func clearTenant(ctx context.Context, conn *pgx.Conn) error {
+	delete(conn.PgConn().CustomData(), tenantKey)
+	_, err := conn.Exec(ctx, `SELECT set_config('app.tenant_id', '', false), set_config('app.all_tenants', 'off', false)`)
+	return err
+}
+
+func setTenant(ctx context.Context, conn *pgx.Conn) error {
+	tenant, all := store.TenantFrom(ctx), "off"
+	if ctx.Value(allTenantsKey{}) != nil {
+		tenant, all = "", "on"
+	}
+	applied := tenant + "\x00" + all
+	data := conn.PgConn().CustomData()
+	if data[tenantKey] == applied {
+		return nil
+	}
+	// set_config(..., false) is SET with bind parameters
+	if _, err := conn.Exec(ctx, `SELECT set_config('app.tenant_id', $1, false), set_config('app.all_tenants', $2, false)`, tenant, all); err != nil {
+		delete(data, tenantKey)
+		return err
+	}
+	data[tenantKey] = applied
+	return nil
+}
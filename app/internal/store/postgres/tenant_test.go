@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// rlsTestRole is the role the isolation test runs as when the test user is
+// a superuser or has BYPASSRLS, which row level security does not apply to.
+const rlsTestRole = "task_manager_rls_test"
+
+// newRLSPool connects to the test database as a role subject to row level
+// security.
+func newRLSPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool := newTestPool(t)
+	ctx := context.Background()
+
+	var bypass, super bool
+	if err := pool.QueryRow(ctx, `SELECT rolbypassrls, rolsuper FROM pg_roles WHERE rolname = current_user`).Scan(&bypass, &super); err != nil {
+		t.Fatalf("current role: %v", err)
+	}
+	if !bypass && !super {
+		return pool
+	}
+	if !super {
+		t.Skip("the test user bypasses row level security and cannot switch roles")
+	}
+	_, err := pool.Exec(ctx, `
+		DO $$ BEGIN
+		  CREATE ROLE `+rlsTestRole+` NOLOGIN NOBYPASSRLS;
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$;
+		GRANT USAGE ON SCHEMA public TO `+rlsTestRole+`;
+		GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO `+rlsTestRole+`;
+		GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO `+rlsTestRole+`;
+	`)
+	if err != nil {
+		t.Fatalf("test role: %v", err)
+	}
+
+	cfg := pool.Config()
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, `SET ROLE `+rlsTestRole)
+		return err
+	}
+	rls, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(rls.Close)
+	return rls
+}
+
+func TestTenantIsolation(t *testing.T) {
+	pool := newRLSPool(t)
+	tasks := NewTasksStore(pool, Options{})
+	// The same owner in two tenants is two users
+	owner := "owner-" + uuid.NewString()
+	acme := store.WithTenant(context.Background(), "acme-"+uuid.NewString())
+	globex := store.WithTenant(context.Background(), "globex-"+uuid.NewString())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	task, err := tasks.Create(acme, owner, store.NewTask{Title: "acme", Content: "c", DueDate: now, RequestTimestamp: now, Tags: []string{"shared"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := tasks.Create(globex, owner, store.NewTask{Title: "globex", Content: "c", DueDate: now, RequestTimestamp: now, Tags: []string{"shared"}}); err != nil {
+		t.Fatalf("Create with a tag name taken in another tenant: %v", err)
+	}
+
+	if _, err := tasks.Get(globex, owner, task.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get from another tenant: %v, want ErrNotFound", err)
+	}
+	page, _, err := tasks.List(globex, owner, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt})
+	if err != nil || len(page) != 1 || page[0].Title != "globex" {
+		t.Errorf("List from another tenant = %+v, %v", page, err)
+	}
+	title := "stolen"
+	if _, err := tasks.Update(globex, owner, task.ID, model.UpdateTaskRequest{Title: &title}, nil, store.Precondition{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update from another tenant: %v, want ErrNotFound", err)
+	}
+	if err := tasks.Delete(globex, owner, task.ID, store.Precondition{}, store.ChildrenOrphan); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete from another tenant: %v, want ErrNotFound", err)
+	}
+	if entries, _, err := tasks.History(globex, owner, task.ID, model.HistoryParams{Limit: 10}); err == nil && len(entries) > 0 {
+		t.Errorf("History from another tenant = %+v", entries)
+	}
+
+	// Queries without any owner filter still stop at the tenant
+	var n int
+	if err := tasks.pool.QueryRow(globex, `SELECT count(*) FROM tasks WHERE id = $1`, task.ID).Scan(&n); err != nil || n != 0 {
+		t.Errorf("unfiltered count from another tenant = %d, %v", n, err)
+	}
+	tag, err := tasks.pool.Exec(globex, `UPDATE tasks SET title = 'stolen' WHERE id = $1`, task.ID)
+	if err != nil || tag.RowsAffected() != 0 {
+		t.Errorf("unfiltered update from another tenant: %d rows, %v", tag.RowsAffected(), err)
+	}
+	if _, err := tasks.pool.Exec(globex, `UPDATE tasks SET tenant_id = 'elsewhere' WHERE id = (SELECT id FROM tasks WHERE owner_id = $1 LIMIT 1)`, owner); err == nil {
+		t.Error("moving a row out of the tenant succeeded")
+	}
+
+	if got, err := tasks.Get(acme, owner, task.ID); err != nil || got.Title != "acme" {
+		t.Errorf("Get in its tenant = %+v, %v", got, err)
+	}
+	if err := tasks.pool.QueryRow(allTenants(context.Background()), `SELECT count(*) FROM tasks WHERE owner_id = $1`, owner).Scan(&n); err != nil || n != 2 {
+		t.Errorf("count across tenants = %d, %v, want 2", n, err)
+	}
+}
+
+func TestConfigurePoolSwitchesTenants(t *testing.T) {
+	pool := newTestPool(t)
+	// One connection, reused by every tenant in turn
+	cfg := pool.Config()
+	cfg.MaxConns = 1
+	single, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(single.Close)
+
+	for _, tc := range []struct {
+		ctx        context.Context
+		tenant, on string
+	}{
+		{store.WithTenant(context.Background(), "acme"), "acme", "off"},
+		{store.WithTenant(context.Background(), "acme"), "acme", "off"},
+		{context.Background(), store.DefaultTenant, "off"},
+		{allTenants(context.Background()), "", "on"},
+		{store.WithTenant(context.Background(), "globex"), "globex", "off"},
+	} {
+		var tenant, all string
+		err := single.QueryRow(tc.ctx, `SELECT current_setting('app.tenant_id'), current_setting('app.all_tenants')`).Scan(&tenant, &all)
+		if err != nil || tenant != tc.tenant || all != tc.on {
+			t.Errorf("settings = %q %q, %v, want %q %q", tenant, all, err, tc.tenant, tc.on)
+		}
+	}
+}
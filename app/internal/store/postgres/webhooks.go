@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// webhookColumns is the select list matching scanWebhook; the secret is never read back.
+const webhookColumns = `id::text, url, events, active, created_at, updated_at`
+
+// WebhookStore is the Postgres store.WebhookRepository and store.WebhookOutbox.
+type WebhookStore struct {
+	pool *pgxpool.Pool
+}
+
+var (
+	_ store.WebhookRepository = (*WebhookStore)(nil)
+	_ store.WebhookOutbox     = (*WebhookStore)(nil)
+)
+
+func NewWebhookStore(pool *pgxpool.Pool) *WebhookStore {
+	return &WebhookStore{pool: pool}
+}
+
+// appendEvent writes the webhook event of a task mutation to the outbox, in
+// the mutation's transaction.
+func appendEvent(ctx context.Context, tx pgx.Tx, eventType string, t model.Task) error {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO webhook_outbox (owner_id, event_type, task_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, t.OwnerID, eventType, t.ID, payload, t.UpdatedAt)
+	return err
+}
+
+func scanWebhook(row pgx.Row) (model.WebhookSubscription, error) {
+	var w model.WebhookSubscription
+	err := row.Scan(&w.ID, &w.URL, &w.Events, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return w, ErrNotFound
+	}
+	return w, err
+}
+
+func (s *WebhookStore) CreateWebhook(ctx context.Context, ownerID string, in model.WebhookSubscription) (model.WebhookSubscription, error) {
+	w, err := scanWebhook(s.pool.QueryRow(ctx, `
+		INSERT INTO webhook_subscriptions (owner_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+webhookColumns, ownerID, in.URL, in.Secret, nonNil(in.Events)))
+	w.Secret = in.Secret
+	return w, err
+}
+
+func (s *WebhookStore) ListWebhooks(ctx context.Context, ownerID string) ([]model.WebhookSubscription, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+webhookColumns+` FROM webhook_subscriptions
+		WHERE owner_id = $1
+		ORDER BY created_at, id
+	`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.WebhookSubscription{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (s *WebhookStore) GetWebhook(ctx context.Context, ownerID, id string) (model.WebhookSubscription, error) {
+	return scanWebhook(s.pool.QueryRow(ctx, `
+		SELECT `+webhookColumns+` FROM webhook_subscriptions
+		WHERE id = $1 AND owner_id = $2
+	`, id, ownerID))
+}
+
+func (s *WebhookStore) UpdateWebhook(ctx context.Context, ownerID, id string, patch model.UpdateWebhookRequest) (model.WebhookSubscription, error) {
+	var events []string
+	if patch.Events != nil {
+		events = nonNil(*patch.Events)
+	}
+	return scanWebhook(s.pool.QueryRow(ctx, `
+		UPDATE webhook_subscriptions SET
+		  url = COALESCE($3, url),
+		  events = COALESCE($4, events),
+		  active = COALESCE($5, active),
+		  secret = COALESCE($6, secret),
+		  updated_at = now()
+		WHERE id = $1 AND owner_id = $2
+		RETURNING `+webhookColumns,
+		id, ownerID, patch.URL, events, patch.Active, patch.Secret))
+}
+
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, ownerID, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *WebhookStore) Attempts(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.WebhookAttempt, int64, error) {
+	if _, err := s.GetWebhook(ctx, ownerID, id); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT a.id, a.delivery_id::text, e.event_id::text, e.event_type, a.attempt, a.outcome,
+		       COALESCE(a.status_code, 0), COALESCE(a.error, ''), a.duration_ms, a.created_at
+		FROM webhook_attempts a
+		JOIN webhook_deliveries d ON d.id = a.delivery_id
+		JOIN webhook_outbox e ON e.id = d.event_id
+		WHERE a.subscription_id = $1 AND a.id > $2
+		ORDER BY a.id
+		LIMIT $3
+	`, id, p.AfterID, p.Limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]model.WebhookAttempt, 0, p.Limit)
+	for rows.Next() {
+		var a model.WebhookAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.EventID, &a.EventType, &a.Attempt, &a.Outcome, &a.StatusCode, &a.Error, &a.DurationMS, &a.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(out) > p.Limit {
+		out = out[:p.Limit]
+		return out, out[len(out)-1].ID, nil
+	}
+	return out, 0, nil
+}
+
+func (s *WebhookStore) Fanout(ctx context.Context, now time.Time, limit int) (int64, error) {
+	// SKIP LOCKED lets a second dispatcher take the next events rather than
+	// wait; rows are picked by dispatched_at, not by id, since ids are not
+	// committed in order.
+	tag, err := s.pool.Exec(allTenants(ctx), `
+		WITH batch AS (
+		  SELECT id, owner_id, tenant_id, event_type FROM webhook_outbox
+		  WHERE dispatched_at IS NULL
+		  ORDER BY id
+		  LIMIT $2
+		  FOR UPDATE SKIP LOCKED
+		), fanned AS (
+		  INSERT INTO webhook_deliveries (subscription_id, event_id, tenant_id, next_attempt_at)
+		  SELECT w.id, b.id, b.tenant_id, $1
+		  FROM batch b
+		  JOIN webhook_subscriptions w ON w.tenant_id = b.tenant_id AND w.owner_id = b.owner_id AND w.active
+		   AND (cardinality(w.events) = 0 OR b.event_type = ANY(w.events))
+		  ON CONFLICT (subscription_id, event_id) DO NOTHING
+		)
+		UPDATE webhook_outbox SET dispatched_at = $1
+		WHERE id IN (SELECT id FROM batch)
+	`, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *WebhookStore) Pending(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := s.pool.Query(allTenants(ctx), `
+		SELECT d.id::text, w.id::text, d.attempts + 1, w.url, w.secret,
+		       e.event_id::text, e.event_type, e.created_at, e.payload
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions w ON w.id = d.subscription_id
+		JOIN webhook_outbox e ON e.id = d.event_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND w.active
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]model.WebhookDelivery, 0, limit)
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Attempt, &d.URL, &d.Secret, &d.Event.ID, &d.Event.Type, &d.Event.CreatedAt, &payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &d.Event.Task); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *WebhookStore) RecordAttempt(ctx context.Context, a model.WebhookAttempt, retryAt *time.Time) error {
+	status := store.DeliveryPending
+	switch a.Outcome {
+	case model.AttemptDelivered:
+		status = store.DeliveryDelivered
+	case model.AttemptDead:
+		status = store.DeliveryDead
+	}
+	_, err := s.pool.Exec(allTenants(ctx), `
+		WITH d AS (
+		  UPDATE webhook_deliveries SET
+		    attempts = $2,
+		    status = $3,
+		    next_attempt_at = COALESCE($4, next_attempt_at),
+		    last_error = NULLIF($6, ''),
+		    delivered_at = CASE WHEN $3 = 'delivered' THEN $8::timestamptz END
+		  WHERE id = $1
+		  RETURNING id, subscription_id, tenant_id
+		)
+		INSERT INTO webhook_attempts (delivery_id, subscription_id, tenant_id, attempt, outcome, status_code, error, duration_ms, created_at)
+		SELECT id, subscription_id, tenant_id, $2, $9, NULLIF($5, 0), NULLIF($6, ''), $7, $8 FROM d
+	`, a.DeliveryID, a.Attempt, status, retryAt, a.StatusCode, a.Error, a.DurationMS, a.CreatedAt, a.Outcome)
+	return err
+}
+
+// nonNil keeps a missing list from becoming a NULL array.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
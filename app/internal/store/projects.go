@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+
+	"team5/task-manager/internal/model"
+)
+
+// ProjectRepository manages the projects of an owner. Like TaskRepository,
+// someone else's project behaves like a missing one. Projects come with the
+// counts of their live tasks.
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, ownerID string, in model.Project) (model.Project, error)
+	// ListProjects returns the projects of userID and those shared with them
+	// (see MemberRepository) by name, archived ones only with includeArchived.
+	// Role is the one of userID.
+	ListProjects(ctx context.Context, userID string, includeArchived bool) ([]model.Project, error)
+	GetProject(ctx context.Context, ownerID, id string) (model.Project, error)
+	UpdateProject(ctx context.Context, ownerID, id string, patch model.UpdateProjectRequest) (model.Project, error)
+	// SetArchived archives or unarchives a project; doing it twice is a no-op.
+	SetArchived(ctx context.Context, ownerID, id string, archived bool) (model.Project, error)
+	// DeleteProject removes a project without live tasks (ErrProjectNotEmpty);
+	// its trashed tasks leave it.
+	DeleteProject(ctx context.Context, ownerID, id string) error
+}
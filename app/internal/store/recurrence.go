@@ -0,0 +1,68 @@
+package store
+
+import (
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/service"
+)
+
+// Recurrence returns the series an update leaves the task in: a new rule
+// restarts it at the task's due date, an empty one stops it.
+func Recurrence(t model.Task, patch model.UpdateTaskRequest) (rule, start string) {
+	if patch.Recurrence == nil {
+		return t.Recurrence, t.RecurrenceStart
+	}
+	if *patch.Recurrence == "" {
+		return "", ""
+	}
+	return *patch.Recurrence, t.DueDate
+}
+
+// Completes reports whether an update from status from to status to
+// completes an occurrence of a recurring series, which then moves to the
+// next occurrence (see NextOccurrence).
+func Completes(from, to, rule string) bool {
+	return rule != "" && from != model.StatusDone && to == model.StatusDone
+}
+
+// NextOccurrence is the task completing t creates: a copy due at the next
+// date of its series. False when t does not recur or its series is over.
+func NextOccurrence(t model.Task) (NewTask, bool) {
+	if t.Recurrence == "" {
+		return NewTask{}, false
+	}
+	rule, err := service.ParseRRule(t.Recurrence)
+	if err != nil {
+		return NewTask{}, false
+	}
+	start, err := service.ParseDateYYYYMMDD(t.RecurrenceStart)
+	if err != nil {
+		return NewTask{}, false
+	}
+	due, err := service.ParseDateYYYYMMDD(t.DueDate)
+	if err != nil {
+		return NewTask{}, false
+	}
+	next, ok := rule.Next(start, due)
+	if !ok {
+		return NewTask{}, false
+	}
+
+	in := NewTask{
+		Title:            t.Title,
+		Content:          t.Content,
+		DueDate:          next,
+		RequestTimestamp: t.LastRequestTimestamp,
+		Tags:             t.Tags,
+		Status:           model.StatusTodo,
+		Priority:         t.Priority,
+		Recurrence:       t.Recurrence,
+		RecurrenceStart:  start,
+	}
+	if t.ParentID != nil {
+		in.ParentID = *t.ParentID
+	}
+	if t.ProjectID != nil {
+		in.ProjectID = *t.ProjectID
+	}
+	return in, true
+}
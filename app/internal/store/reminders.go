@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"team5/task-manager/internal/model"
+)
+
+// Delivery states of a reminder.
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"  // given up after the last attempt
+	ReminderSkipped = "skipped" // the task was closed, trashed or rescheduled first
+)
+
+// ReminderRepository tracks due-date reminders: at most one per task and due
+// date, whatever the number of schedulers.
+type ReminderRepository interface {
+	// Enqueue creates a pending reminder, attemptable from now, for every live
+	// open task (neither done nor cancelled) due between the dates of now and
+	// until, inclusive. Existing reminders are left alone. It returns how many
+	// were created.
+	Enqueue(ctx context.Context, now, until time.Time) (int64, error)
+	// Pending returns up to limit pending reminders whose next attempt is due
+	// at now, oldest first, with their task. Reminders whose task no longer
+	// needs one are moved to ReminderSkipped instead.
+	Pending(ctx context.Context, now time.Time, limit int) ([]model.Reminder, error)
+	// MarkSent records a successful delivery.
+	MarkSent(ctx context.Context, id string, at time.Time) error
+	// MarkFailed records failed attempt number attempt. The reminder is tried
+	// again at retryAt, or given up (ReminderFailed) when retryAt is nil.
+	MarkFailed(ctx context.Context, id string, attempt int, retryAt *time.Time, reason string) error
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"team5/task-manager/internal/model"
+)
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrInvalidParent: the parent is not one of the caller's live tasks
+	ErrInvalidParent = errors.New("invalid parent")
+	// ErrParentCycle: the task would become its own ancestor
+	ErrParentCycle = errors.New("parent cycle")
+	// ErrHasChildren: ChildrenBlock refused to delete a task with live subtasks
+	ErrHasChildren = errors.New("task has subtasks")
+	// ErrInvalidTransition: the workflow does not allow the status change (see TransitionError)
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrInvalidProject: the project is not one of the caller's projects
+	ErrInvalidProject = errors.New("invalid project")
+	// ErrProjectNotEmpty: a project with live tasks cannot be deleted
+	ErrProjectNotEmpty = errors.New("project has tasks")
+)
+
+// TransitionError is the ErrInvalidTransition of a given status change.
+type TransitionError struct {
+	From, To string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid status transition from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// NewTask holds the validated fields of a task to create.
+type NewTask struct {
+	Title            string
+	Content          string
+	DueDate          time.Time
+	RequestTimestamp time.Time
+	ParentID         string    // empty for a top-level task
+	ProjectID        string    // empty outside of projects
+	Tags             []string  // normalised (see service.NormalizeTags)
+	Status           string    // model.StatusTodo when empty
+	Priority         string    // model.PriorityMedium when empty
+	Recurrence       string    // canonical RRULE (see service.ParseRRule), empty for a one-off task
+	RecurrenceStart  time.Time // first due date of the series, DueDate when zero
+}
+
+// ChildrenMode decides what deleting a task does to its live subtasks.
+type ChildrenMode string
+
+const (
+	ChildrenOrphan  ChildrenMode = "orphan"  // subtasks become top-level
+	ChildrenCascade ChildrenMode = "cascade" // the whole subtree goes to the trash
+	ChildrenBlock   ChildrenMode = "block"   // refused with ErrHasChildren
+)
+
+// Precondition guards Update and Delete. Unset fields are not checked.
+type Precondition struct {
+	// RequestTimestamp must be strictly after the task's last_request_timestamp (ErrConflict)
+	RequestTimestamp *time.Time
+	// Version must equal the task's current version (ErrPreconditionFailed)
+	Version *int64
+	// Transition decides whether Update may change the task's status
+	// (a TransitionError otherwise). Nil allows every change.
+	Transition func(from, to string) bool
+	// CascadeProject, when set, keeps a cascading Delete within this project:
+	// subtasks elsewhere and their own subtasks are left as they are.
+	CascadeProject *string
+}
+
+// Check validates the precondition against the task's current state.
+func (p Precondition) Check(lastRequestTS time.Time, version int64) error {
+	if p.Version != nil && *p.Version != version {
+		return ErrPreconditionFailed
+	}
+	if p.RequestTimestamp != nil && !p.RequestTimestamp.After(lastRequestTS) {
+		return ErrConflict
+	}
+	return nil
+}
+
+// CheckTransition validates a status change with Transition.
+func (p Precondition) CheckTransition(from, to string) error {
+	if from == to || p.Transition == nil || p.Transition(from, to) {
+		return nil
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// NextStatus is the status an update moves a task to. An explicit status
+// wins; the legacy done flag means done when true and, when false, reopens a
+// done task as todo.
+func NextStatus(current string, patch model.UpdateTaskRequest) string {
+	switch {
+	case patch.Status != nil:
+		return *patch.Status
+	case patch.Done == nil:
+		return current
+	case *patch.Done:
+		return model.StatusDone
+	case current == model.StatusDone:
+		return model.StatusTodo
+	}
+	return current
+}
+
+// TaskRepository is what the HTTP layer needs from a task backend.
+//
+// Every method is scoped to ownerID: a task owned by someone else behaves
+// exactly like a missing one (ErrNotFound). Update and Delete are guarded by a
+// Precondition; a successful Update bumps the task version and, when a request
+// timestamp was given, its last_request_timestamp. Every mutation appends a
+// history entry atomically with the change, attributed to ActorFrom(ctx).
+type TaskRepository interface {
+	// Create and Update check that a parent is a live task of the same owner
+	// (ErrInvalidParent) and never an own descendant (ErrParentCycle), and
+	// that a project is one of the owner's (ErrInvalidProject).
+	Create(ctx context.Context, ownerID string, in NewTask) (model.Task, error)
+	// List returns one page of tasks and the cursor of the next page (nil on
+	// the last one). p.Shared adds the tasks of other owners' projects.
+	List(ctx context.Context, ownerID string, p model.ListTasksParams) ([]model.Task, *model.TaskCursor, error)
+	Get(ctx context.Context, ownerID, id string) (model.Task, error)
+	Update(ctx context.Context, ownerID, id string, patch model.UpdateTaskRequest, dueDate *time.Time, cond Precondition) (model.Task, error)
+	// Delete moves the task to the trash: it disappears from Get and List
+	// until restored, and can only be listed with ListTasksParams.Trashed.
+	// Its live subtasks are handled according to children.
+	Delete(ctx context.Context, ownerID, id string, cond Precondition, children ChildrenMode) error
+	// Restore brings a trashed task back (ErrNotFound if it is not in the trash).
+	Restore(ctx context.Context, ownerID, id string, cond Precondition) (model.Task, error)
+	// Tree returns a live task with all its live descendants.
+	Tree(ctx context.Context, ownerID, id string) (model.TaskNode, error)
+	// Tags lists the tags of the owner's live tasks, and of those of the
+	// shared projects, with their usage counts, by name.
+	Tags(ctx context.Context, ownerID string, shared []string) ([]model.TagCount, error)
+	// Search returns one page of the owner's live tasks, and of those of
+	// p.Shared, matching every term of p, most relevant first, and whether
+	// more pages follow.
+	Search(ctx context.Context, ownerID string, p model.SearchParams) ([]model.SearchHit, bool, error)
+	// PurgeDeleted permanently removes every task trashed before the given time.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// History returns one page of a task's audit trail, oldest first, and the
+	// ID to resume after (0 on the last page). It stays readable once the task
+	// is trashed or purged.
+	History(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.TaskHistoryEntry, int64, error)
+	// Batch applies ops in order with the rules of Create, Update and Delete,
+	// reporting their errors (see IsOpError) per operation.
+	// When atomic, the first failure undoes the whole batch (see MarkRolledBack);
+	// otherwise every other operation still applies. The error is only set
+	// when the batch as a whole could not run.
+	Batch(ctx context.Context, ownerID string, ops []BatchOp, atomic bool) ([]BatchResult, error)
+}
+
+// BuildTree nests tasks under the one with id rootID, keeping their order.
+// It reports false when the root is not among them.
+func BuildTree(rootID string, tasks []model.Task) (model.TaskNode, bool) {
+	children := make(map[string][]model.Task)
+	var root *model.Task
+	for i, t := range tasks {
+		if t.ID == rootID {
+			root = &tasks[i]
+		} else if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+	if root == nil {
+		return model.TaskNode{}, false
+	}
+
+	var build func(t model.Task) model.TaskNode
+	build = func(t model.Task) model.TaskNode {
+		n := model.TaskNode{Task: t, Children: []model.TaskNode{}}
+		for _, c := range children[t.ID] {
+			n.Children = append(n.Children, build(c))
+		}
+		return n
+	}
+	return build(*root), true
+}
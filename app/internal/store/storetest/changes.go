@@ -0,0 +1,85 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// RunChangeLog checks a ChangeLog against the history written by its
+// TaskRepository.
+func RunChangeLog(t *testing.T, newRepos func(t *testing.T) (store.TaskRepository, store.ChangeLog)) {
+	tasks, log := newRepos(t)
+	ctx := context.Background()
+	owner, other := newOwner(), newOwner()
+
+	a := mustCreate(t, tasks, owner, "a", "2025-06-01")
+	mustCreate(t, tasks, other, "not mine", "2025-06-01")
+	b := mustCreate(t, tasks, owner, "b", "2025-06-01")
+	if _, err := tasks.Update(ctx, owner, a.ID, model.UpdateTaskRequest{Title: ptr("a2")}, nil, at(ts(1))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := tasks.Delete(ctx, owner, b.ID, at(ts(2)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	all, err := log.Changes(ctx, owner, nil, 0, 10)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	want := []struct{ task, action string }{
+		{a.ID, model.ActionCreated}, {b.ID, model.ActionCreated}, {a.ID, model.ActionUpdated}, {b.ID, model.ActionDeleted},
+	}
+	if len(all) != len(want) {
+		t.Fatalf("Changes = %d entries, want %d: %+v", len(all), len(want), all)
+	}
+	for i, w := range want {
+		if all[i].TaskID != w.task || all[i].Action != w.action {
+			t.Errorf("entry %d = %s %s, want %s %s", i, all[i].TaskID, all[i].Action, w.task, w.action)
+		}
+		if i > 0 && all[i].ID <= all[i-1].ID {
+			t.Errorf("entry %d: id %d not after %d", i, all[i].ID, all[i-1].ID)
+		}
+	}
+
+	page, err := log.Changes(ctx, owner, nil, all[0].ID, 2)
+	if err != nil || len(page) != 2 || page[0].ID != all[1].ID || page[1].ID != all[2].ID {
+		t.Errorf("Changes after %d, limit 2 = %+v, %v", all[0].ID, page, err)
+	}
+	if rest, err := log.Changes(ctx, owner, nil, all[3].ID, 10); err != nil || len(rest) != 0 {
+		t.Errorf("Changes after the last = %+v, %v", rest, err)
+	}
+
+	// Overlapping may list entries that committed in order (while another
+	// transaction was running), but only the owner's ones below id
+	overlap, err := log.Overlapping(ctx, owner, nil, all[3].ID)
+	if err != nil {
+		t.Fatalf("Overlapping: %v", err)
+	}
+	for i, e := range overlap {
+		if e.ID >= all[3].ID || e.TaskID != a.ID && e.TaskID != b.ID || i > 0 && e.ID <= overlap[i-1].ID {
+			t.Errorf("Overlapping(%d) = %+v", all[3].ID, overlap)
+		}
+	}
+	if got, err := log.Overlapping(ctx, owner, nil, 1<<62); err != nil || len(got) != 0 {
+		t.Errorf("Overlapping of a missing entry = %+v, %v", got, err)
+	}
+
+	theirs, err := log.Changes(ctx, other, nil, 0, 10)
+	if err != nil || len(theirs) != 1 {
+		t.Fatalf("Changes of the other owner = %+v, %v", theirs, err)
+	}
+
+	got, err := log.ChangesByID(ctx, owner, nil, []int64{all[3].ID, theirs[0].ID, all[1].ID})
+	if err != nil {
+		t.Fatalf("ChangesByID: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != all[1].ID || got[1].ID != all[3].ID {
+		t.Errorf("ChangesByID = %+v, want entries %d and %d of the owner only", got, all[1].ID, all[3].ID)
+	}
+	if got[1].Version != all[3].Version || len(got[1].Changes) != len(all[3].Changes) {
+		t.Errorf("ChangesByID entry %+v differs from %+v", got[1], all[3])
+	}
+}
@@ -0,0 +1,223 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// mustCreateIn creates a task of owner in a project, under parentID unless "".
+func mustCreateIn(t *testing.T, repo store.TaskRepository, owner, projectID, parentID, title string) model.Task {
+	t.Helper()
+	task, err := repo.Create(context.Background(), owner, store.NewTask{Title: title, Content: "c", DueDate: date("2025-06-01"), RequestTimestamp: ts(0), ProjectID: projectID, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("Create %s: %v", title, err)
+	}
+	return task
+}
+
+// RunMemberRepository checks the sharing of projects: invitations,
+// memberships and the access lookups of the policy.
+func RunMemberRepository(t *testing.T, newRepos func(t *testing.T) (store.TaskRepository, store.ProjectRepository, store.MemberRepository)) {
+	tasks, projects, members := newRepos(t)
+	ctx := context.Background()
+	owner, bob, carol := newOwner(), newOwner(), newOwner()
+
+	project, err := projects.CreateProject(ctx, owner, model.Project{Name: "shared"})
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	inside, err := tasks.Create(ctx, owner, store.NewTask{Title: "inside", Content: "c", DueDate: date("2025-06-01"), RequestTimestamp: ts(0), ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	private, err := tasks.Create(ctx, owner, store.NewTask{Title: "private", Content: "c", DueDate: date("2025-06-01"), RequestTimestamp: ts(0)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Before sharing, only the owner has access
+	if a, err := members.ProjectAccess(ctx, owner, project.ID); err != nil || a != (model.Access{OwnerID: owner, ProjectID: project.ID, Role: model.RoleOwner}) {
+		t.Errorf("owner ProjectAccess = %+v, %v", a, err)
+	}
+	if a, err := members.TaskAccess(ctx, owner, inside.ID); err != nil || a != (model.Access{OwnerID: owner, ProjectID: project.ID, Role: model.RoleOwner}) {
+		t.Errorf("owner TaskAccess = %+v, %v", a, err)
+	}
+	if _, err := members.ProjectAccess(ctx, bob, project.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ProjectAccess before sharing: %v, want ErrNotFound", err)
+	}
+	if _, err := members.TaskAccess(ctx, bob, inside.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("TaskAccess before sharing: %v, want ErrNotFound", err)
+	}
+	if _, err := members.TaskAccess(ctx, bob, "not-a-uuid"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("TaskAccess of a malformed id: %v, want ErrNotFound", err)
+	}
+
+	// Invitations
+	if _, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: owner, Role: model.RoleViewer, InvitedBy: owner}); !errors.Is(err, store.ErrAlreadyMember) {
+		t.Errorf("inviting the owner: %v, want ErrAlreadyMember", err)
+	}
+	if _, err := members.CreateInvitation(ctx, bob, project.ID, model.Invitation{Invitee: carol, Role: model.RoleViewer, InvitedBy: bob}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("inviting to someone else's project: %v, want ErrNotFound", err)
+	}
+	first, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: bob, Role: model.RoleViewer, InvitedBy: owner})
+	if err != nil || first.ProjectName != "shared" || first.Role != model.RoleViewer {
+		t.Fatalf("CreateInvitation = %+v, %v", first, err)
+	}
+	again, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: bob, Role: model.RoleEditor, InvitedBy: owner})
+	if err != nil || again.ID != first.ID || again.Role != model.RoleEditor {
+		t.Errorf("inviting again = %+v, %v, want the invitation %s updated", again, err, first.ID)
+	}
+	toCarol, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: carol, Role: model.RoleAdmin, InvitedBy: owner})
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	if list, err := members.ListInvitations(ctx, owner, project.ID); err != nil || len(list) != 2 {
+		t.Errorf("ListInvitations = %+v, %v", list, err)
+	}
+	if list, err := members.PendingInvitations(ctx, bob); err != nil || len(list) != 1 || list[0].ID != first.ID {
+		t.Errorf("PendingInvitations = %+v, %v", list, err)
+	}
+	if _, err := members.AcceptInvitation(ctx, carol, first.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("accepting someone else's invitation: %v, want ErrNotFound", err)
+	}
+	m, err := members.AcceptInvitation(ctx, bob, first.ID)
+	if err != nil || m.UserID != bob || m.Role != model.RoleEditor || m.ProjectID != project.ID {
+		t.Fatalf("AcceptInvitation = %+v, %v", m, err)
+	}
+	if _, err := members.AcceptInvitation(ctx, bob, first.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("accepting twice: %v, want ErrNotFound", err)
+	}
+	if _, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: bob, Role: model.RoleAdmin, InvitedBy: owner}); !errors.Is(err, store.ErrAlreadyMember) {
+		t.Errorf("inviting a member: %v, want ErrAlreadyMember", err)
+	}
+	if err := members.DeclineInvitation(ctx, carol, toCarol.ID); err != nil {
+		t.Errorf("DeclineInvitation: %v", err)
+	}
+	if list, _ := members.PendingInvitations(ctx, carol); len(list) != 0 {
+		t.Errorf("pending after declining = %+v", list)
+	}
+	if err := members.DeleteInvitation(ctx, owner, project.ID, uuid.NewString()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteInvitation of a missing one: %v, want ErrNotFound", err)
+	}
+
+	// Members reach the project and its tasks, not the owner's other tasks
+	want := model.Access{OwnerID: owner, ProjectID: project.ID, Role: model.RoleEditor}
+	if a, err := members.ProjectAccess(ctx, bob, project.ID); err != nil || a != want {
+		t.Errorf("member ProjectAccess = %+v, %v, want %+v", a, err, want)
+	}
+	if a, err := members.TaskAccess(ctx, bob, inside.ID); err != nil || a != want {
+		t.Errorf("member TaskAccess = %+v, %v, want %+v", a, err, want)
+	}
+	if _, err := members.TaskAccess(ctx, bob, private.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("TaskAccess outside the project: %v, want ErrNotFound", err)
+	}
+
+	// Listings of all their tasks include those of the shared projects
+	if got, err := members.SharedProjects(ctx, bob); err != nil || len(got) != 1 || got[0] != want {
+		t.Errorf("SharedProjects = %+v, %v, want [%+v]", got, err, want)
+	}
+	if got, err := members.SharedProjects(ctx, owner); err != nil || len(got) != 0 {
+		t.Errorf("SharedProjects of the owner = %+v, %v", got, err)
+	}
+	mustCreate(t, tasks, bob, "bob's", "2025-06-01")
+	shared := []string{project.ID}
+	if got := sorted(collect(t, tasks, bob, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt, Shared: shared})); fmt.Sprint(got) != "[bob's inside]" {
+		t.Errorf("List with the shared project = %v", got)
+	}
+	hits, _, err := tasks.Search(ctx, bob, model.SearchParams{Terms: []model.SearchTerm{{Text: "inside"}}, Shared: shared, Limit: 10})
+	if err != nil || len(hits) != 1 || hits[0].ID != inside.ID {
+		t.Errorf("Search with the shared project = %+v, %v", hits, err)
+	}
+	if counts, err := tasks.Tags(ctx, bob, shared); err != nil || len(counts) != 0 {
+		t.Errorf("Tags with the shared project = %+v, %v", counts, err)
+	}
+	if log, ok := tasks.(store.ChangeLog); ok {
+		entries, err := log.Changes(ctx, bob, shared, 0, 10)
+		if err != nil || len(entries) != 2 || entries[0].TaskID != inside.ID {
+			t.Errorf("Changes with the shared project = %+v, %v", entries, err)
+		}
+	}
+
+	if err := tasks.Delete(ctx, owner, inside.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if a, err := members.TaskAccess(ctx, bob, inside.ID); err != nil || a != want {
+		t.Errorf("member TaskAccess to a trashed task = %+v, %v", a, err)
+	}
+	if list, err := projects.ListProjects(ctx, bob, false); err != nil || len(list) != 1 || list[0].ID != project.ID || list[0].Role != model.RoleEditor {
+		t.Errorf("member ListProjects = %+v, %v", list, err)
+	}
+	if list, _ := projects.ListProjects(ctx, owner, false); len(list) != 1 || list[0].Role != model.RoleOwner {
+		t.Errorf("owner ListProjects = %+v", list)
+	}
+
+	list, err := members.ListMembers(ctx, owner, project.ID)
+	if err != nil || len(list) != 2 || list[0].UserID != owner || list[0].Role != model.RoleOwner || list[1].UserID != bob {
+		t.Errorf("ListMembers = %+v, %v", list, err)
+	}
+	if _, err := members.ListMembers(ctx, bob, project.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ListMembers as a member: %v, want ErrNotFound", err)
+	}
+	if m, err := members.SetMemberRole(ctx, owner, project.ID, bob, model.RoleViewer); err != nil || m.Role != model.RoleViewer {
+		t.Errorf("SetMemberRole = %+v, %v", m, err)
+	}
+	if _, err := members.SetMemberRole(ctx, owner, project.ID, carol, model.RoleViewer); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("SetMemberRole of a non-member: %v, want ErrNotFound", err)
+	}
+	if err := members.RemoveMember(ctx, owner, project.ID, bob); err != nil {
+		t.Errorf("RemoveMember: %v", err)
+	}
+	if _, err := members.ProjectAccess(ctx, bob, project.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ProjectAccess after removal: %v, want ErrNotFound", err)
+	}
+	if err := members.RemoveMember(ctx, owner, project.ID, bob); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("removing twice: %v, want ErrNotFound", err)
+	}
+
+	// Deleting the project drops its invitations
+	if _, err := members.CreateInvitation(ctx, owner, project.ID, model.Invitation{Invitee: carol, Role: model.RoleViewer, InvitedBy: owner}); err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	if err := projects.DeleteProject(ctx, owner, project.ID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	if list, _ := members.PendingInvitations(ctx, carol); len(list) != 0 {
+		t.Errorf("invitations of a deleted project = %+v", list)
+	}
+
+	// A cascade kept within the project leaves the subtasks elsewhere
+	// (the projects of another owner, not to disturb the counts above)
+	dave := newOwner()
+	here, err := projects.CreateProject(ctx, dave, model.Project{Name: "here"})
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	other, err := projects.CreateProject(ctx, dave, model.Project{Name: "other"})
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	parent := mustCreateIn(t, tasks, dave, here.ID, "", "parent")
+	child := mustCreateIn(t, tasks, dave, here.ID, parent.ID, "child")
+	away := mustCreateIn(t, tasks, dave, other.ID, parent.ID, "away")
+	below := mustCreateIn(t, tasks, dave, here.ID, away.ID, "below")
+	cond := at(ts(1))
+	cond.CascadeProject = &here.ID
+	if err := tasks.Delete(ctx, dave, parent.ID, cond, store.ChildrenCascade); err != nil {
+		t.Fatalf("Delete within the project: %v", err)
+	}
+	if _, err := tasks.Get(ctx, dave, child.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("subtask in the project: %v, want trashed", err)
+	}
+	for _, live := range []model.Task{away, below} {
+		if _, err := tasks.Get(ctx, dave, live.ID); err != nil {
+			t.Errorf("%s: %v, want left as it is", live.Title, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// RunProjectRepository checks a store.ProjectRepository against the tasks of
+// its TaskRepository. Other tests may share the stores: only fresh owners are
+// looked at.
+func RunProjectRepository(t *testing.T, newRepos func(t *testing.T) (store.TaskRepository, store.ProjectRepository)) {
+	tasks, projects := newRepos(t)
+	ctx := context.Background()
+	owner, other := newOwner(), newOwner()
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+
+	create := func(name string) model.Project {
+		t.Helper()
+		p, err := projects.CreateProject(ctx, owner, model.Project{Name: name, Description: "about " + name})
+		if err != nil {
+			t.Fatalf("CreateProject(%q): %v", name, err)
+		}
+		return p
+	}
+	addTask := func(projectID, title, due, status string) model.Task {
+		t.Helper()
+		task, err := tasks.Create(ctx, owner, store.NewTask{Title: title, Content: "c", DueDate: date(due), RequestTimestamp: ts(0), ProjectID: projectID, Status: status})
+		if err != nil {
+			t.Fatalf("Create(%q): %v", title, err)
+		}
+		return task
+	}
+	listed := func(p model.ListTasksParams) []string {
+		t.Helper()
+		p.Limit, p.Sort = 100, model.SortCreatedAt
+		return collect(t, tasks, owner, p)
+	}
+
+	work, home := create("work"), create("home")
+	if work.Archived || work.Name != "work" || work.Description != "about work" || work.OwnerID != owner {
+		t.Fatalf("created %+v", work)
+	}
+	if _, err := projects.GetProject(ctx, other, work.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetProject by another owner: %v, want ErrNotFound", err)
+	}
+	if list, err := projects.ListProjects(ctx, owner, false); err != nil || len(list) != 2 || list[0].ID != home.ID || list[1].ID != work.ID {
+		t.Errorf("ListProjects = %+v, %v, want home then work", list, err)
+	}
+
+	// Tasks join projects of their owner only
+	if _, err := tasks.Create(ctx, other, store.NewTask{Title: "x", Content: "c", DueDate: date("2025-06-01"), RequestTimestamp: ts(0), ProjectID: work.ID}); !errors.Is(err, store.ErrInvalidProject) {
+		t.Errorf("Create in another owner's project: %v, want ErrInvalidProject", err)
+	}
+	overdue := addTask(work.ID, "overdue", "2000-01-01", "")
+	addTask(work.ID, "upcoming", tomorrow, model.StatusInProgress)
+	addTask(work.ID, "done", "2000-01-01", model.StatusDone)
+	addTask(work.ID, "cancelled", "2000-01-01", model.StatusCancelled)
+	trashed := addTask(work.ID, "trashed", "2000-01-01", "")
+	if err := tasks.Delete(ctx, owner, trashed.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	loose := addTask("", "loose", tomorrow, "")
+	if overdue.ProjectID == nil || *overdue.ProjectID != work.ID {
+		t.Errorf("task project_id = %v, want %s", overdue.ProjectID, work.ID)
+	}
+
+	got, err := projects.GetProject(ctx, owner, work.ID)
+	if want := (model.ProjectCounts{Open: 2, Done: 1, Overdue: 1}); err != nil || got.TaskCounts != want {
+		t.Errorf("counts = %+v, %v, want %+v", got.TaskCounts, err, want)
+	}
+
+	// Moving tasks in and out
+	moved, err := tasks.Update(ctx, owner, loose.ID, model.UpdateTaskRequest{ProjectID: ptr(home.ID)}, nil, at(ts(1)))
+	if err != nil || moved.ProjectID == nil || *moved.ProjectID != home.ID {
+		t.Fatalf("move into home: %+v, %v", moved.ProjectID, err)
+	}
+	if _, err := tasks.Update(ctx, owner, loose.ID, model.UpdateTaskRequest{ProjectID: ptr(uuid.NewString())}, nil, at(ts(2))); !errors.Is(err, store.ErrInvalidProject) {
+		t.Errorf("move into a missing project: %v, want ErrInvalidProject", err)
+	}
+	if moved, err = tasks.Update(ctx, owner, loose.ID, model.UpdateTaskRequest{ProjectID: ptr("")}, nil, at(ts(3))); err != nil || moved.ProjectID != nil {
+		t.Errorf("move out: %+v, %v", moved.ProjectID, err)
+	}
+	if got := listed(model.ListTasksParams{ProjectID: &home.ID}); len(got) != 0 {
+		t.Errorf("home tasks = %v after moving out", got)
+	}
+
+	// Archiving hides the tasks from live listings without a project
+	if got := listed(model.ListTasksParams{}); len(got) != 5 {
+		t.Fatalf("listed %v before archiving, want 5", got)
+	}
+	archived, err := projects.SetArchived(ctx, owner, work.ID, true)
+	if err != nil || !archived.Archived || archived.ArchivedAt == nil {
+		t.Fatalf("SetArchived: %+v, %v", archived, err)
+	}
+	again, err := projects.SetArchived(ctx, owner, work.ID, true)
+	if err != nil || !again.ArchivedAt.Equal(*archived.ArchivedAt) {
+		t.Errorf("archiving twice moved archived_at: %+v, %v", again, err)
+	}
+	if got := listed(model.ListTasksParams{}); len(got) != 1 || got[0] != "loose" {
+		t.Errorf("listed %v with work archived, want [loose]", got)
+	}
+	if got := listed(model.ListTasksParams{IncludeArchived: true}); len(got) != 5 {
+		t.Errorf("listed %v including archived, want 5", got)
+	}
+	if got := listed(model.ListTasksParams{ProjectID: &work.ID}); len(got) != 4 {
+		t.Errorf("listed %v in the archived project, want 4", got)
+	}
+	if list, _ := projects.ListProjects(ctx, owner, false); len(list) != 1 || list[0].ID != home.ID {
+		t.Errorf("ListProjects without archived = %+v", list)
+	}
+	if list, _ := projects.ListProjects(ctx, owner, true); len(list) != 2 {
+		t.Errorf("ListProjects with archived = %+v", list)
+	}
+	if p, err := projects.SetArchived(ctx, owner, work.ID, false); err != nil || p.Archived || p.ArchivedAt != nil {
+		t.Errorf("unarchive: %+v, %v", p, err)
+	}
+
+	renamed, err := projects.UpdateProject(ctx, owner, home.ID, model.UpdateProjectRequest{Name: ptr("house")})
+	if err != nil || renamed.Name != "house" || renamed.Description != "about home" {
+		t.Errorf("UpdateProject: %+v, %v", renamed, err)
+	}
+	if _, err := projects.UpdateProject(ctx, other, home.ID, model.UpdateProjectRequest{Name: ptr("x")}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdateProject by another owner: %v, want ErrNotFound", err)
+	}
+
+	// Only projects without live tasks go; their trashed tasks leave them
+	if err := projects.DeleteProject(ctx, owner, work.ID); !errors.Is(err, store.ErrProjectNotEmpty) {
+		t.Errorf("DeleteProject with tasks: %v, want ErrProjectNotEmpty", err)
+	}
+	if err := projects.DeleteProject(ctx, other, home.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteProject by another owner: %v, want ErrNotFound", err)
+	}
+	page, _, err := tasks.List(ctx, owner, model.ListTasksParams{Limit: 100, Sort: model.SortCreatedAt, ProjectID: &work.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range page {
+		if err := tasks.Delete(ctx, owner, task.ID, at(ts(9)), store.ChildrenOrphan); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+	if err := projects.DeleteProject(ctx, owner, work.ID); err != nil {
+		t.Fatalf("DeleteProject once emptied: %v", err)
+	}
+	if _, err := projects.GetProject(ctx, owner, work.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetProject after delete: %v, want ErrNotFound", err)
+	}
+	restored, err := tasks.Restore(ctx, owner, trashed.ID, at(ts(10)))
+	if err != nil || restored.ProjectID != nil {
+		t.Errorf("restored task of a deleted project: %+v, %v", restored.ProjectID, err)
+	}
+}
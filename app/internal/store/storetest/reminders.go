@@ -0,0 +1,114 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// RunReminderRepository checks a store.ReminderRepository against the tasks of
+// its TaskRepository. Other tests may share the stores: only the reminders of
+// a fresh owner are looked at.
+func RunReminderRepository(t *testing.T, newRepos func(t *testing.T) (store.TaskRepository, store.ReminderRepository)) {
+	tasks, reminders := newRepos(t)
+	ctx := context.Background()
+	owner := newOwner()
+	now := time.Now().UTC().Truncate(time.Second)
+	day := func(n int) time.Time { return now.AddDate(0, 0, n).Truncate(24 * time.Hour) }
+	create := func(title string, due time.Time, status string) model.Task {
+		t.Helper()
+		task, err := tasks.Create(ctx, owner, store.NewTask{Title: title, Content: "c", DueDate: due, RequestTimestamp: ts(0), Status: status})
+		if err != nil {
+			t.Fatalf("Create(%q): %v", title, err)
+		}
+		return task
+	}
+	enqueue := func() {
+		t.Helper()
+		if _, err := reminders.Enqueue(ctx, now, now.Add(48*time.Hour)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	pending := func(at time.Time) []model.Reminder {
+		t.Helper()
+		all, err := reminders.Pending(ctx, at, 1000)
+		if err != nil {
+			t.Fatalf("Pending: %v", err)
+		}
+		var mine []model.Reminder
+		for _, r := range all {
+			if r.Task.OwnerID == owner {
+				mine = append(mine, r)
+			}
+		}
+		return mine
+	}
+
+	soon := create("soon", day(1), "")
+	create("later", day(10), "")
+	create("closed", day(1), model.StatusDone)
+	trashed := create("trashed", day(1), "")
+	if err := tasks.Delete(ctx, owner, trashed.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	enqueue()
+	enqueue()
+	got := pending(now)
+	if len(got) != 1 || got[0].Task.ID != soon.ID || got[0].Attempt != 1 || got[0].Type != model.EventTaskDueSoon || got[0].ID == "" {
+		t.Fatalf("pending = %+v, want one first attempt for %q", got, soon.Title)
+	}
+	id := got[0].ID
+
+	retryAt := now.Add(time.Hour)
+	if err := reminders.MarkFailed(ctx, id, 1, &retryAt, "boom"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if got := pending(now); len(got) != 0 {
+		t.Errorf("pending before retry = %+v", got)
+	}
+	if got := pending(retryAt); len(got) != 1 || got[0].ID != id || got[0].Attempt != 2 {
+		t.Fatalf("pending at retry = %+v, want attempt 2 of %s", got, id)
+	}
+
+	if err := reminders.MarkSent(ctx, id, retryAt); err != nil {
+		t.Fatalf("MarkSent: %v", err)
+	}
+	enqueue()
+	if got := pending(retryAt); len(got) != 0 {
+		t.Errorf("sent reminder came back: %+v", got)
+	}
+
+	// Rescheduling gives the task a new reminder; closing it skips a pending one
+	moved, err := tasks.Update(ctx, owner, soon.ID, model.UpdateTaskRequest{DueDate: ptr(day(2).Format("2006-01-02"))}, ptr(day(2)), at(ts(2)))
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	enqueue()
+	got = pending(now)
+	if len(got) != 1 || got[0].Task.DueDate != moved.DueDate {
+		t.Fatalf("pending after reschedule = %+v", got)
+	}
+	if _, err := tasks.Update(ctx, owner, soon.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(3))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := pending(now); len(got) != 0 {
+		t.Errorf("reminder of a done task = %+v", got)
+	}
+
+	other := create("other", day(0), "")
+	enqueue()
+	got = pending(now)
+	if len(got) != 1 || got[0].Task.ID != other.ID {
+		t.Fatalf("pending = %+v, want %q", got, other.Title)
+	}
+	if err := reminders.MarkFailed(ctx, got[0].ID, 1, nil, "gone"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if got := pending(now.Add(24 * time.Hour)); len(got) != 0 {
+		t.Errorf("given up reminder = %+v", got)
+	}
+}
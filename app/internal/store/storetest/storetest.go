@@ -0,0 +1,1058 @@
+// Package storetest is the conformance suite every store.TaskRepository
+// implementation must pass.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// RunTaskRepository runs the suite against repositories built by newRepo.
+// Each test uses fresh owner IDs, so repositories may share state between tests.
+func RunTaskRepository(t *testing.T, newRepo func(t *testing.T) store.TaskRepository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo store.TaskRepository)
+	}{
+		{"CreateGet", testCreateGet},
+		{"OwnerIsolation", testOwnerIsolation},
+		{"UpdatePatch", testUpdatePatch},
+		{"UpdateConflict", testUpdateConflict},
+		{"DeleteConflict", testDeleteConflict},
+		{"VersionPrecondition", testVersionPrecondition},
+		{"TrashRestorePurge", testTrashRestorePurge},
+		{"NotFound", testNotFound},
+		{"ListPagination", testListPagination},
+		{"ListFilters", testListFilters},
+		{"History", testHistory},
+		{"BatchAtomic", testBatchAtomic},
+		{"BatchBestEffort", testBatchBestEffort},
+		{"Subtasks", testSubtasks},
+		{"SubtaskCycles", testSubtaskCycles},
+		{"DeleteChildren", testDeleteChildren},
+		{"Tags", testTags},
+		{"Search", testSearch},
+		{"StatusWorkflow", testStatusWorkflow},
+		{"Recurrence", testRecurrence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+// ts returns a fixed base time shifted by n seconds.
+func ts(n int) time.Time {
+	return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(n) * time.Second)
+}
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func newOwner() string {
+	return "owner-" + uuid.NewString()
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// at is the legacy request_timestamp precondition.
+func at(t time.Time) store.Precondition {
+	return store.Precondition{RequestTimestamp: &t}
+}
+
+func mustCreate(t *testing.T, repo store.TaskRepository, owner, title, due string) model.Task {
+	t.Helper()
+	return mustCreateChild(t, repo, owner, "", title, due)
+}
+
+func mustCreateChild(t *testing.T, repo store.TaskRepository, owner, parentID, title, due string) model.Task {
+	t.Helper()
+	task, err := repo.Create(context.Background(), owner, store.NewTask{Title: title, Content: "content of " + title, DueDate: date(due), RequestTimestamp: ts(0), ParentID: parentID})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return task
+}
+
+func testCreateGet(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+
+	created, err := repo.Create(ctx, owner, store.NewTask{Title: "write tests", Content: "cover the store", DueDate: date("2025-03-14"), RequestTimestamp: ts(0)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.OwnerID != owner || created.Done {
+		t.Fatalf("Create returned %+v", created)
+	}
+	if created.DueDate != "2025-03-14" || !created.LastRequestTimestamp.Equal(ts(0)) {
+		t.Fatalf("Create returned due_date %q, last_request_timestamp %s", created.DueDate, created.LastRequestTimestamp)
+	}
+
+	got, err := repo.Get(ctx, owner, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Title != "write tests" || got.Content != "cover the store" || !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("Get returned %+v, want %+v", got, created)
+	}
+}
+
+func testOwnerIsolation(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	alice, bob := newOwner(), newOwner()
+	task := mustCreate(t, repo, alice, "alice's", "2025-01-10")
+
+	if _, err := repo.Get(ctx, bob, task.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get by other owner: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Update(ctx, bob, task.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(10))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update by other owner: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, bob, task.ID, at(ts(10)), store.ChildrenOrphan); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete by other owner: err = %v, want ErrNotFound", err)
+	}
+
+	tasks, _, err := repo.List(ctx, bob, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt, Desc: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("List by other owner returned %d tasks", len(tasks))
+	}
+
+	got, err := repo.Get(ctx, alice, task.ID)
+	if err != nil || got.Done {
+		t.Errorf("owner's task changed by someone else: %+v, %v", got, err)
+	}
+}
+
+func testUpdatePatch(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "draft", "2025-01-10")
+
+	due := date("2025-02-01")
+	updated, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("final"), Done: ptr(true)}, &due, at(ts(5)))
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Title != "final" || !updated.Done || updated.DueDate != "2025-02-01" {
+		t.Errorf("Update returned %+v", updated)
+	}
+	if updated.Content != task.Content {
+		t.Errorf("omitted content changed: %q", updated.Content)
+	}
+	if !updated.LastRequestTimestamp.Equal(ts(5)) {
+		t.Errorf("last_request_timestamp = %s, want %s", updated.LastRequestTimestamp, ts(5))
+	}
+	if updated.UpdatedAt.Before(task.UpdatedAt) {
+		t.Errorf("updated_at went backwards")
+	}
+
+	got, err := repo.Get(ctx, owner, task.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Title != "final" || !got.Done {
+		t.Errorf("update not persisted: %+v", got)
+	}
+}
+
+func testUpdateConflict(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "t", "2025-01-10")
+
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(0))); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Update with same timestamp: err = %v, want ErrConflict", err)
+	}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(-1))); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Update with older timestamp: err = %v, want ErrConflict", err)
+	}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("v2")}, nil, at(ts(10))); err != nil {
+		t.Fatalf("Update with newer timestamp: %v", err)
+	}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("stale")}, nil, at(ts(9))); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Update older than the last accepted one: err = %v, want ErrConflict", err)
+	}
+
+	got, err := repo.Get(ctx, owner, task.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Title != "v2" || got.Done {
+		t.Errorf("rejected updates were applied: %+v", got)
+	}
+}
+
+func testDeleteConflict(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "t", "2025-01-10")
+
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(0)), store.ChildrenOrphan); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Delete with same timestamp: err = %v, want ErrConflict", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, owner, task.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(2)), store.ChildrenOrphan); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testVersionPrecondition(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "t", "2025-01-10")
+	if task.Version != 1 {
+		t.Fatalf("new task version = %d, want 1", task.Version)
+	}
+
+	updated, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("v2")}, nil, store.Precondition{Version: ptr(int64(1))})
+	if err != nil {
+		t.Fatalf("Update with current version: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("version after update = %d, want 2", updated.Version)
+	}
+	if !updated.LastRequestTimestamp.Equal(task.LastRequestTimestamp) {
+		t.Errorf("last_request_timestamp changed without a request timestamp: %s", updated.LastRequestTimestamp)
+	}
+
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("lost")}, nil, store.Precondition{Version: ptr(int64(1))}); !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Errorf("Update with stale version: err = %v, want ErrPreconditionFailed", err)
+	}
+	both := store.Precondition{Version: ptr(int64(2)), RequestTimestamp: ptr(ts(0))}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("lost")}, nil, both); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Update with current version but stale timestamp: err = %v, want ErrConflict", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, store.Precondition{Version: ptr(int64(1))}, store.ChildrenOrphan); !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Errorf("Delete with stale version: err = %v, want ErrPreconditionFailed", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, store.Precondition{Version: ptr(int64(2))}, store.ChildrenOrphan); err != nil {
+		t.Errorf("Delete with current version: %v", err)
+	}
+}
+
+func testTrashRestorePurge(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	kept := mustCreate(t, repo, owner, "kept", "2025-01-10")
+	task := mustCreate(t, repo, owner, "trashed", "2025-01-10")
+	trash := model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt, Trashed: true}
+	live := model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt}
+
+	if _, err := repo.Restore(ctx, owner, task.ID, at(ts(1))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Restore of a live task: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(5)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("x")}, nil, at(ts(6))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update of a trashed task: err = %v, want ErrNotFound", err)
+	}
+	if got := collect(t, repo, owner, trash); fmt.Sprint(got) != "[trashed]" {
+		t.Errorf("trash = %v, want [trashed]", got)
+	}
+	if got := collect(t, repo, owner, live); fmt.Sprint(got) != "[kept]" {
+		t.Errorf("live tasks = %v, want [kept]", got)
+	}
+	if got := collect(t, repo, newOwner(), trash); len(got) != 0 {
+		t.Errorf("someone else's trash = %v", got)
+	}
+
+	if _, err := repo.Restore(ctx, owner, task.ID, at(ts(5))); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Restore with the delete's timestamp: err = %v, want ErrConflict", err)
+	}
+	if _, err := repo.Restore(ctx, newOwner(), task.ID, at(ts(7))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Restore by other owner: err = %v, want ErrNotFound", err)
+	}
+	restored, err := repo.Restore(ctx, owner, task.ID, at(ts(7)))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Version != 3 || !restored.LastRequestTimestamp.Equal(ts(7)) {
+		t.Errorf("Restore returned %+v", restored)
+	}
+	if _, err := repo.Get(ctx, owner, task.ID); err != nil {
+		t.Errorf("Get after Restore: %v", err)
+	}
+
+	// Only tasks trashed before the cut-off are purged
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(8)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if got := collect(t, repo, owner, trash); len(got) != 1 {
+		t.Errorf("recently trashed task purged: trash = %v", got)
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if got := collect(t, repo, owner, trash); len(got) != 0 {
+		t.Errorf("trash after purge = %v", got)
+	}
+	if _, err := repo.Restore(ctx, owner, task.ID, at(ts(9))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Restore after purge: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Get(ctx, owner, kept.ID); err != nil {
+		t.Errorf("live task purged: %v", err)
+	}
+}
+
+func testNotFound(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	id := uuid.NewString()
+
+	if _, err := repo.Get(ctx, owner, id); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Update(ctx, owner, id, model.UpdateTaskRequest{}, nil, at(ts(1))); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, owner, id, at(ts(1)), store.ChildrenOrphan); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+// collect walks every page and returns the titles in order.
+func collect(t *testing.T, repo store.TaskRepository, owner string, p model.ListTasksParams) []string {
+	t.Helper()
+	var titles []string
+	for page := 0; ; page++ {
+		if page > 100 {
+			t.Fatal("pagination does not terminate")
+		}
+		tasks, next, err := repo.List(context.Background(), owner, p)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(tasks) > p.Limit {
+			t.Fatalf("List returned %d tasks, limit %d", len(tasks), p.Limit)
+		}
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		if next == nil {
+			return titles
+		}
+		if len(tasks) != p.Limit {
+			t.Fatalf("non-final page has %d tasks, want %d", len(tasks), p.Limit)
+		}
+		p.After = next
+	}
+}
+
+func testListPagination(t *testing.T, repo store.TaskRepository) {
+	owner := newOwner()
+	// Duplicate due dates exercise the id tie-breaker
+	dues := []string{"2025-01-03", "2025-01-01", "2025-01-02", "2025-01-01", "2025-01-05", "2025-01-04", "2025-01-02"}
+	var created []model.Task
+	for i, due := range dues {
+		created = append(created, mustCreate(t, repo, owner, fmt.Sprintf("task-%d", i), due))
+	}
+
+	for _, sort := range []string{model.SortCreatedAt, model.SortUpdatedAt, model.SortDueDate} {
+		for _, desc := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/desc=%v", sort, desc), func(t *testing.T) {
+				all := collect(t, repo, owner, model.ListTasksParams{Limit: 100, Sort: sort, Desc: desc})
+				if len(all) != len(dues) {
+					t.Fatalf("single page returned %d tasks, want %d", len(all), len(dues))
+				}
+				assertOrdered(t, created, all, sort, desc)
+
+				paged := collect(t, repo, owner, model.ListTasksParams{Limit: 2, Sort: sort, Desc: desc})
+				if fmt.Sprint(paged) != fmt.Sprint(all) {
+					t.Errorf("paged walk = %v, want %v", paged, all)
+				}
+			})
+		}
+	}
+}
+
+func assertOrdered(t *testing.T, created []model.Task, titles []string, sort string, desc bool) {
+	t.Helper()
+	byTitle := make(map[string]model.Task, len(created))
+	for _, task := range created {
+		byTitle[task.Title] = task
+	}
+	for i := 1; i < len(titles); i++ {
+		prev := model.NewTaskCursor(byTitle[titles[i-1]], sort, desc)
+		cur := model.NewTaskCursor(byTitle[titles[i]], sort, desc)
+		c := cur.Value.Compare(prev.Value)
+		if desc {
+			c = -c
+		}
+		if c < 0 {
+			t.Fatalf("%v not ordered by %s (desc=%v) at %d", titles, sort, desc, i)
+		}
+	}
+}
+
+func testListFilters(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	mustCreate(t, repo, owner, "Buy milk", "2025-01-01")
+	mustCreate(t, repo, owner, "Pay rent", "2025-01-05")
+	rent := mustCreate(t, repo, owner, "Renew 100% of passport", "2025-01-10")
+	if _, err := repo.Update(ctx, owner, rent.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(1))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	base := model.ListTasksParams{Limit: 10, Sort: model.SortDueDate}
+	tests := []struct {
+		name   string
+		modify func(p *model.ListTasksParams)
+		want   []string
+	}{
+		{"done", func(p *model.ListTasksParams) { p.Done = ptr(true) }, []string{"Renew 100% of passport"}},
+		{"not done", func(p *model.ListTasksParams) { p.Done = ptr(false) }, []string{"Buy milk", "Pay rent"}},
+		{"due before is exclusive", func(p *model.ListTasksParams) { p.DueBefore = ptr(date("2025-01-05")) }, []string{"Buy milk"}},
+		{"due after is exclusive", func(p *model.ListTasksParams) { p.DueAfter = ptr(date("2025-01-05")) }, []string{"Renew 100% of passport"}},
+		{"query is case-insensitive", func(p *model.ListTasksParams) { p.Query = "RENT" }, []string{"Pay rent"}},
+		{"query matches content", func(p *model.ListTasksParams) { p.Query = "content of buy" }, []string{"Buy milk"}},
+		{"query escapes wildcards", func(p *model.ListTasksParams) { p.Query = "100%" }, []string{"Renew 100% of passport"}},
+		{"query underscore is literal", func(p *model.ListTasksParams) { p.Query = "p_y" }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := base
+			tt.modify(&p)
+			got := collect(t, repo, owner, p)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testHistory(t *testing.T, repo store.TaskRepository) {
+	owner := newOwner()
+	ctx := store.WithActor(context.Background(), store.Actor{ID: "auditor", CorrelationID: "corr-1"})
+
+	task, err := repo.Create(ctx, owner, store.NewTask{Title: "draft", Content: "body", DueDate: date("2025-01-10"), RequestTimestamp: ts(0)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("final"), Content: ptr("body")}, nil, at(ts(1))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	// Rejected mutations leave no trace
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("stale")}, nil, at(ts(1))); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("stale Update: err = %v, want ErrConflict", err)
+	}
+	if err := repo.Delete(ctx, owner, task.ID, store.Precondition{Version: ptr(int64(2))}, store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Restore(context.Background(), owner, task.ID, at(ts(3))); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	var entries []model.TaskHistoryEntry
+	p := model.HistoryParams{Limit: 3}
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("history pagination does not terminate")
+		}
+		items, next, err := repo.History(ctx, owner, task.ID, p)
+		if err != nil {
+			t.Fatalf("History: %v", err)
+		}
+		entries = append(entries, items...)
+		if next == 0 {
+			break
+		}
+		p.AfterID = next
+	}
+
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	if fmt.Sprint(actions) != "[created updated deleted restored]" {
+		t.Fatalf("actions = %v", actions)
+	}
+	for i, e := range entries {
+		if e.TaskID != task.ID || e.Version != int64(i+1) {
+			t.Errorf("entry %d = %+v", i, e)
+		}
+	}
+
+	created, updated, deleted, restored := entries[0], entries[1], entries[2], entries[3]
+	if created.Actor != "auditor" || created.CorrelationID != "corr-1" || created.RequestTimestamp == nil || !created.RequestTimestamp.Equal(ts(0)) {
+		t.Errorf("created entry = %+v", created)
+	}
+	if c := created.Changes["title"]; c.From != nil || c.To != "draft" {
+		t.Errorf("created title change = %+v", c)
+	}
+	if len(updated.Changes) != 1 || updated.Changes["title"] != (model.FieldChange{From: "draft", To: "final"}) {
+		t.Errorf("updated changes = %+v, want only the title", updated.Changes)
+	}
+	if deleted.RequestTimestamp != nil || deleted.Changes["deleted"] != (model.FieldChange{From: false, To: true}) {
+		t.Errorf("deleted entry = %+v", deleted)
+	}
+	if restored.Actor != owner || restored.CorrelationID != "" {
+		t.Errorf("restore without actor attributed to %q (correlation %q), want the owner", restored.Actor, restored.CorrelationID)
+	}
+
+	if _, _, err := repo.History(ctx, newOwner(), task.ID, model.HistoryParams{Limit: 10}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("History by other owner: err = %v, want ErrNotFound", err)
+	}
+
+	// The trail outlives the task
+	if err := repo.Delete(ctx, owner, task.ID, at(ts(4)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	items, _, err := repo.History(ctx, owner, task.ID, model.HistoryParams{Limit: 10})
+	if err != nil || len(items) != 5 {
+		t.Errorf("History after purge: %d entries, err %v", len(items), err)
+	}
+}
+
+func testBatchAtomic(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "existing", "2025-01-10")
+
+	ops := []store.BatchOp{
+		{Kind: model.BatchOpCreate, New: store.NewTask{Title: "new", Content: "c", DueDate: date("2025-02-01"), RequestTimestamp: ts(0)}},
+		{Kind: model.BatchOpUpdate, ID: task.ID, Patch: model.UpdateTaskRequest{Title: ptr("renamed")}, Cond: at(ts(1))},
+		{Kind: model.BatchOpDelete, ID: task.ID, Cond: at(ts(1))}, // same timestamp as the update: conflict
+	}
+	results, err := repo.Batch(ctx, owner, ops, true)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(results) != 3 || !errors.Is(results[0].Err, store.ErrRolledBack) || !errors.Is(results[1].Err, store.ErrRolledBack) || !errors.Is(results[2].Err, store.ErrConflict) {
+		t.Fatalf("results = %+v", results)
+	}
+	if got := collect(t, repo, owner, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt}); fmt.Sprint(got) != "[existing]" {
+		t.Errorf("tasks after rolled back batch = %v", got)
+	}
+	if entries, _, err := repo.History(ctx, owner, task.ID, model.HistoryParams{Limit: 10}); err != nil || len(entries) != 1 {
+		t.Errorf("history after rolled back batch: %d entries, err %v", len(entries), err)
+	}
+
+	ops[2].Cond = at(ts(2))
+	results, err = repo.Batch(ctx, owner, ops, true)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("result %d: %v", i, r.Err)
+		}
+	}
+	if results[0].Task.Title != "new" || results[0].Task.Version != 1 || results[1].Task.Title != "renamed" || results[1].Task.Version != 2 {
+		t.Errorf("results = %+v", results)
+	}
+	if got := collect(t, repo, owner, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt}); fmt.Sprint(got) != "[new]" {
+		t.Errorf("tasks after batch = %v", got)
+	}
+}
+
+func testBatchBestEffort(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "existing", "2025-01-10")
+	other := mustCreate(t, repo, newOwner(), "someone else's", "2025-01-10")
+
+	results, err := repo.Batch(ctx, owner, []store.BatchOp{
+		{Kind: model.BatchOpUpdate, ID: other.ID, Patch: model.UpdateTaskRequest{Done: ptr(true)}, Cond: at(ts(1))},
+		{Kind: model.BatchOpUpdate, ID: task.ID, Patch: model.UpdateTaskRequest{Done: ptr(true)}, Cond: at(ts(0))},
+		{Kind: model.BatchOpUpdate, ID: task.ID, Patch: model.UpdateTaskRequest{Title: ptr("renamed")}, Cond: store.Precondition{Version: ptr(int64(1))}},
+		{Kind: model.BatchOpDelete, ID: task.ID, Cond: store.Precondition{Version: ptr(int64(1))}},
+		{Kind: model.BatchOpCreate, New: store.NewTask{Title: "new", Content: "c", DueDate: date("2025-02-01"), RequestTimestamp: ts(0)}},
+	}, false)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	want := []error{store.ErrNotFound, store.ErrConflict, nil, store.ErrPreconditionFailed, nil}
+	for i, r := range results {
+		if !errors.Is(r.Err, want[i]) || (want[i] == nil && r.Err != nil) {
+			t.Errorf("result %d: err = %v, want %v", i, r.Err, want[i])
+		}
+	}
+	if got := collect(t, repo, owner, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt}); fmt.Sprint(sorted(got)) != "[new renamed]" {
+		t.Errorf("tasks after batch = %v", got)
+	}
+	if got, err := repo.Get(ctx, owner, task.ID); err != nil || got.Done || got.Version != 2 {
+		t.Errorf("task after batch = %+v, %v", got, err)
+	}
+}
+
+func testSubtasks(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	parent := mustCreate(t, repo, owner, "parent", "2025-01-10")
+	if parent.ParentID != nil || parent.Progress != nil {
+		t.Fatalf("top-level task without subtasks = %+v", parent)
+	}
+	a := mustCreateChild(t, repo, owner, parent.ID, "a", "2025-01-10")
+	b := mustCreateChild(t, repo, owner, parent.ID, "b", "2025-01-10")
+	mustCreateChild(t, repo, owner, parent.ID, "c", "2025-01-10")
+	grandchild := mustCreateChild(t, repo, owner, a.ID, "a1", "2025-01-10")
+	if a.ParentID == nil || *a.ParentID != parent.ID {
+		t.Fatalf("child parent_id = %v", a.ParentID)
+	}
+
+	if _, err := repo.Update(ctx, owner, b.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(1))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.Get(ctx, owner, parent.ID)
+	if err != nil || got.Progress == nil || *got.Progress != 33 {
+		t.Fatalf("parent progress = %v, err %v; want 33", got.Progress, err)
+	}
+
+	children := model.ListTasksParams{Limit: 2, Sort: model.SortCreatedAt, ParentID: &parent.ID}
+	if got := collect(t, repo, owner, children); fmt.Sprint(sorted(got)) != "[a b c]" {
+		t.Errorf("children = %v", got)
+	}
+
+	tree, err := repo.Tree(ctx, owner, parent.ID)
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	if len(tree.Children) != 3 {
+		t.Fatalf("tree has %d children, want 3", len(tree.Children))
+	}
+	for _, n := range tree.Children {
+		want := 0
+		if n.ID == a.ID {
+			want = 1
+		}
+		if len(n.Children) != want || (want == 1 && n.Children[0].ID != grandchild.ID) {
+			t.Errorf("subtree of %s = %+v", n.Title, n.Children)
+		}
+	}
+	if _, err := repo.Tree(ctx, newOwner(), parent.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Tree by other owner: err = %v, want ErrNotFound", err)
+	}
+
+	for name, parentID := range map[string]string{
+		"missing":        uuid.NewString(),
+		"someone else's": mustCreate(t, repo, newOwner(), "other", "2025-01-10").ID,
+		"trashed":        trashed(t, repo, owner),
+	} {
+		in := store.NewTask{Title: "x", Content: "x", DueDate: date("2025-01-10"), RequestTimestamp: ts(0), ParentID: parentID}
+		if _, err := repo.Create(ctx, owner, in); !errors.Is(err, store.ErrInvalidParent) {
+			t.Errorf("Create under a %s parent: err = %v, want ErrInvalidParent", name, err)
+		}
+	}
+
+	// An empty parent_id detaches the task
+	detached, err := repo.Update(ctx, owner, grandchild.ID, model.UpdateTaskRequest{ParentID: ptr("")}, nil, at(ts(1)))
+	if err != nil || detached.ParentID != nil {
+		t.Fatalf("detach: %+v, %v", detached, err)
+	}
+}
+
+// sorted returns titles in alphabetical order, for tasks created within the same clock tick.
+func sorted(titles []string) []string {
+	out := append([]string(nil), titles...)
+	sort.Strings(out)
+	return out
+}
+
+func trashed(t *testing.T, repo store.TaskRepository, owner string) string {
+	t.Helper()
+	task := mustCreate(t, repo, owner, "trashed", "2025-01-10")
+	if err := repo.Delete(context.Background(), owner, task.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	return task.ID
+}
+
+func testSubtaskCycles(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	root := mustCreate(t, repo, owner, "root", "2025-01-10")
+	child := mustCreateChild(t, repo, owner, root.ID, "child", "2025-01-10")
+	grandchild := mustCreateChild(t, repo, owner, child.ID, "grandchild", "2025-01-10")
+
+	for name, parentID := range map[string]string{"itself": root.ID, "its child": child.ID, "its grandchild": grandchild.ID} {
+		if _, err := repo.Update(ctx, owner, root.ID, model.UpdateTaskRequest{ParentID: ptr(parentID)}, nil, at(ts(1))); !errors.Is(err, store.ErrParentCycle) {
+			t.Errorf("moving root under %s: err = %v, want ErrParentCycle", name, err)
+		}
+	}
+
+	// Moving a subtree elsewhere is fine
+	other := mustCreate(t, repo, owner, "other", "2025-01-10")
+	moved, err := repo.Update(ctx, owner, child.ID, model.UpdateTaskRequest{ParentID: ptr(other.ID)}, nil, at(ts(1)))
+	if err != nil || moved.ParentID == nil || *moved.ParentID != other.ID {
+		t.Fatalf("move: %+v, %v", moved, err)
+	}
+	if _, err := repo.Update(ctx, owner, root.ID, model.UpdateTaskRequest{ParentID: ptr(grandchild.ID)}, nil, at(ts(2))); err != nil {
+		t.Errorf("moving root under a task that left its subtree: %v", err)
+	}
+}
+
+func testDeleteChildren(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	live := model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt}
+
+	parent := mustCreate(t, repo, owner, "parent", "2025-01-10")
+	child := mustCreateChild(t, repo, owner, parent.ID, "child", "2025-01-10")
+	if err := repo.Delete(ctx, owner, parent.ID, at(ts(1)), store.ChildrenBlock); !errors.Is(err, store.ErrHasChildren) {
+		t.Fatalf("block: err = %v, want ErrHasChildren", err)
+	}
+	if got := collect(t, repo, owner, live); fmt.Sprint(sorted(got)) != "[child parent]" {
+		t.Fatalf("tasks after blocked delete = %v", got)
+	}
+
+	if err := repo.Delete(ctx, owner, parent.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("orphan: %v", err)
+	}
+	got, err := repo.Get(ctx, owner, child.ID)
+	if err != nil || got.ParentID != nil || got.Version != 2 {
+		t.Errorf("orphaned child = %+v, %v", got, err)
+	}
+
+	root := mustCreate(t, repo, owner, "root", "2025-01-10")
+	mid := mustCreateChild(t, repo, owner, root.ID, "mid", "2025-01-10")
+	mustCreateChild(t, repo, owner, mid.ID, "leaf", "2025-01-10")
+	if err := repo.Delete(ctx, owner, root.ID, at(ts(1)), store.ChildrenCascade); err != nil {
+		t.Fatalf("cascade: %v", err)
+	}
+	if got := collect(t, repo, owner, live); fmt.Sprint(got) != "[child]" {
+		t.Errorf("live tasks after cascade = %v", got)
+	}
+	trash := model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt, Trashed: true}
+	if got := collect(t, repo, owner, trash); fmt.Sprint(sorted(got)) != "[leaf mid parent root]" {
+		t.Errorf("trash after cascade = %v", got)
+	}
+
+	// Purging a parent turns its remaining children into top-level tasks
+	restored, err := repo.Restore(ctx, owner, mid.ID, at(ts(2)))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.ParentID == nil {
+		t.Fatalf("restored child lost its parent")
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	got, err = repo.Get(ctx, owner, mid.ID)
+	if err != nil || got.ParentID != nil {
+		t.Errorf("child of a purged parent = %+v, %v", got, err)
+	}
+}
+
+func testTags(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	create := func(title string, tags ...string) model.Task {
+		t.Helper()
+		task, err := repo.Create(ctx, owner, store.NewTask{Title: title, Content: "c", DueDate: date("2025-01-10"), RequestTimestamp: ts(0), Tags: tags})
+		if err != nil {
+			t.Fatalf("Create(%q): %v", title, err)
+		}
+		return task
+	}
+	home := create("home", "home")
+	both := create("both", "home", "work")
+	create("work", "work")
+	create("untagged")
+	if fmt.Sprint(both.Tags) != "[home work]" {
+		t.Errorf("Create returned tags %v", both.Tags)
+	}
+	if got, _ := repo.Get(ctx, owner, both.ID); fmt.Sprint(got.Tags) != "[home work]" {
+		t.Errorf("Get returned tags %v", got.Tags)
+	}
+
+	list := func(all bool, tags ...string) []string {
+		return sorted(collect(t, repo, owner, model.ListTasksParams{Limit: 10, Sort: model.SortCreatedAt, Tags: tags, TagsAll: all}))
+	}
+	if got := list(false, "home", "work"); fmt.Sprint(got) != "[both home work]" {
+		t.Errorf("any of home, work = %v", got)
+	}
+	if got := list(true, "home", "work"); fmt.Sprint(got) != "[both]" {
+		t.Errorf("all of home, work = %v", got)
+	}
+	if got := list(false, "nope"); len(got) != 0 {
+		t.Errorf("unknown tag = %v", got)
+	}
+
+	updated, err := repo.Update(ctx, owner, home.ID, model.UpdateTaskRequest{Tags: &[]string{"errands"}}, nil, at(ts(1)))
+	if err != nil || fmt.Sprint(updated.Tags) != "[errands]" {
+		t.Fatalf("Update tags: %v, %v", updated.Tags, err)
+	}
+	untouched, err := repo.Update(ctx, owner, home.ID, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(2)))
+	if err != nil || fmt.Sprint(untouched.Tags) != "[errands]" {
+		t.Fatalf("Update without tags changed them: %v, %v", untouched.Tags, err)
+	}
+	if err := repo.Delete(ctx, owner, both.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	counts, err := repo.Tags(ctx, owner, nil)
+	if err != nil {
+		t.Fatalf("Tags: %v", err)
+	}
+	if fmt.Sprint(counts) != "[{errands 1} {work 1}]" {
+		t.Errorf("Tags = %v, want errands and work once (trashed tasks do not count)", counts)
+	}
+	if counts, err := repo.Tags(ctx, newOwner(), nil); err != nil || len(counts) != 0 {
+		t.Errorf("someone else's tags = %v, %v", counts, err)
+	}
+
+	entries, _, err := repo.History(ctx, owner, home.ID, model.HistoryParams{Limit: 10})
+	if err != nil || len(entries) < 2 {
+		t.Fatalf("History: %v, %v", entries, err)
+	}
+	if c, ok := entries[1].Changes["tags"]; !ok || fmt.Sprint(c.From) != "[home]" || fmt.Sprint(c.To) != "[errands]" {
+		t.Errorf("tags change = %+v", entries[1].Changes)
+	}
+}
+
+func testSearch(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	create := func(owner, title, content string) model.Task {
+		t.Helper()
+		task, err := repo.Create(ctx, owner, store.NewTask{Title: title, Content: content, DueDate: date("2025-01-10"), RequestTimestamp: ts(0)})
+		if err != nil {
+			t.Fatalf("Create(%q): %v", title, err)
+		}
+		return task
+	}
+	create(owner, "Groceries", "buy milk and bread")
+	create(owner, "Milk run", "weekly errand")
+	create(owner, "Bread recipe", "flour water salt")
+	trashed := create(owner, "Milk trash", "spilled milk")
+	if err := repo.Delete(ctx, owner, trashed.ID, at(ts(1)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	create(newOwner(), "Milk elsewhere", "milk")
+
+	search := func(limit, offset int, terms ...model.SearchTerm) ([]model.SearchHit, bool) {
+		t.Helper()
+		hits, more, err := repo.Search(ctx, owner, model.SearchParams{Terms: terms, Limit: limit, Offset: offset})
+		if err != nil {
+			t.Fatalf("Search(%v): %v", terms, err)
+		}
+		return hits, more
+	}
+	titles := func(hits []model.SearchHit) []string {
+		out := make([]string, len(hits))
+		for i, h := range hits {
+			out[i] = h.Title
+		}
+		return out
+	}
+	word := func(s string) model.SearchTerm { return model.SearchTerm{Text: s} }
+
+	hits, more := search(10, 0, word("milk"))
+	if fmt.Sprint(titles(hits)) != "[Milk run Groceries]" || more {
+		t.Fatalf("milk = %v (more %v), want the title match first", titles(hits), more)
+	}
+	if hits[0].Rank <= hits[1].Rank {
+		t.Errorf("ranks = %v, %v", hits[0].Rank, hits[1].Rank)
+	}
+	if !strings.Contains(hits[0].Snippet, "<mark>Milk</mark>") || !strings.Contains(hits[1].Snippet, "<mark>milk</mark>") {
+		t.Errorf("snippets = %q, %q", hits[0].Snippet, hits[1].Snippet)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		terms []model.SearchTerm
+		want  string
+	}{
+		{"all words", []model.SearchTerm{word("bread"), word("milk")}, "[Groceries]"},
+		{"phrase", []model.SearchTerm{{Text: "buy milk", Phrase: true}}, "[Groceries]"},
+		{"phrase order", []model.SearchTerm{{Text: "milk buy", Phrase: true}}, "[]"},
+		{"prefix", []model.SearchTerm{{Text: "groc", Prefix: true}}, "[Groceries]"},
+		{"prefix of words", []model.SearchTerm{{Text: `bread\rec`, Prefix: true}}, "[Bread recipe]"},
+		{"prefix with tsquery syntax", []model.SearchTerm{{Text: `gro'c:&|!`, Prefix: true}}, "[]"},
+		{"no match", []model.SearchTerm{word("zebra")}, "[]"},
+	} {
+		if hits, _ := search(10, 0, tt.terms...); fmt.Sprint(titles(hits)) != tt.want {
+			t.Errorf("%s: %v, want %s", tt.name, titles(hits), tt.want)
+		}
+	}
+
+	first, more := search(1, 0, word("milk"))
+	second, last := search(1, 1, word("milk"))
+	if fmt.Sprint(titles(first), more, titles(second), last) != "[Milk run] true [Groceries] false" {
+		t.Errorf("pages = %v %v %v %v", titles(first), more, titles(second), last)
+	}
+}
+
+func testStatusWorkflow(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	task := mustCreate(t, repo, owner, "a", "2025-01-10")
+	if task.Status != model.StatusTodo || task.Priority != model.PriorityMedium || task.Done {
+		t.Fatalf("defaults = %s, %s, done %v", task.Status, task.Priority, task.Done)
+	}
+	done, err := repo.Create(ctx, owner, store.NewTask{Title: "b", Content: "c", DueDate: date("2025-01-10"), RequestTimestamp: ts(0), Status: model.StatusDone, Priority: model.PriorityLow})
+	if err != nil || !done.Done || done.Priority != model.PriorityLow {
+		t.Fatalf("Create done: %+v, %v", done, err)
+	}
+
+	update := func(n int, patch model.UpdateTaskRequest) (model.Task, error) {
+		cond := at(ts(n))
+		cond.Transition = func(from, to string) bool { return to != model.StatusCancelled }
+		return repo.Update(ctx, owner, task.ID, patch, nil, cond)
+	}
+	steps := []struct {
+		patch      model.UpdateTaskRequest
+		wantStatus string
+	}{
+		{model.UpdateTaskRequest{Done: ptr(true)}, model.StatusDone},
+		{model.UpdateTaskRequest{Done: ptr(false)}, model.StatusTodo},
+		{model.UpdateTaskRequest{Status: ptr(model.StatusInProgress), Priority: ptr(model.PriorityHigh)}, model.StatusInProgress},
+		{model.UpdateTaskRequest{Done: ptr(false)}, model.StatusInProgress}, // only reopens done tasks
+	}
+	for i, step := range steps {
+		got, err := update(i+1, step.patch)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got.Status != step.wantStatus || got.Done != (step.wantStatus == model.StatusDone) {
+			t.Fatalf("step %d: status %s, done %v, want %s", i, got.Status, got.Done, step.wantStatus)
+		}
+		task = got
+	}
+	if task.Priority != model.PriorityHigh {
+		t.Errorf("priority = %s", task.Priority)
+	}
+
+	var terr *store.TransitionError
+	if _, err := update(10, model.UpdateTaskRequest{Status: ptr(model.StatusCancelled)}); !errors.As(err, &terr) || !errors.Is(err, store.ErrInvalidTransition) {
+		t.Fatalf("forbidden transition: %v", err)
+	}
+	if terr.From != model.StatusInProgress || terr.To != model.StatusCancelled {
+		t.Errorf("TransitionError = %+v", terr)
+	}
+	if got, _ := repo.Get(ctx, owner, task.ID); got.Version != task.Version {
+		t.Errorf("forbidden transition changed the task (version %d, want %d)", got.Version, task.Version)
+	}
+
+	entries, _, err := repo.History(ctx, owner, task.ID, model.HistoryParams{Limit: 10})
+	if err != nil || len(entries) < 2 {
+		t.Fatalf("History: %v, %v", entries, err)
+	}
+	if c := entries[1].Changes["status"]; c.From != model.StatusTodo || c.To != model.StatusDone {
+		t.Errorf("status change = %+v", entries[1].Changes)
+	}
+
+	list := func(p model.ListTasksParams) []string {
+		p.Limit, p.Sort = 10, model.SortCreatedAt
+		return sorted(collect(t, repo, owner, p))
+	}
+	if got := list(model.ListTasksParams{Statuses: []string{model.StatusInProgress, model.StatusBlocked}}); fmt.Sprint(got) != "[a]" {
+		t.Errorf("status filter = %v", got)
+	}
+	if got := list(model.ListTasksParams{Priorities: []string{model.PriorityLow}}); fmt.Sprint(got) != "[b]" {
+		t.Errorf("priority filter = %v", got)
+	}
+	if got := list(model.ListTasksParams{Done: ptr(true)}); fmt.Sprint(got) != "[b]" {
+		t.Errorf("done filter = %v", got)
+	}
+}
+
+func testRecurrence(t *testing.T, repo store.TaskRepository) {
+	ctx := context.Background()
+	owner := newOwner()
+	first, err := repo.Create(ctx, owner, store.NewTask{
+		Title: "bins", Content: "take the bins out", DueDate: date("2025-01-06"), RequestTimestamp: ts(0),
+		Tags: []string{"chores"}, Priority: model.PriorityHigh, Recurrence: "FREQ=WEEKLY;COUNT=3",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if first.Recurrence != "FREQ=WEEKLY;COUNT=3" || first.RecurrenceStart != "2025-01-06" {
+		t.Fatalf("recurrence = %q from %q", first.Recurrence, first.RecurrenceStart)
+	}
+
+	open := func() []model.Task {
+		t.Helper()
+		tasks, _, err := repo.List(ctx, owner, model.ListTasksParams{Limit: 10, Sort: model.SortDueDate, Done: ptr(false)})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		return tasks
+	}
+	complete := func(n int, id string) model.Task {
+		t.Helper()
+		done, err := repo.Update(ctx, owner, id, model.UpdateTaskRequest{Done: ptr(true)}, nil, at(ts(n)))
+		if err != nil {
+			t.Fatalf("complete %s: %v", id, err)
+		}
+		return done
+	}
+
+	task := first
+	for i, want := range []string{"2025-01-13", "2025-01-20"} {
+		if done := complete(i+1, task.ID); done.Recurrence != "" || !done.Done {
+			t.Errorf("completed occurrence keeps recurrence %q", done.Recurrence)
+		}
+		next := open()
+		if len(next) != 1 {
+			t.Fatalf("after completing %s: %d open tasks, want 1", task.DueDate, len(next))
+		}
+		task = next[0]
+		if task.DueDate != want || task.Status != model.StatusTodo || task.Recurrence != first.Recurrence ||
+			task.RecurrenceStart != "2025-01-06" || task.Priority != model.PriorityHigh || fmt.Sprint(task.Tags) != "[chores]" {
+			t.Errorf("next occurrence = %+v, want due %s", task, want)
+		}
+	}
+
+	// COUNT=3: the third occurrence is the last
+	complete(3, task.ID)
+	if next := open(); len(next) != 0 {
+		t.Errorf("series over, yet %d open tasks", len(next))
+	}
+	// Reopening and completing again does not fork the series
+	if _, err := repo.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Done: ptr(false)}, nil, at(ts(4))); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	complete(5, task.ID)
+	if next := open(); len(next) != 0 {
+		t.Errorf("re-completing created %d tasks", len(next))
+	}
+
+	// A stopped series ends with the current occurrence
+	daily, err := repo.Create(ctx, owner, store.NewTask{Title: "plants", Content: "water", DueDate: date("2025-02-01"), RequestTimestamp: ts(0), Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	stopped, err := repo.Update(ctx, owner, daily.ID, model.UpdateTaskRequest{Recurrence: ptr("")}, nil, at(ts(1)))
+	if err != nil || stopped.Recurrence != "" || stopped.RecurrenceStart != "" {
+		t.Fatalf("stop: %+v, %v", stopped, err)
+	}
+	complete(2, daily.ID)
+	if next := open(); len(next) != 0 {
+		t.Errorf("stopped series created %d tasks", len(next))
+	}
+}
@@ -0,0 +1,201 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// WebhookStore is a backend of both sides of webhooks.
+type WebhookStore interface {
+	store.WebhookRepository
+	store.WebhookOutbox
+}
+
+// RunWebhookRepository checks a WebhookStore against the outbox written by
+// its TaskRepository. Other tests may share the stores: only the
+// subscriptions of fresh owners are looked at.
+func RunWebhookRepository(t *testing.T, newRepos func(t *testing.T) (store.TaskRepository, WebhookStore)) {
+	tasks, hooks := newRepos(t)
+	ctx := context.Background()
+	owner, other := newOwner(), newOwner()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	subscribe := func(owner string, events ...string) model.WebhookSubscription {
+		t.Helper()
+		w, err := hooks.CreateWebhook(ctx, owner, model.WebhookSubscription{URL: "https://example.com/" + owner, Events: events, Secret: "0123456789abcdef"})
+		if err != nil {
+			t.Fatalf("CreateWebhook: %v", err)
+		}
+		return w
+	}
+	fanout := func() {
+		t.Helper()
+		for {
+			n, err := hooks.Fanout(ctx, now, 100)
+			if err != nil {
+				t.Fatalf("Fanout: %v", err)
+			}
+			if n == 0 {
+				return
+			}
+		}
+	}
+	// pending returns the due deliveries of the subscriptions by subscription
+	pending := func(at time.Time, subs ...model.WebhookSubscription) map[string][]model.WebhookDelivery {
+		t.Helper()
+		all, err := hooks.Pending(ctx, at, 1000)
+		if err != nil {
+			t.Fatalf("Pending: %v", err)
+		}
+		out := make(map[string][]model.WebhookDelivery)
+		for _, d := range all {
+			for _, w := range subs {
+				if d.SubscriptionID == w.ID {
+					out[w.ID] = append(out[w.ID], d)
+				}
+			}
+		}
+		return out
+	}
+	types := func(ds []model.WebhookDelivery) string {
+		var out []string
+		for _, d := range ds {
+			out = append(out, d.Event.Type)
+		}
+		sort.Strings(out)
+		return fmt.Sprint(out)
+	}
+
+	all := subscribe(owner)
+	if all.ID == "" || !all.Active || all.Secret == "" || len(all.Events) != 0 {
+		t.Fatalf("created %+v", all)
+	}
+	deletes := subscribe(owner, model.EventTaskDeleted)
+	foreign := subscribe(other)
+
+	// Subscriptions are private to their owner
+	if list, err := hooks.ListWebhooks(ctx, owner); err != nil || len(list) != 2 || list[0].ID != all.ID || list[1].ID != deletes.ID || list[0].Secret != "" {
+		t.Errorf("ListWebhooks = %+v, %v", list, err)
+	}
+	if _, err := hooks.GetWebhook(ctx, other, all.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetWebhook by another owner: %v", err)
+	}
+	if _, err := hooks.UpdateWebhook(ctx, other, all.ID, model.UpdateWebhookRequest{Active: ptr(false)}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdateWebhook by another owner: %v", err)
+	}
+	if err := hooks.DeleteWebhook(ctx, other, all.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteWebhook by another owner: %v", err)
+	}
+	got, err := hooks.UpdateWebhook(ctx, owner, all.ID, model.UpdateWebhookRequest{URL: ptr("https://example.com/v2")})
+	if err != nil || got.URL != "https://example.com/v2" || !got.Active || len(got.Events) != 0 {
+		t.Fatalf("UpdateWebhook = %+v, %v", got, err)
+	}
+
+	// Every mutation writes an event, unless rolled back
+	task := mustCreate(t, tasks, owner, "hooked", "2025-01-10")
+	if _, err := tasks.Update(ctx, owner, task.ID, model.UpdateTaskRequest{Title: ptr("renamed")}, nil, at(ts(1))); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	ops := []store.BatchOp{
+		{Kind: model.BatchOpCreate, New: store.NewTask{Title: "rolled back", Content: "c", DueDate: date("2025-02-01"), RequestTimestamp: ts(0)}},
+		{Kind: model.BatchOpDelete, ID: task.ID, Cond: at(ts(1))},
+	}
+	if _, err := tasks.Batch(ctx, owner, ops, true); err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if err := tasks.Delete(ctx, owner, task.ID, at(ts(2)), store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	fanout()
+
+	due := pending(now, all, deletes, foreign)
+	if got := types(due[all.ID]); got != "[task.created task.deleted task.updated]" {
+		t.Errorf("deliveries to every event = %s", got)
+	}
+	if got := types(due[deletes.ID]); got != "[task.deleted]" {
+		t.Fatalf("deliveries to task.deleted = %s", got)
+	}
+	if len(due[foreign.ID]) != 0 {
+		t.Errorf("another owner got %d deliveries", len(due[foreign.ID]))
+	}
+	d := due[deletes.ID][0]
+	if d.Attempt != 1 || d.URL != "https://example.com/"+owner || d.Secret != "0123456789abcdef" || d.Event.ID == "" ||
+		d.Event.Task.ID != task.ID || d.Event.Task.DeletedAt == nil || d.Event.Task.Title != "renamed" {
+		t.Errorf("delivery = %+v", d)
+	}
+
+	// Retry, then dead letter
+	retryAt := now.Add(time.Minute)
+	record := func(d model.WebhookDelivery, outcome string, status int, retryAt *time.Time) {
+		t.Helper()
+		a := model.WebhookAttempt{DeliveryID: d.ID, Attempt: d.Attempt, Outcome: outcome, StatusCode: status, DurationMS: 12, CreatedAt: now}
+		if outcome != model.AttemptDelivered {
+			a.Error = "boom"
+		}
+		if err := hooks.RecordAttempt(ctx, a, retryAt); err != nil {
+			t.Fatalf("RecordAttempt: %v", err)
+		}
+	}
+	record(d, model.AttemptRetry, 503, &retryAt)
+	if got := pending(now, deletes); len(got[deletes.ID]) != 0 {
+		t.Errorf("retried before its time: %+v", got)
+	}
+	retry := pending(retryAt, deletes)[deletes.ID]
+	if len(retry) != 1 || retry[0].ID != d.ID || retry[0].Attempt != 2 || retry[0].Event.ID != d.Event.ID {
+		t.Fatalf("retry = %+v", retry)
+	}
+	record(retry[0], model.AttemptDead, 0, nil)
+	for _, d := range due[all.ID] {
+		record(d, model.AttemptDelivered, 204, nil)
+	}
+	if got := pending(retryAt.Add(time.Hour), all, deletes); len(got) != 0 {
+		t.Errorf("pending after delivery = %+v", got)
+	}
+
+	log, next, err := hooks.Attempts(ctx, owner, deletes.ID, model.HistoryParams{Limit: 10})
+	if err != nil || next != 0 || len(log) != 2 {
+		t.Fatalf("Attempts = %+v, %d, %v", log, next, err)
+	}
+	if log[0].Outcome != model.AttemptRetry || log[0].StatusCode != 503 || log[0].Error != "boom" || log[0].Attempt != 1 ||
+		log[0].EventID != d.Event.ID || log[0].EventType != model.EventTaskDeleted || log[0].DeliveryID != d.ID || log[0].DurationMS != 12 ||
+		log[1].Outcome != model.AttemptDead || log[1].StatusCode != 0 || log[1].Attempt != 2 {
+		t.Errorf("attempts = %+v", log)
+	}
+	page, next, err := hooks.Attempts(ctx, owner, all.ID, model.HistoryParams{Limit: 2})
+	if err != nil || len(page) != 2 || next != page[1].ID {
+		t.Fatalf("Attempts page 1 = %+v, %d, %v", page, next, err)
+	}
+	page, next, err = hooks.Attempts(ctx, owner, all.ID, model.HistoryParams{Limit: 2, AfterID: next})
+	if err != nil || len(page) != 1 || next != 0 || page[0].Outcome != model.AttemptDelivered {
+		t.Errorf("Attempts page 2 = %+v, %d, %v", page, next, err)
+	}
+	if _, _, err := hooks.Attempts(ctx, other, all.ID, model.HistoryParams{Limit: 10}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Attempts by another owner: %v", err)
+	}
+
+	// Paused subscriptions get nothing; deleted ones are gone with their log
+	if _, err := hooks.UpdateWebhook(ctx, owner, all.ID, model.UpdateWebhookRequest{Active: ptr(false)}); err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+	mustCreate(t, tasks, owner, "unheard", "2025-01-11")
+	fanout()
+	if got := pending(now, all); len(got) != 0 {
+		t.Errorf("paused subscription got %+v", got)
+	}
+	if err := hooks.DeleteWebhook(ctx, owner, deletes.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := hooks.GetWebhook(ctx, owner, deletes.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetWebhook after delete: %v", err)
+	}
+	if _, _, err := hooks.Attempts(ctx, owner, deletes.ID, model.HistoryParams{Limit: 10}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Attempts after delete: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package store
+
+import "context"
+
+// DefaultTenant is the tenant of callers whose token names none, and of the
+// rows that predate tenants.
+const DefaultTenant = "default"
+
+type tenantKey struct{}
+
+// WithTenant attaches the caller's tenant to ctx. The Postgres and memory
+// stores only see the rows of that tenant, whatever their queries say.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant attached to ctx, DefaultTenant if none.
+func TenantFrom(ctx context.Context) string {
+	if t, _ := ctx.Value(tenantKey{}).(string); t != "" {
+		return t
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"team5/task-manager/internal/model"
+)
+
+// Delivery states of a webhook event for one subscription.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // dead letter: given up after the last attempt
+)
+
+// EventType is the webhook event of a task history action.
+func EventType(action string) string {
+	switch action {
+	case model.ActionCreated:
+		return model.EventTaskCreated
+	case model.ActionDeleted:
+		return model.EventTaskDeleted
+	}
+	return model.EventTaskUpdated
+}
+
+// WebhookRepository manages the webhook subscriptions of an owner. Like
+// TaskRepository, someone else's subscription behaves like a missing one.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, ownerID string, in model.WebhookSubscription) (model.WebhookSubscription, error)
+	// ListWebhooks returns the owner's subscriptions, oldest first.
+	ListWebhooks(ctx context.Context, ownerID string) ([]model.WebhookSubscription, error)
+	GetWebhook(ctx context.Context, ownerID, id string) (model.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, ownerID, id string, patch model.UpdateWebhookRequest) (model.WebhookSubscription, error)
+	// DeleteWebhook removes the subscription with its deliveries and log.
+	DeleteWebhook(ctx context.Context, ownerID, id string) error
+	// Attempts returns one page of the subscription's delivery log, oldest
+	// first, and the ID to resume after (0 on the last page).
+	Attempts(ctx context.Context, ownerID, id string, p model.HistoryParams) ([]model.WebhookAttempt, int64, error)
+}
+
+// WebhookOutbox is the dispatcher side of webhooks. The TaskRepository writes
+// an event to the outbox in the transaction of every mutation; the outbox
+// turns each into one delivery per matching subscription.
+type WebhookOutbox interface {
+	// Fanout creates, attemptable from now, the deliveries of up to limit
+	// undispatched events, oldest first, to the active subscriptions of their
+	// owner that want them. It returns how many events were dispatched.
+	Fanout(ctx context.Context, now time.Time, limit int) (int64, error)
+	// Pending returns up to limit pending deliveries of active subscriptions
+	// whose next attempt is due at now, oldest first.
+	Pending(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	// RecordAttempt logs attempt a of its delivery and moves the delivery on
+	// according to a.Outcome: delivered, retried at retryAt, or dead-lettered.
+	RecordAttempt(ctx context.Context, a model.WebhookAttempt, retryAt *time.Time) error
+}
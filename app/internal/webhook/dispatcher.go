@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"team5/task-manager/internal/jobs"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+)
+
+// LockKey is the Postgres advisory lock key of the dispatcher.
+const LockKey int64 = 0x7461736b77656231 // "taskweb1"
+
+var attempts = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "webhook_delivery_attempts_total",
+		Help: "Webhook delivery attempts by outcome (delivered, retry, dead)",
+	},
+	[]string{"outcome"},
+)
+
+// Locker elects the replica that runs a tick.
+type Locker interface {
+	// TryLock returns ok=false without waiting when someone else holds the lock.
+	TryLock(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+type DispatcherOptions struct {
+	// MaxAttempts per delivery before dead-lettering it (10 if zero)
+	MaxAttempts int
+	// Retries wait BaseBackoff, doubled on each attempt up to MaxBackoff (30s and 1h if zero)
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BatchSize caps the events fanned out and the deliveries attempted per tick (100 if zero)
+	BatchSize int
+	// Concurrency bounds the deliveries in flight (10 if zero), so a tick
+	// lasts at most BatchSize/Concurrency timeouts however slow some
+	// subscribers are
+	Concurrency int
+	// Timeout bounds each delivery (10s if zero), whatever is left of the tick
+	Timeout time.Duration
+}
+
+// Dispatcher delivers the task events of the outbox to the subscriptions.
+// Every replica runs it; the lock lets one of them work at a time.
+type Dispatcher struct {
+	outbox store.WebhookOutbox
+	client *http.Client
+	lock   Locker
+	log    *slog.Logger
+	opts   DispatcherOptions
+	now    func() time.Time
+}
+
+func NewDispatcher(outbox store.WebhookOutbox, client *http.Client, lock Locker, log *slog.Logger, opts DispatcherOptions) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &Dispatcher{outbox: outbox, client: client, lock: lock, log: log.With("job", "webhooks"), opts: opts, now: time.Now}
+}
+
+// Tick fans the new outbox events out to the subscriptions and attempts the
+// pending deliveries, if this replica gets the lock. Meant for jobs.Every.
+// Deliveries run concurrently: the events of a subscription may arrive out
+// of order, receivers order them by created_at.
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	unlock, ok, err := d.lock.TryLock(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	if _, err := d.outbox.Fanout(ctx, d.now(), d.opts.BatchSize); err != nil {
+		return err
+	}
+	pending, err := d.outbox.Pending(ctx, d.now(), d.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, d.opts.Concurrency)
+	for _, del := range pending {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			if err := d.deliver(ctx, del); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// deliver attempts one delivery and records the attempt. A request under way
+// when the tick ends runs to its own timeout: cutting it short would leave
+// the delivery pending while the receiver may have processed it.
+func (d *Dispatcher) deliver(ctx context.Context, del model.WebhookDelivery) error {
+	body, err := json.Marshal(del.Event)
+	if err != nil {
+		return err
+	}
+	reqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.opts.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		if !errors.Is(context.Cause(ctx), jobs.ErrOverrun) {
+			cancel()
+		}
+	})
+	defer stop()
+
+	start := d.now()
+	status, postErr := Post(reqCtx, d.client, del.URL, del.Secret, del.Event.ID, body)
+	if postErr != nil && ctx.Err() != nil && !errors.Is(context.Cause(ctx), jobs.ErrOverrun) {
+		// Shutting down: the delivery stays pending
+		return ctx.Err()
+	}
+
+	at := d.now()
+	a := model.WebhookAttempt{
+		DeliveryID: del.ID,
+		EventID:    del.Event.ID,
+		EventType:  del.Event.Type,
+		Attempt:    del.Attempt,
+		Outcome:    model.AttemptDelivered,
+		StatusCode: status,
+		DurationMS: at.Sub(start).Milliseconds(),
+		CreatedAt:  at,
+	}
+	var retryAt *time.Time
+	switch {
+	case postErr == nil:
+	case del.Attempt < d.opts.MaxAttempts && Retryable(postErr):
+		a.Outcome, a.Error = model.AttemptRetry, postErr.Error()
+		next := at.Add(Backoff(d.opts.BaseBackoff, d.opts.MaxBackoff, del.Attempt))
+		retryAt = &next
+		d.log.Warn("webhook delivery failed, will retry", "delivery_id", del.ID, "subscription_id", del.SubscriptionID, "attempt", del.Attempt, "retry_at", next, "error", postErr)
+	default:
+		a.Outcome, a.Error = model.AttemptDead, postErr.Error()
+		d.log.Error("webhook delivery failed, dead-lettered", "delivery_id", del.ID, "subscription_id", del.SubscriptionID, "attempt", del.Attempt, "error", postErr)
+	}
+	attempts.WithLabelValues(a.Outcome).Inc()
+	// Recorded even past the end of the tick, so the attempt counts
+	recCtx, recCancel := context.WithTimeout(context.WithoutCancel(ctx), d.opts.Timeout)
+	defer recCancel()
+	return d.outbox.RecordAttempt(recCtx, a, retryAt)
+}
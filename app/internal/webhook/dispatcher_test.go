@@ -0,0 +1,332 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"team5/task-manager/internal/jobs"
+	"team5/task-manager/internal/model"
+	"team5/task-manager/internal/store"
+	"team5/task-manager/internal/store/memory"
+)
+
+const (
+	owner  = "alice"
+	secret = "0123456789abcdef"
+)
+
+type fakeLock struct{ busy bool }
+
+func (l *fakeLock) TryLock(context.Context) (func(), bool, error) {
+	if l.busy {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+// receiver is a webhook endpoint answering with the queued statuses, then 204.
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	got      []model.TaskEvent
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	unix, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if !Verify(secret, time.Unix(unix, 0), body, r.Header.Get(HeaderSignature)) {
+		rc.t.Errorf("bad signature %q", r.Header.Get(HeaderSignature))
+	}
+	var e model.TaskEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		rc.t.Errorf("decode: %v", err)
+	}
+	if r.Header.Get(HeaderID) != e.ID {
+		rc.t.Errorf("%s = %q, want %q", HeaderID, r.Header.Get(HeaderID), e.ID)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.got = append(rc.got, e)
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+type fixture struct {
+	tasks *memory.TasksStore
+	hooks *memory.WebhookStore
+	sub   model.WebhookSubscription
+	recv  *receiver
+	lock  *fakeLock
+	disp  *Dispatcher
+	now   time.Time
+}
+
+func newFixture(t *testing.T, statuses ...int) *fixture {
+	f := &fixture{
+		tasks: memory.NewTasksStore(),
+		recv:  &receiver{t: t, statuses: statuses},
+		lock:  &fakeLock{},
+		now:   time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+	}
+	f.hooks = memory.NewWebhookStore(f.tasks)
+	srv := httptest.NewServer(f.recv)
+	t.Cleanup(srv.Close)
+
+	var err error
+	f.sub, err = f.hooks.CreateWebhook(context.Background(), owner, model.WebhookSubscription{URL: srv.URL, Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f.disp = NewDispatcher(f.hooks, srv.Client(), f.lock, log, DispatcherOptions{MaxAttempts: 3})
+	f.disp.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fixture) create(t *testing.T, title string) model.Task {
+	t.Helper()
+	task, err := f.tasks.Create(context.Background(), owner, store.NewTask{Title: title, Content: "c", DueDate: f.now, RequestTimestamp: f.now})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return task
+}
+
+func (f *fixture) tick(t *testing.T) {
+	t.Helper()
+	if err := f.disp.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+}
+
+func (f *fixture) log(t *testing.T) []model.WebhookAttempt {
+	t.Helper()
+	log, _, err := f.hooks.Attempts(context.Background(), owner, f.sub.ID, model.HistoryParams{Limit: 100})
+	if err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	return log
+}
+
+func outcomes(log []model.WebhookAttempt) []string {
+	out := make([]string, len(log))
+	for i, a := range log {
+		out[i] = strconv.Itoa(a.Attempt) + ":" + a.Outcome
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	f := newFixture(t)
+	task := f.create(t, "first")
+	if err := f.tasks.Delete(context.Background(), owner, task.ID, store.Precondition{}, store.ChildrenOrphan); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	f.tick(t)
+	f.now = f.now.Add(time.Minute)
+	f.tick(t)
+
+	if len(f.recv.got) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(f.recv.got))
+	}
+	types := map[string]bool{}
+	for _, e := range f.recv.got {
+		types[e.Type] = true
+		if e.Task.ID != task.ID || e.ID == "" {
+			t.Errorf("delivered %+v", e)
+		}
+	}
+	if !types[model.EventTaskCreated] || !types[model.EventTaskDeleted] {
+		t.Errorf("event types = %v", types)
+	}
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:delivered", "1:delivered"}) {
+		t.Errorf("log = %v", got)
+	}
+	if a := f.log(t)[0]; a.StatusCode != http.StatusNoContent || a.Error != "" {
+		t.Errorf("attempt = %+v", a)
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	f := newFixture(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	f.create(t, "first")
+
+	f.tick(t) // 503, retry in 30s
+	f.now = f.now.Add(20 * time.Second)
+	f.tick(t) // too early
+	f.now = f.now.Add(10 * time.Second)
+	f.tick(t) // 429, retry in 1m
+	f.now = f.now.Add(time.Minute)
+	f.tick(t) // 204
+	f.now = f.now.Add(time.Hour)
+	f.tick(t)
+
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:retry", "2:retry", "3:delivered"}) {
+		t.Errorf("log = %v", got)
+	}
+	if ids := f.recv.got; len(ids) != 3 || ids[0].ID != ids[2].ID {
+		t.Errorf("the event id changed across retries: %+v", ids)
+	}
+	if a := f.log(t)[0]; a.StatusCode != http.StatusServiceUnavailable || a.Error == "" || a.EventType != model.EventTaskCreated {
+		t.Errorf("attempt = %+v", a)
+	}
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	f := newFixture(t, 500, 500, 500, 500)
+	f.create(t, "first")
+	for range 5 {
+		f.tick(t)
+		f.now = f.now.Add(time.Hour)
+	}
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:retry", "2:retry", "3:dead"}) {
+		t.Errorf("log = %v, want dead after MaxAttempts", got)
+	}
+
+	f = newFixture(t, http.StatusGone)
+	f.create(t, "first")
+	f.tick(t)
+	f.now = f.now.Add(time.Hour)
+	f.tick(t)
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:dead"}) {
+		t.Errorf("log after 410 = %v", got)
+	}
+}
+
+func TestDispatcherSkips(t *testing.T) {
+	f := newFixture(t)
+	f.lock.busy = true
+	f.create(t, "first")
+	f.tick(t)
+	if len(f.recv.got) != 0 {
+		t.Fatalf("delivered without the lock")
+	}
+
+	// A paused subscription gets nothing
+	f.lock.busy = false
+	if _, err := f.hooks.UpdateWebhook(context.Background(), owner, f.sub.ID, model.UpdateWebhookRequest{Active: &[]bool{false}[0]}); err != nil {
+		t.Fatal(err)
+	}
+	f.tick(t)
+	if len(f.recv.got) != 0 {
+		t.Errorf("delivered to a paused subscription")
+	}
+}
+
+func TestDispatcherDeliversConcurrently(t *testing.T) {
+	f := newFixture(t)
+	// Each request waits for the other one: sequential deliveries time out
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		barrier.Done()
+		done := make(chan struct{})
+		go func() { barrier.Wait(); close(done) }()
+		select {
+		case <-done:
+			w.WriteHeader(http.StatusNoContent)
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(slow.Close)
+	for _, path := range []string{"/a", "/b"} {
+		if _, err := f.hooks.CreateWebhook(context.Background(), owner, model.WebhookSubscription{URL: slow.URL + path, Events: []string{model.EventTaskCreated}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.create(t, "first")
+	f.tick(t)
+
+	hooks, err := f.hooks.ListWebhooks(context.Background(), owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range hooks {
+		log, _, err := f.hooks.Attempts(context.Background(), owner, w.ID, model.HistoryParams{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := outcomes(log); !equal(got, []string{"1:delivered"}) {
+			t.Errorf("%s: log = %v", w.URL, got)
+		}
+	}
+}
+
+func TestDispatcherOutlivesTheTick(t *testing.T) {
+	f := newFixture(t)
+	delay := 300 * time.Millisecond
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(slow.Close)
+	if _, err := f.hooks.UpdateWebhook(context.Background(), owner, f.sub.ID, model.UpdateWebhookRequest{URL: &slow.URL}); err != nil {
+		t.Fatal(err)
+	}
+	// Like jobs.Every, whose interval is shorter than the receiver
+	tick := func() {
+		ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, jobs.ErrOverrun)
+		defer cancel()
+		_ = f.disp.Tick(ctx)
+	}
+
+	f.create(t, "first")
+	tick()
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:delivered"}) {
+		t.Fatalf("log = %v, want the delivery completed past the tick", got)
+	}
+
+	// A receiver slower than the delivery timeout is a failed attempt
+	f.disp.opts.Timeout = 100 * time.Millisecond
+	f.create(t, "second")
+	tick()
+	if got := outcomes(f.log(t)); !equal(got, []string{"1:delivered", "1:retry"}) {
+		t.Errorf("log = %v, want the timeout recorded", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 8: time.Hour, 40: time.Hour} {
+		if got := Backoff(30*time.Second, time.Hour, attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	sig := Sign(secret, ts, []byte(`{"a":1}`))
+	if !Verify(secret, ts, []byte(`{"a":1}`), sig) {
+		t.Errorf("Verify rejected its own signature")
+	}
+	if Verify(secret, ts.Add(time.Second), []byte(`{"a":1}`), sig) || Verify("other", ts, []byte(`{"a":1}`), sig) || Verify(secret, ts, []byte(`{"a":2}`), sig) {
+		t.Errorf("Verify accepted a forged signature")
+	}
+}
@@ -0,0 +1,102 @@
+// Package webhook posts signed JSON events to HTTP endpoints, and dispatches
+// the task events of the outbox to the owners' subscriptions.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">",
+	// only when a secret is configured.
+	HeaderSignature = "X-Webhook-Signature"
+
+	userAgent = "task-manager-webhooks/1"
+)
+
+// Sign returns the HeaderSignature value of body sent at ts.
+func Sign(secret string, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a HeaderSignature value in constant time.
+func Verify(secret string, ts time.Time, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// StatusError is a delivery answered with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Retryable reports whether a failed delivery may succeed later: transport
+// errors, timeouts, 408, 429 and 5xx answers are; other 4xx answers and
+// forbidden destinations are not.
+func Retryable(err error) bool {
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return err != nil && !errors.Is(err, ErrForbiddenAddress)
+	}
+	return se.StatusCode == http.StatusRequestTimeout || se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+}
+
+// Backoff is the wait after failed attempt number attempt (1-based): base,
+// doubled on each attempt up to limit.
+func Backoff(base, limit time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Post sends body to url as JSON and returns the answer's status code (0
+// without an answer). id identifies the event across retries so receivers can
+// deduplicate; the body is signed when secret is not empty.
+func Post(ctx context.Context, client *http.Client, url, secret, id string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, id)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, now, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
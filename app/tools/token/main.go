@@ -9,7 +9,7 @@ import (
 
 func main() {
 	secret := []byte("devsecret")
-	claims := jwt.MapClaims{"sub": "dev", "exp": time.Now().Add(24 * time.Hour).Unix()}
+	claims := jwt.MapClaims{"sub": "dev", "scope": "tasks:admin", "exp": time.Now().Add(24 * time.Hour).Unix()}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	s, _ := t.SignedString(secret)
 	fmt.Println(s)